@@ -18,38 +18,27 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/admin/cars": {
-            "post": {
-                "description": "Create new car",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/api-keys": {
+            "get": {
+                "description": "Get all API keys including revoked ones, keys are only returned when they are created",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Create car",
-                "parameters": [
-                    {
-                        "description": "Create new car",
-                        "name": "car",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.Car"
-                        }
-                    }
-                ],
+                "summary": "Get all API keys",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "car": {
-                                    "$ref": "#/definitions/entity.Car"
+                                "api_keys": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.APIKey"
+                                    }
                                 },
                                 "message": {
                                     "type": "string"
@@ -57,30 +46,22 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
-                        }
-                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/admin/cars/{car_id}": {
-            "put": {
-                "description": "Update car by id",
+            },
+            "post": {
+                "description": "Create an API key for a service to act as a user with the X-API-Key header, the key is only in this response. Reads need the \u003cresource\u003e:read scope and writes the \u003cresource\u003e:write scope, which grants reads too. Only admins' keys may have admin scopes",
                 "consumes": [
                     "application/json"
                 ],
@@ -90,33 +71,29 @@ const docTemplate = `{
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Update car",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "car update by car_id",
-                        "name": "car",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Update car",
-                        "name": "car",
+                        "description": "Create new API key",
+                        "name": "api_key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Car"
+                            "$ref": "#/definitions/dto.APIKey"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "car": {
-                                    "$ref": "#/definitions/entity.Car"
+                                "api_key": {
+                                    "$ref": "#/definitions/entity.APIKey"
+                                },
+                                "key": {
+                                    "type": "string"
                                 },
                                 "message": {
                                     "type": "string"
@@ -127,47 +104,52 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/api-keys/{api_key_id}": {
             "delete": {
-                "description": "Delete car by id",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Revoke API key by id, requests with it are rejected from then on",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Delete car",
+                "summary": "Revoke API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "car delete by car_id",
-                        "name": "car",
-                        "in": "query",
+                        "description": "API key id",
+                        "name": "api_key_id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -186,154 +168,216 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/rental-history": {
-            "get": {
-                "description": "Get rental history",
+        "/admin/branches": {
+            "post": {
+                "description": "Create new branch",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Get rental history",
+                "summary": "Create branch",
+                "parameters": [
+                    {
+                        "description": "Create new branch",
+                        "name": "branch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Branch"
+                        }
+                    }
+                ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "branch": {
+                                    "$ref": "#/definitions/entity.Branch"
+                                },
                                 "message": {
                                     "type": "string"
-                                },
-                                "rental_history": {
-                                    "type": "array",
-                                    "items": {
-                                        "$ref": "#/definitions/dto.RentalHistory"
-                                    }
                                 }
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/users": {
-            "get": {
-                "description": "Get all users",
+        "/admin/branches/{branch_id}": {
+            "put": {
+                "description": "Update branch by id",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Get all users",
+                "summary": "Update branch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "branch id",
+                        "name": "branch_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update branch",
+                        "name": "branch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Branch"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "branch": {
+                                    "$ref": "#/definitions/entity.Branch"
+                                },
                                 "message": {
                                     "type": "string"
-                                },
-                                "users": {
-                                    "type": "array",
-                                    "items": {
-                                        "$ref": "#/definitions/entity.User"
-                                    }
                                 }
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/cars": {
-            "get": {
-                "description": "Get all cars",
+            },
+            "delete": {
+                "description": "Delete branch by id, branches that still have cars assigned can't be deleted",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Car"
+                    "Admin"
+                ],
+                "summary": "Delete branch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "branch id",
+                        "name": "branch_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all cars",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "cars": {
-                                    "type": "array",
-                                    "items": {
-                                        "$ref": "#/definitions/entity.Car"
-                                    }
-                                },
                                 "message": {
                                     "type": "string"
                                 }
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/cars": {
             "post": {
-                "description": "Rent a car",
+                "description": "Create new car",
                 "consumes": [
                     "application/json"
                 ],
@@ -341,17 +385,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Car"
+                    "Admin"
                 ],
-                "summary": "Rent a car",
+                "summary": "Create car",
                 "parameters": [
                     {
-                        "description": "user rent a car",
-                        "name": "rental",
+                        "description": "Create new car",
+                        "name": "car",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Rental"
+                            "$ref": "#/definitions/dto.Car"
                         }
                     }
                 ],
@@ -361,14 +405,11 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "invoice": {
-                                    "$ref": "#/definitions/entity.Invoice"
+                                "car": {
+                                    "$ref": "#/definitions/entity.Car"
                                 },
                                 "message": {
                                     "type": "string"
-                                },
-                                "rental": {
-                                    "$ref": "#/definitions/entity.Rental"
                                 }
                             }
                         }
@@ -376,33 +417,33 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/cars/pay/{rental_id}": {
-            "post": {
-                "description": "Pay rented car",
+        "/admin/cars/{car_id}": {
+            "put": {
+                "description": "Update car by id",
                 "consumes": [
                     "application/json"
                 ],
@@ -410,24 +451,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Car"
+                    "Admin"
                 ],
-                "summary": "Pay rented car",
+                "summary": "Update car",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "pay rental car by rental_id",
-                        "name": "pay",
+                        "description": "car update by car_id",
+                        "name": "car",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "user pay rented a car",
-                        "name": "pay",
+                        "description": "Update car",
+                        "name": "car",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Payment"
+                            "$ref": "#/definitions/dto.Car"
                         }
                     }
                 ],
@@ -437,11 +478,11 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "car": {
+                                    "$ref": "#/definitions/entity.Car"
+                                },
                                 "message": {
                                     "type": "string"
-                                },
-                                "payment": {
-                                    "$ref": "#/definitions/entity.Payment"
                                 }
                             }
                         }
@@ -449,33 +490,37 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/cars/return/{rental_id}": {
-            "post": {
-                "description": "Return rented car",
+            },
+            "delete": {
+                "description": "Delete car by id",
                 "consumes": [
                     "application/json"
                 ],
@@ -483,14 +528,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Car"
+                    "Admin"
                 ],
-                "summary": "Return rented car",
+                "summary": "Delete car",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "return rental car by rental_id",
-                        "name": "rental",
+                        "description": "car delete by car_id",
+                        "name": "car",
                         "in": "query",
                         "required": true
                     }
@@ -510,56 +555,63 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/cars/{category_id}": {
-            "get": {
-                "description": "Get cars by category id",
+        "/admin/cars/{car_id}/images": {
+            "post": {
+                "description": "Upload one or more jpeg/png images for a car, a thumbnail is generated for each image",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Car"
+                    "Admin"
                 ],
-                "summary": "Get cars by category",
+                "summary": "Upload car images",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "cars search by category_id",
-                        "name": "category",
-                        "in": "query",
+                        "description": "car id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "car images",
+                        "name": "images",
+                        "in": "formData",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "cars": {
+                                "images": {
                                     "type": "array",
                                     "items": {
-                                        "$ref": "#/definitions/entity.Car"
+                                        "$ref": "#/definitions/entity.CarImage"
                                     }
                                 },
                                 "message": {
@@ -568,30 +620,99 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/users/login": {
-            "post": {
-                "description": "User do login",
+        "/admin/cars/{car_id}/images/{image_id}": {
+            "delete": {
+                "description": "Delete a car image, the next image becomes primary when the primary image is deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete car image",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "car id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "image id",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the position of a car image or make it the primary image",
                 "consumes": [
                     "application/json"
                 ],
@@ -599,17 +720,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "User login",
+                "summary": "Update car image",
                 "parameters": [
                     {
-                        "description": "login user",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "car id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "image id",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "image position and primary flag",
+                        "name": "image",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Login"
+                            "$ref": "#/definitions/dto.CarImage"
                         }
                     }
                 ],
@@ -619,10 +754,10 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "message": {
-                                    "type": "string"
+                                "image": {
+                                    "$ref": "#/definitions/entity.CarImage"
                                 },
-                                "token": {
+                                "message": {
                                     "type": "string"
                                 }
                             }
@@ -631,59 +766,200 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/users/register": {
-            "post": {
-                "description": "Create new users",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/login-attempts": {
+            "get": {
+                "description": "Get the latest login attempts, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "Create Users",
+                "summary": "Get login attempts",
                 "parameters": [
                     {
-                        "description": "Create new user",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "format": "date",
+                        "description": "first attempt date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last attempt date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "attempted email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "successful attempts only, or failed attempts only",
+                        "name": "success",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "invalid_credentials",
+                            "locked",
+                            "rate_limited"
+                        ],
+                        "type": "string",
+                        "description": "rejection reason",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "maximum attempts, defaults to 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.User"
+                            "type": "object",
+                            "properties": {
+                                "login_attempts": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.LoginAttempt"
+                                    }
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/payments": {
+            "get": {
+                "description": "Get all payments, as JSON or as a csv or xlsx spreadsheet",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first payment date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last payment date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paid by user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "settlement"
+                        ],
+                        "type": "string",
+                        "description": "payment status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "response format, defaults to json",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "properties": {
                                 "message": {
                                     "type": "string"
                                 },
-                                "user": {
-                                    "$ref": "#/definitions/entity.User"
+                                "payments": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.PaymentHistory"
+                                    }
                                 }
                             }
                         }
@@ -691,21 +967,68 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/users/topup": {
+        "/admin/pricing-rules": {
+            "get": {
+                "description": "Get all pricing rules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all pricing rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "pricing_rules": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.PricingRule"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "User top up",
+                "description": "Create new pricing rule, percent is a surcharge when positive and a discount when negative",
                 "consumes": [
                     "application/json"
                 ],
@@ -713,17 +1036,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "User top up",
+                "summary": "Create pricing rule",
                 "parameters": [
                     {
-                        "description": "top up",
-                        "name": "car",
+                        "description": "Create new pricing rule",
+                        "name": "rule",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.TopUp"
+                            "$ref": "#/definitions/dto.PricingRule"
                         }
                     }
                 ],
@@ -733,11 +1056,11 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "invoice": {
-                                    "$ref": "#/definitions/entity.Invoice"
-                                },
                                 "message": {
                                     "type": "string"
+                                },
+                                "pricing_rule": {
+                                    "$ref": "#/definitions/entity.PricingRule"
                                 }
                             }
                         }
@@ -745,277 +1068,4033 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.Car": {
-            "type": "object",
-            "properties": {
-                "capacity": {
-                    "type": "number"
-                },
-                "category_id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "rental_cost_per_day": {
-                    "type": "number"
-                }
-            }
-        },
-        "dto.CarRentalHistory": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
         },
-        "dto.Login": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
-            "properties": {
+        "/admin/pricing-rules/{rule_id}": {
+            "put": {
+                "description": "Update pricing rule by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update pricing rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "pricing rule id",
+                        "name": "rule_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update pricing rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PricingRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "pricing_rule": {
+                                    "$ref": "#/definitions/entity.PricingRule"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete pricing rule by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete pricing rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "pricing rule id",
+                        "name": "rule_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rental-history": {
+            "get": {
+                "description": "Get rental history including unpaid rentals, as JSON or as a csv or xlsx spreadsheet",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get rental history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first rental date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last rental date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rented by user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rented car",
+                        "name": "car_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "category of the rented car",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "unpaid",
+                            "settlement"
+                        ],
+                        "type": "string",
+                        "description": "payment status",
+                        "name": "payment_status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "booked",
+                            "ongoing",
+                            "overdue",
+                            "returned"
+                        ],
+                        "type": "string",
+                        "description": "rental status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "search user name, user email and car name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rental_id",
+                            "rental_date",
+                            "return_date",
+                            "total_price",
+                            "fullname",
+                            "car_name"
+                        ],
+                        "type": "string",
+                        "description": "sort column, defaults to rental_date",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "sort order, defaults to desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "response format, defaults to json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "rental_history": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.RentalHistory"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/coupons": {
+            "get": {
+                "description": "Number of paid rentals starting in the period that used every coupon and the discount they cost",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Coupon usage report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "coupons": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.CouponUsage"
+                                    }
+                                },
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "to": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/rental-length": {
+            "get": {
+                "description": "Average length of the paid rentals starting in the period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Rental length report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "rental_length": {
+                                    "$ref": "#/definitions/dto.RentalLength"
+                                },
+                                "to": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/revenue": {
+            "get": {
+                "description": "Revenue of settled payments by payment date, grouped by day, week or month",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Revenue report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "period of every row, defaults to day",
+                        "name": "group_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "revenue": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.RevenuePeriod"
+                                    }
+                                },
+                                "to": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/revenue/cars": {
+            "get": {
+                "description": "Revenue of settled payments by car",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Revenue by car report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "revenue": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.CarRevenue"
+                                    }
+                                },
+                                "to": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/revenue/categories": {
+            "get": {
+                "description": "Revenue of settled payments by car category",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Revenue by category report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "revenue": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.CategoryRevenue"
+                                    }
+                                },
+                                "to": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/top-customers": {
+            "get": {
+                "description": "Customers with the highest settled payments",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Top customers report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of customers, defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "customers": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.TopCustomer"
+                                    }
+                                },
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "to": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/utilization": {
+            "get": {
+                "description": "Share of the period every car and the whole fleet was rented for",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Fleet utilization report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first date, defaults to 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last date, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "from": {
+                                    "type": "string"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "to": {
+                                    "type": "string"
+                                },
+                                "utilization": {
+                                    "$ref": "#/definitions/dto.Utilization"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "Get all users, as JSON or as a csv or xlsx spreadsheet",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "response format, defaults to json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "users": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.User"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wallet-transactions": {
+            "get": {
+                "description": "Get the wallet ledger of all users, as JSON or as a csv or xlsx spreadsheet",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get wallet transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "first transaction date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "last transaction date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "wallet owner",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "topup",
+                            "payment"
+                        ],
+                        "type": "string",
+                        "description": "transaction type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "response format, defaults to json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "wallet_transactions": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.WalletTransaction"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks": {
+            "get": {
+                "description": "Get all webhook endpoints, secrets are only returned when an endpoint is created",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "webhooks": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.WebhookEndpoint"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a webhook endpoint subscribed to events, the response has the secret the payloads are signed with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "description": "Create new webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookEndpoint"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "webhook": {
+                                    "$ref": "#/definitions/entity.WebhookEndpoint"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{webhook_id}": {
+            "put": {
+                "description": "Update webhook endpoint by id, the secret is kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookEndpoint"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "webhook": {
+                                    "$ref": "#/definitions/entity.WebhookEndpoint"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete webhook endpoint by id with its deliveries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{webhook_id}/deliveries": {
+            "get": {
+                "description": "Get the deliveries of a webhook endpoint, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by event",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum deliveries returned, defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "deliveries": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.WebhookDelivery"
+                                    }
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{webhook_id}/deliveries/{delivery_id}": {
+            "get": {
+                "description": "Get a delivery of a webhook endpoint with the log of its attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "delivery id",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "delivery": {
+                                    "$ref": "#/definitions/entity.WebhookDelivery"
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "description": "Send a delivery again with a new set of retries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Redeliver webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "delivery id",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/branches": {
+            "get": {
+                "description": "Get all branches",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Branch"
+                ],
+                "summary": "Get all branches",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "branches": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.Branch"
+                                    }
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/cars": {
+            "get": {
+                "description": "Get all cars",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Car"
+                ],
+                "summary": "Get all cars",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "filter by brand",
+                        "name": "brand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by model (partial match)",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "automatic"
+                        ],
+                        "type": "string",
+                        "description": "filter by transmission",
+                        "name": "transmission",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "petrol",
+                            "diesel",
+                            "electric",
+                            "hybrid"
+                        ],
+                        "type": "string",
+                        "description": "filter by fuel type",
+                        "name": "fuel_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by color",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by plate number",
+                        "name": "plate_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum production year",
+                        "name": "min_year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum production year",
+                        "name": "max_year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "minimum capacity",
+                        "name": "min_capacity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cars having all of the features",
+                        "name": "feature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "cars currently located at the branch",
+                        "name": "branch_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "available",
+                            "rented"
+                        ],
+                        "type": "string",
+                        "description": "filter by status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "cars": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.Car"
+                                    }
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Rent a car, send quote_token from quote a car rental to pay the quoted price. The invoice is created after the rental, it is null when the payment gateway fails and the rental can still be paid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Car"
+                ],
+                "summary": "Rent a car",
+                "parameters": [
+                    {
+                        "description": "user rent a car",
+                        "name": "rental",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Rental"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "retries with the same key return the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "invoice": {
+                                    "$ref": "#/definitions/entity.Invoice"
+                                },
+                                "message": {
+                                    "type": "string"
+                                },
+                                "price_breakdown": {
+                                    "$ref": "#/definitions/pricing.Breakdown"
+                                },
+                                "rental": {
+                                    "$ref": "#/definitions/entity.Rental"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/cars/pay/{rental_id}": {
+            "post": {
+                "description": "Pay rented car",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Car"
+                ],
+                "summary": "Pay rented car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "pay rental car by rental_id",
+                        "name": "pay",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "user pay rented a car",
+                        "name": "pay",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Payment"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "retries with the same key return the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "payment": {
+                                    "$ref": "#/definitions/entity.Payment"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/cars/quote": {
+            "post": {
+                "description": "Price a rental without creating it, the returned quote_token can be sent to rent a car to lock the price until the quote expires",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Car"
+                ],
+                "summary": "Quote a car rental",
+                "parameters": [
+                    {
+                        "description": "rental to quote",
+                        "name": "rental",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Rental"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "quote": {
+                                    "$ref": "#/definitions/dto.Quote"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/cars/return/{rental_id}": {
+            "post": {
+                "description": "Return rented car, a car returned after the return date is billed a late fee from the user deposit. The return never fails on the deposit, late_fee_due is the late fee the deposit couldn't cover, it is charged after the next top up",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Car"
+                ],
+                "summary": "Return rented car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "return rental car by rental_id",
+                        "name": "rental",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "late_fee": {
+                                    "$ref": "#/definitions/pricing.Breakdown"
+                                },
+                                "late_fee_due": {
+                                    "type": "integer"
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/cars/{category_id}": {
+            "get": {
+                "description": "Get cars by category id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Car"
+                ],
+                "summary": "Get cars by category",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "cars search by category_id",
+                        "name": "category",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by brand",
+                        "name": "brand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by model (partial match)",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "automatic"
+                        ],
+                        "type": "string",
+                        "description": "filter by transmission",
+                        "name": "transmission",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "petrol",
+                            "diesel",
+                            "electric",
+                            "hybrid"
+                        ],
+                        "type": "string",
+                        "description": "filter by fuel type",
+                        "name": "fuel_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by color",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by plate number",
+                        "name": "plate_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum production year",
+                        "name": "min_year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum production year",
+                        "name": "max_year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "minimum capacity",
+                        "name": "min_capacity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cars having all of the features",
+                        "name": "feature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "cars currently located at the branch",
+                        "name": "branch_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "available",
+                            "rented"
+                        ],
+                        "type": "string",
+                        "description": "filter by status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "cars": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.Car"
+                                    }
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "description": "User do login, unknown emails and wrong passwords get the same response. Logins are rate limited per client IP and per email, failed logins in a row lock the email out for a while",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "login user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Login"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "seconds to wait before the next login"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/language": {
+            "put": {
+                "description": "Change the language of emails sent to the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update language",
+                "parameters": [
+                    {
+                        "description": "email language",
+                        "name": "language",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Language"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/notification-preferences": {
+            "get": {
+                "description": "Get the channels the current user receives every notification event on",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Get notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "preferences": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.NotificationPreference"
+                                    }
+                                },
+                                "webhook_url": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Turn channels on or off per event and set the webhook URL notifications are posted to, events and channels left out keep their preference",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Update notification preferences",
+                "parameters": [
+                    {
+                        "description": "notification preferences",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.NotificationPreferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "preferences": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.NotificationPreference"
+                                    }
+                                },
+                                "webhook_url": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/notifications": {
+            "get": {
+                "description": "Get the in-app notifications of the current user, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Get notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum notifications returned, defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "notifications": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/entity.Notification"
+                                    }
+                                },
+                                "unread_count": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/notifications/read": {
+            "post": {
+                "description": "Mark every unread in-app notification of the current user as read",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Mark all notifications as read",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "read": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/notifications/{notification_id}/read": {
+            "post": {
+                "description": "Mark an in-app notification of the current user as read",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Mark notification as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "notification id",
+                        "name": "notification_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "notification": {
+                                    "$ref": "#/definitions/entity.Notification"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/payments/{payment_id}/receipt": {
+            "get": {
+                "description": "Download the PDF receipt of a payment of the current user",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Download payment receipt",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "payment id",
+                        "name": "payment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/register": {
+            "post": {
+                "description": "Create new users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Create Users",
+                "parameters": [
+                    {
+                        "description": "Create new user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "user": {
+                                    "$ref": "#/definitions/entity.User"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/topup": {
+            "post": {
+                "description": "User top up, the invoice is created after the deposit is credited and is null when the payment gateway fails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "User top up",
+                "parameters": [
+                    {
+                        "description": "top up",
+                        "name": "car",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TopUp"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "retries with the same key return the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "invoice": {
+                                    "$ref": "#/definitions/entity.Invoice"
+                                },
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/verification": {
+            "post": {
+                "description": "Send a new verification email to the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Resend verification email",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/verify": {
+            "get": {
+                "description": "Verify the email address of a user with the token sent by email",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Verify email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "verification token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.APIKey": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "kiosk jakarta"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "cars:write"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.Branch": {
+            "type": "object",
+            "required": [
+                "address",
+                "city",
+                "name"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "one_way_fee": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.Car": {
+            "type": "object",
+            "properties": {
+                "branch_id": {
+                    "type": "integer"
+                },
+                "brand": {
+                    "type": "string"
+                },
+                "capacity": {
+                    "type": "number"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fuel_type": {
+                    "type": "string",
+                    "enum": [
+                        "petrol",
+                        "diesel",
+                        "electric",
+                        "hybrid"
+                    ]
+                },
+                "home_branch_id": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plate_number": {
+                    "type": "string"
+                },
+                "rental_cost_per_day": {
+                    "type": "integer"
+                },
+                "rental_cost_per_hour": {
+                    "type": "integer"
+                },
+                "transmission": {
+                    "type": "string",
+                    "enum": [
+                        "manual",
+                        "automatic"
+                    ]
+                },
+                "vin": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CarImage": {
+            "type": "object",
+            "properties": {
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "dto.CarRentalHistory": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CarRevenue": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plate_number": {
+                    "type": "string"
+                },
+                "rentals": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CarUtilization": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rented_hours": {
+                    "type": "number"
+                },
+                "utilization_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.CategoryRevenue": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "rentals": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CouponUsage": {
+            "type": "object",
+            "properties": {
+                "coupon_id": {
+                    "type": "integer"
+                },
+                "coupon_name": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "rentals": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Language": {
+            "type": "object",
+            "required": [
+                "language"
+            ],
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "en",
+                        "id"
+                    ]
+                }
+            }
+        },
+        "dto.Login": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.NotificationPreference": {
+            "type": "object",
+            "required": [
+                "channel",
+                "event"
+            ],
+            "properties": {
+                "channel": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "rental_created"
+                }
+            }
+        },
+        "dto.NotificationPreferences": {
+            "type": "object",
+            "properties": {
+                "preferences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.NotificationPreference"
+                    }
+                },
+                "webhook_url": {
+                    "description": "WebhookURL is left unchanged when omitted and removed when empty, it must\nbe an https URL of a public host.",
+                    "type": "string",
+                    "example": "https://example.com/hooks/rental"
+                }
+            }
+        },
+        "dto.Payment": {
+            "type": "object",
+            "required": [
+                "payment_method_id"
+            ],
+            "properties": {
+                "payment_method_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.PaymentHistory": {
+            "type": "object",
+            "properties": {
+                "car_name": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "fullname": {
+                    "type": "string"
+                },
+                "payment_date": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "integer"
+                },
+                "payment_method": {
+                    "type": "string"
+                },
+                "payment_status": {
+                    "type": "string"
+                },
+                "receipt_number": {
+                    "type": "string"
+                },
+                "rental_id": {
+                    "type": "integer"
+                },
+                "total_price": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.PricingRule": {
+            "type": "object",
+            "required": [
+                "name",
+                "percent",
+                "type"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2024-12-26"
+                },
+                "min_days": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "min_lead_days": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "number",
+                    "maximum": 1000,
+                    "minimum": -100
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2024-12-24"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "weekend",
+                        "holiday",
+                        "season",
+                        "long_rental",
+                        "early_bird"
+                    ]
+                }
+            }
+        },
+        "dto.Quote": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "coupon_id": {
+                    "type": "integer"
+                },
+                "dropoff_branch_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "insurance": {
+                    "type": "boolean"
+                },
+                "pickup_branch_id": {
+                    "type": "integer"
+                },
+                "price_breakdown": {
+                    "$ref": "#/definitions/pricing.Breakdown"
+                },
+                "quote_token": {
+                    "type": "string"
+                },
+                "rental_date": {
+                    "type": "string"
+                },
+                "return_date": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Rental": {
+            "type": "object",
+            "required": [
+                "car_id",
+                "rental_date",
+                "return_date"
+            ],
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "coupon_id": {
+                    "type": "integer"
+                },
+                "dropoff_branch_id": {
+                    "type": "integer"
+                },
+                "insurance": {
+                    "type": "boolean"
+                },
+                "pickup_branch_id": {
+                    "type": "integer"
+                },
+                "quote_token": {
+                    "type": "string"
+                },
+                "rental_date": {
+                    "type": "string",
+                    "example": "2024-04-18T09:00:00+07:00"
+                },
+                "return_date": {
+                    "type": "string",
+                    "example": "2024-04-20T12:00:00+07:00"
+                }
+            }
+        },
+        "dto.RentalHistory": {
+            "type": "object",
+            "properties": {
+                "car": {
+                    "$ref": "#/definitions/dto.CarRentalHistory"
+                },
+                "car_id": {
+                    "type": "integer"
+                },
+                "payment_status": {
+                    "type": "string",
+                    "example": "unpaid"
+                },
+                "rental_date": {
+                    "type": "string"
+                },
+                "rental_id": {
+                    "type": "integer"
+                },
+                "return_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "booked"
+                },
+                "total_price": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/dto.UserRentalHistory"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RentalLength": {
+            "type": "object",
+            "properties": {
+                "average_days": {
+                    "type": "number"
+                },
+                "average_hours": {
+                    "type": "number"
+                },
+                "rentals": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RevenuePeriod": {
+            "type": "object",
+            "properties": {
+                "payments": {
+                    "type": "integer"
+                },
+                "period": {
+                    "type": "string",
+                    "example": "2024-04-01"
+                },
+                "revenue": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TopCustomer": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "fullname": {
+                    "type": "string"
+                },
+                "rentals": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TopUp": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 100000
+                }
+            }
+        },
+        "dto.User": {
+            "type": "object",
+            "required": [
+                "address",
+                "email",
+                "fullname"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "deposit": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "fullname": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "en",
+                        "id"
+                    ]
+                }
+            }
+        },
+        "dto.UserRentalHistory": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "fullname": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.Utilization": {
+            "type": "object",
+            "properties": {
+                "cars": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CarUtilization"
+                    }
+                },
+                "fleet_size": {
+                    "type": "integer"
+                },
+                "period_hours": {
+                    "type": "number"
+                },
+                "rented_hours": {
+                    "type": "number"
+                },
+                "utilization_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.WebhookEndpoint": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "rental.created"
+                    ]
+                },
+                "url": {
+                    "description": "URL must be an https URL of a public host.",
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/rental"
+                }
+            }
+        },
+        "entity.APIKey": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Branch": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "branch_id": {
+                    "type": "integer"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "one_way_fee": {
+                    "type": "integer"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Car": {
+            "type": "object",
+            "properties": {
+                "branch_id": {
+                    "type": "integer"
+                },
+                "brand": {
+                    "type": "string"
+                },
+                "capacity": {
+                    "type": "number"
+                },
+                "car_id": {
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fuel_type": {
+                    "type": "string",
+                    "enum": [
+                        "petrol",
+                        "diesel",
+                        "electric",
+                        "hybrid"
+                    ]
+                },
+                "home_branch_id": {
+                    "type": "integer"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CarImage"
+                    }
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plate_number": {
+                    "type": "string"
+                },
+                "rental_cost_per_day": {
+                    "type": "integer"
+                },
+                "rental_cost_per_hour": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "transmission": {
+                    "type": "string",
+                    "enum": [
+                        "manual",
+                        "automatic"
+                    ]
+                },
+                "vin": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer",
+                    "minimum": 1900
+                }
+            }
+        },
+        "entity.CarImage": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "image_id": {
+                    "type": "integer"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Invoice": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "invoice_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.LoginAttempt": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
-                "password": {
+                "ip": {
+                    "type": "string"
+                },
+                "login_attempt_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Notification": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "notification_id": {
+                    "type": "integer"
+                },
+                "read_at": {
                     "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.Payment": {
+        "entity.Payment": {
             "type": "object",
-            "required": [
-                "payment_method_id"
-            ],
             "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PaymentItem"
+                    }
+                },
+                "payment_date": {
+                    "type": "string"
+                },
                 "payment_method_id": {
                     "type": "integer"
+                },
+                "payment_status": {
+                    "type": "string"
+                },
+                "receipt_number": {
+                    "type": "string"
+                },
+                "rental_id": {
+                    "type": "integer"
+                },
+                "total_price": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.Rental": {
+        "entity.PaymentItem": {
             "type": "object",
-            "required": [
-                "car_id",
-                "rental_date",
-                "return_date"
-            ],
             "properties": {
-                "car_id": {
-                    "type": "integer"
-                },
-                "coupon_id": {
+                "amount": {
                     "type": "integer"
                 },
-                "rental_date": {
+                "code": {
                     "type": "string"
                 },
-                "return_date": {
+                "description": {
                     "type": "string"
+                },
+                "payment_id": {
+                    "type": "integer"
+                },
+                "payment_item_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.RentalHistory": {
+        "entity.PricingRule": {
             "type": "object",
             "properties": {
-                "car": {
-                    "$ref": "#/definitions/dto.CarRentalHistory"
+                "active": {
+                    "type": "boolean"
                 },
-                "car_id": {
+                "category_id": {
                     "type": "integer"
                 },
-                "rental_date": {
+                "created_at": {
                     "type": "string"
                 },
-                "rental_id": {
+                "end_date": {
+                    "type": "string",
+                    "format": "date"
+                },
+                "min_days": {
                     "type": "integer"
                 },
-                "return_date": {
+                "min_lead_days": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
-                "total_price": {
+                "percent": {
                     "type": "number"
                 },
-                "user": {
-                    "$ref": "#/definitions/dto.UserRentalHistory"
-                },
-                "user_id": {
+                "pricing_rule_id": {
                     "type": "integer"
+                },
+                "start_date": {
+                    "type": "string",
+                    "format": "date"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "dto.TopUp": {
+        "entity.Rental": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number"
+                "car_id": {
+                    "type": "integer"
+                },
+                "coupon_id": {
+                    "type": "integer"
+                },
+                "dropoff_branch_id": {
+                    "type": "integer"
+                },
+                "hourly_price": {
+                    "type": "integer"
+                },
+                "insurance": {
+                    "type": "boolean"
+                },
+                "invoice_id": {
+                    "description": "InvoiceID is the Xendit invoice, it is stored once the rental is\ncommitted and stays empty when the invoice couldn't be created.",
+                    "type": "string"
+                },
+                "late_fee": {
+                    "type": "integer"
+                },
+                "late_fee_due": {
+                    "description": "LateFeeDue is the part of the late fee of a paid rental not charged from\nthe deposit yet, see helpers.SettleLateFees.",
+                    "type": "integer"
+                },
+                "one_way_fee": {
+                    "type": "integer"
+                },
+                "pickup_branch_id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "rental_date": {
+                    "type": "string"
+                },
+                "return_date": {
+                    "type": "string"
+                },
+                "returned_at": {
+                    "type": "string"
+                },
+                "total_price": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.User": {
+        "entity.User": {
             "type": "object",
-            "required": [
-                "address",
-                "email",
-                "fullname"
-            ],
             "properties": {
                 "address": {
                     "type": "string"
                 },
                 "deposit": {
-                    "type": "number"
+                    "type": "integer"
                 },
                 "email": {
                     "type": "string"
                 },
+                "email_verified_at": {
+                    "type": "string"
+                },
                 "fullname": {
                     "type": "string"
-                }
-            }
-        },
-        "dto.UserRentalHistory": {
-            "type": "object",
-            "properties": {
-                "address": {
+                },
+                "language": {
                     "type": "string"
                 },
-                "fullname": {
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "webhook_url": {
                     "type": "string"
                 }
             }
         },
-        "entity.Car": {
+        "entity.WalletTransaction": {
             "type": "object",
             "properties": {
-                "capacity": {
-                    "type": "number"
-                },
-                "car_id": {
+                "amount": {
                     "type": "integer"
                 },
-                "category_id": {
+                "balance_after": {
                     "type": "integer"
                 },
-                "name": {
+                "created_at": {
                     "type": "string"
                 },
-                "rental_cost_per_day": {
-                    "type": "number"
+                "invoice_id": {
+                    "description": "InvoiceID is the Xendit invoice of a top up.",
+                    "type": "string"
                 },
-                "status": {
+                "reference": {
                     "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "wallet_transaction_id": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.Invoice": {
+        "entity.WebhookAttempt": {
             "type": "object",
             "properties": {
-                "id": {
+                "attempt_id": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "invoice_url": {
+                "delivery_id": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "description": "Duration is in milliseconds.",
+                    "type": "integer"
+                },
+                "error": {
                     "type": "string"
+                },
+                "response_body": {
+                    "type": "string"
+                },
+                "response_status": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.Payment": {
+        "entity.WebhookDelivery": {
             "type": "object",
             "properties": {
-                "payment_date": {
+                "attempt_log": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.WebhookAttempt"
+                    }
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "payment_method_id": {
+                "delivered_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
                     "type": "integer"
                 },
-                "payment_status": {
+                "event": {
                     "type": "string"
                 },
-                "rental_id": {
+                "last_error": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_status": {
                     "type": "integer"
                 },
-                "total_price": {
-                    "type": "number"
+                "status": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.Rental": {
+        "entity.WebhookEndpoint": {
             "type": "object",
             "properties": {
-                "car_id": {
-                    "type": "integer"
+                "active": {
+                    "type": "boolean"
                 },
-                "coupon_id": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "description": {
+                    "type": "string"
                 },
-                "rental_date": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
                     "type": "string"
                 },
-                "return_date": {
+                "updated_at": {
                     "type": "string"
                 },
-                "user_id": {
+                "url": {
+                    "type": "string"
+                },
+                "webhook_id": {
                     "type": "integer"
                 }
             }
         },
-        "entity.User": {
+        "httputil.FieldError": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
+                "field": {
+                    "type": "string",
+                    "example": "rental_date"
                 },
-                "deposit": {
-                    "type": "number"
+                "message": {
+                    "type": "string",
+                    "example": "is required"
                 },
-                "email": {
-                    "type": "string"
+                "rule": {
+                    "type": "string",
+                    "example": "required"
+                }
+            }
+        },
+        "httputil.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "INSUFFICIENT_DEPOSIT"
                 },
-                "fullname": {
-                    "type": "string"
+                "detail": {
+                    "type": "string",
+                    "example": "your deposit is Rp100.000 while total payment is Rp350.000"
                 },
-                "role": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httputil.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/cars/pay/1"
+                },
+                "request_id": {
                     "type": "string"
                 },
-                "user_id": {
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Insufficient deposit"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/insufficient-deposit"
+                }
+            }
+        },
+        "pricing.Breakdown": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer"
+                },
+                "hours": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pricing.Line"
+                    }
+                },
+                "total": {
                     "type": "integer"
                 }
             }
         },
-        "httputil.HTTPError": {
+        "pricing.Line": {
             "type": "object",
             "properties": {
-                "detail": {
+                "amount": {
+                    "type": "integer"
+                },
+                "code": {
                     "type": "string"
                 },
-                "message": {
+                "description": {
                     "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
                 }
             }
         }
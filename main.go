@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"p2-mini-project/src/config"
 	"p2-mini-project/src/routes"
 )
@@ -15,7 +16,16 @@ import (
 // @host      localhost:8081
 // @BasePath  /api/v1
 func main() {
+	config.GetLogger()
+	shutdownTracing := config.SetupTracing()
+	defer shutdownTracing(context.Background())
+
+	config.SetupXendit()
+	config.SetupVerification()
 	db := config.GetConnection()
 
+	go config.GetOutboxWorker(db).Run(context.Background())
+	go config.GetReminderScheduler(db).Run(context.Background())
+
 	routes.Routes(db)
 }
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type DBEnv struct {
 	DBName     string `envconfig:"NAME"`
 	DBHost     string `envconfig:"HOST"`
@@ -7,3 +9,138 @@ type DBEnv struct {
 	DBUsername string `envconfig:"USERNAME"`
 	DBPassword string `envconfig:"PASSWORD"`
 }
+
+type StorageEnv struct {
+	LocalDir     string `envconfig:"LOCAL_DIR" default:"uploads"`
+	BaseURL      string `envconfig:"BASE_URL" default:"/uploads"`
+	MaxImageSize int64  `envconfig:"MAX_IMAGE_SIZE" default:"5242880"`
+}
+
+type PricingEnv struct {
+	GracePeriod time.Duration `envconfig:"GRACE_PERIOD" default:"30m"`
+	TimeZone    string        `envconfig:"TIMEZONE" default:"Asia/Jakarta"`
+	VATRate     float64       `envconfig:"VAT_RATE" default:"11"`
+	QuoteTTL    time.Duration `envconfig:"QUOTE_TTL" default:"15m"`
+	// QuoteSecret signs quote tokens, it is read from QUOTE_SECRET when
+	// PRICING_QUOTE_SECRET isn't set.
+	QuoteSecret string `envconfig:"QUOTE_SECRET" required:"true"`
+	// InsurancePerDay is in whole rupiah.
+	InsurancePerDay int64   `envconfig:"INSURANCE_PER_DAY" default:"50000"`
+	LateFeePercent  float64 `envconfig:"LATE_FEE_PERCENT" default:"150"`
+}
+
+type XenditEnv struct {
+	// Timeout bounds a whole Xendit request, including reading the response.
+	Timeout time.Duration `envconfig:"TIMEOUT" default:"10s"`
+}
+
+type VerificationEnv struct {
+	// Secret signs email verification tokens, it must not be shared with the
+	// JWT secret so a leak of one doesn't forge the other.
+	Secret string `envconfig:"SECRET" required:"true"`
+}
+
+type ReceiptEnv struct {
+	CompanyName    string `envconfig:"COMPANY_NAME" default:"Rental Car"`
+	CompanyAddress string `envconfig:"COMPANY_ADDRESS"`
+	CompanyPhone   string `envconfig:"COMPANY_PHONE"`
+	CompanyEmail   string `envconfig:"COMPANY_EMAIL"`
+}
+
+type MailerEnv struct {
+	Driver       string `envconfig:"MAILER_DRIVER" default:"smtp"`
+	SMTPHost     string `envconfig:"SMTP_HOST"`
+	SMTPPort     int    `envconfig:"SMTP_PORT" default:"587"`
+	SenderName   string `envconfig:"SENDER_NAME"`
+	AuthEmail    string `envconfig:"AUTH_EMAIL"`
+	AuthPassword string `envconfig:"AUTH_PASSWORD"`
+}
+
+type OutboxEnv struct {
+	PollInterval time.Duration `envconfig:"POLL_INTERVAL" default:"5s"`
+	BatchSize    int           `envconfig:"BATCH_SIZE" default:"20"`
+	MaxAttempts  int           `envconfig:"MAX_ATTEMPTS" default:"8"`
+	BackoffBase  time.Duration `envconfig:"BACKOFF_BASE" default:"30s"`
+	BackoffMax   time.Duration `envconfig:"BACKOFF_MAX" default:"1h"`
+}
+
+type ReminderEnv struct {
+	Interval     time.Duration `envconfig:"INTERVAL" default:"5m"`
+	PickupBefore time.Duration `envconfig:"PICKUP_BEFORE" default:"24h"`
+	ReturnBefore time.Duration `envconfig:"RETURN_BEFORE" default:"3h"`
+	// OverdueAfter are the escalation levels, the user is alerted at every
+	// level and admins from AdminLevel on.
+	OverdueAfter []time.Duration `envconfig:"OVERDUE_AFTER" default:"1h,24h,72h"`
+	AdminLevel   int             `envconfig:"ADMIN_LEVEL" default:"2"`
+}
+
+type IdempotencyEnv struct {
+	// TTL is how long a key is remembered, a key can be reused after it.
+	TTL time.Duration `envconfig:"TTL" default:"24h"`
+	// Lease is how long a key stays processing, a request still running past
+	// it, e.g. after a crash, can be retried with the same key.
+	Lease time.Duration `envconfig:"LEASE" default:"1m"`
+}
+
+type LogEnv struct {
+	Level  string `envconfig:"LEVEL" default:"info"`
+	Format string `envconfig:"FORMAT" default:"json"`
+	// SlowQuery logs slower queries as warnings, other queries are only
+	// logged at debug level.
+	SlowQuery time.Duration `envconfig:"SLOW_QUERY" default:"200ms"`
+}
+
+type MetricsEnv struct {
+	Enabled bool   `envconfig:"ENABLED" default:"true"`
+	Path    string `envconfig:"PATH" default:"/metrics"`
+	// Addr is the internal listener metrics are served on, it is separate
+	// from the public API and only reachable locally by default.
+	Addr string `envconfig:"ADDR" default:"127.0.0.1:9090"`
+}
+
+type TracingEnv struct {
+	// Exporter is none, stdout or otlp, tracing is disabled with none.
+	Exporter string `envconfig:"EXPORTER" default:"none"`
+	// Endpoint is the host:port of the OTLP HTTP collector.
+	Endpoint    string  `envconfig:"ENDPOINT" default:"localhost:4318"`
+	Insecure    bool    `envconfig:"INSECURE" default:"false"`
+	ServiceName string  `envconfig:"SERVICE_NAME" default:"rental-car"`
+	SampleRatio float64 `envconfig:"SAMPLE_RATIO" default:"1"`
+}
+
+type LoginEnv struct {
+	// IPLimit and AccountLimit are the logins allowed per period from a client
+	// IP and for an email, 0 disables the limit.
+	IPLimit       int           `envconfig:"IP_LIMIT" default:"20"`
+	IPPeriod      time.Duration `envconfig:"IP_PERIOD" default:"1m"`
+	AccountLimit  int           `envconfig:"ACCOUNT_LIMIT" default:"5"`
+	AccountPeriod time.Duration `envconfig:"ACCOUNT_PERIOD" default:"1m"`
+	// LockoutThreshold failed logins in a row lock the email out for
+	// LockoutBase, doubled on every further failure up to LockoutMax.
+	LockoutThreshold int           `envconfig:"LOCKOUT_THRESHOLD" default:"5"`
+	LockoutBase      time.Duration `envconfig:"LOCKOUT_BASE" default:"1m"`
+	LockoutMax       time.Duration `envconfig:"LOCKOUT_MAX" default:"1h"`
+	LockoutWindow    time.Duration `envconfig:"LOCKOUT_WINDOW" default:"24h"`
+}
+
+type HTTPEnv struct {
+	// CORSAllowedOrigins enables CORS for the listed origins, "*" allows any
+	// origin but can't be combined with credentials.
+	CORSAllowedOrigins   []string      `envconfig:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods   []string      `envconfig:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	CORSAllowedHeaders   []string      `envconfig:"CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,Idempotency-Key,X-Request-ID"`
+	CORSExposedHeaders   []string      `envconfig:"CORS_EXPOSED_HEADERS" default:"X-Request-ID,Retry-After,Idempotent-Replayed,Content-Disposition"`
+	CORSAllowCredentials bool          `envconfig:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	CORSMaxAge           time.Duration `envconfig:"CORS_MAX_AGE" default:"12h"`
+	// MaxBodySize limits request bodies in bytes, image uploads have their
+	// own limit.
+	MaxBodySize int64 `envconfig:"MAX_BODY_SIZE" default:"1048576"`
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For header is
+	// trusted for the client IP, none are trusted by default.
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
+	// TrustedPlatform is a header set by the platform carrying the client IP,
+	// such as CF-Connecting-IP, it takes precedence over TrustedProxies.
+	TrustedPlatform       string        `envconfig:"TRUSTED_PLATFORM"`
+	ContentSecurityPolicy string        `envconfig:"CONTENT_SECURITY_POLICY" default:"default-src 'none'; frame-ancestors 'none'"`
+	HSTSMaxAge            time.Duration `envconfig:"HSTS_MAX_AGE" default:"0s"`
+}
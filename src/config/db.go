@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"p2-mini-project/src/entity"
+	"p2-mini-project/src/logging"
+	"p2-mini-project/src/tracing"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -27,19 +30,40 @@ func GetConnection() *gorm.DB {
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=require TimeZone=Asia/Jakarta", dbConfig.DBHost, dbConfig.DBUsername, dbConfig.DBPassword, dbConfig.DBName, dbConfig.DBPort)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logging.NewGormLogger(slog.Default(), getLogConfig().SlowQuery),
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to database")
 		return nil
 	}
 
-	err = db.AutoMigrate(&entity.PaymentMethod{}, &entity.Coupon{}, &entity.Category{}, &entity.Car{}, &entity.User{}, &entity.Rental{}, &entity.Payment{})
+	err = db.Use(tracing.GormPlugin{})
+	if err != nil {
+		log.Fatal("Failed to register tracing plugin: ", err)
+		return nil
+	}
+
+	err = migrateSchema(db)
+	if err != nil {
+		log.Fatal("Failed to migrate schema: ", err)
+		return nil
+	}
+
+	err = db.AutoMigrate(&entity.PaymentMethod{}, &entity.Coupon{}, &entity.Category{}, &entity.Branch{}, &entity.Car{}, &entity.CarImage{}, &entity.User{}, &entity.Rental{}, &entity.Payment{}, &entity.PaymentItem{}, &entity.PricingRule{}, &entity.WalletTransaction{}, &entity.OutboxMessage{}, &entity.Notification{}, &entity.NotificationPreference{}, &entity.RentalNotice{}, &entity.WebhookEndpoint{}, &entity.WebhookDelivery{}, &entity.WebhookAttempt{}, &entity.IdempotencyKey{}, &entity.LoginAttempt{}, &entity.APIKey{})
 	if err != nil {
 		log.Fatal("Failed to auto migrate db: ", err)
 		return nil
 	}
 
-	fmt.Println("DB Connected")
+	err = migrateData(db)
+	if err != nil {
+		log.Fatal("Failed to migrate data: ", err)
+		return nil
+	}
+
+	slog.Info("database connected", "host", dbConfig.DBHost, "name", dbConfig.DBName)
 
 	return db
 }
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"log"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/kelseyhightower/envconfig"
+)
+
+func GetHTTPConfig() HTTPEnv {
+	var httpConfig HTTPEnv
+	if err := envconfig.Process("HTTP", &httpConfig); err != nil {
+		log.Fatal("Failed to process http env: ", err)
+	}
+
+	return httpConfig
+}
+
+// GetCORSMiddleware returns the CORS policy of httpConfig, it is nil when no
+// origin is allowed.
+func GetCORSMiddleware(httpConfig HTTPEnv) gin.HandlerFunc {
+	if len(httpConfig.CORSAllowedOrigins) == 0 {
+		return nil
+	}
+
+	corsConfig := cors.Config{
+		AllowMethods:     httpConfig.CORSAllowedMethods,
+		AllowHeaders:     httpConfig.CORSAllowedHeaders,
+		ExposeHeaders:    httpConfig.CORSExposedHeaders,
+		AllowCredentials: httpConfig.CORSAllowCredentials,
+		MaxAge:           httpConfig.CORSMaxAge,
+	}
+	if len(httpConfig.CORSAllowedOrigins) == 1 && httpConfig.CORSAllowedOrigins[0] == "*" {
+		if httpConfig.CORSAllowCredentials {
+			log.Fatal("Failed to configure CORS: HTTP_CORS_ALLOW_CREDENTIALS can't be used with any origin")
+		}
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = httpConfig.CORSAllowedOrigins
+	}
+	if err := corsConfig.Validate(); err != nil {
+		log.Fatal("Failed to configure CORS: ", err)
+	}
+
+	return cors.New(corsConfig)
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"log"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+func GetIdempotencyConfig() IdempotencyEnv {
+	var idempotencyConfig IdempotencyEnv
+	if err := envconfig.Process("IDEMPOTENCY", &idempotencyConfig); err != nil {
+		log.Fatal("Failed to process idempotency env: ", err)
+	}
+
+	return idempotencyConfig
+}
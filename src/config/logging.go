@@ -0,0 +1,39 @@
+package config
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"p2-mini-project/src/logging"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+)
+
+// GetLogger builds the logger from the LOG env and makes it the default
+// logger, the standard log package writes to it as well. It runs before
+// GetConnection, which reports a missing .env file.
+func GetLogger() *slog.Logger {
+	godotenv.Load(".env")
+	logConfig := getLogConfig()
+
+	level, err := logging.ParseLevel(logConfig.Level)
+	if err != nil {
+		log.Fatal("Failed to parse LOG_LEVEL: ", err)
+	}
+	logger, err := logging.New(os.Stdout, level, logConfig.Format)
+	if err != nil {
+		log.Fatal("Failed to create logger: ", err)
+	}
+
+	slog.SetDefault(logger)
+	return logger
+}
+
+func getLogConfig() LogEnv {
+	var logConfig LogEnv
+	if err := envconfig.Process("LOG", &logConfig); err != nil {
+		log.Fatal("Failed to process log env: ", err)
+	}
+	return logConfig
+}
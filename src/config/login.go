@@ -0,0 +1,42 @@
+package config
+
+import (
+	"log"
+	"p2-mini-project/src/ratelimit"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+func GetLoginConfig() LoginEnv {
+	var loginConfig LoginEnv
+	if err := envconfig.Process("LOGIN", &loginConfig); err != nil {
+		log.Fatal("Failed to process login env: ", err)
+	}
+
+	return loginConfig
+}
+
+// GetLoginLimiters returns the per client IP and per email login limiters
+// and the limiter sampling the logins rejected by the IP limit, one per IP
+// and LOGIN_IP_PERIOD. The buckets are kept in memory so every instance
+// enforces its own limits.
+func GetLoginLimiters() (*ratelimit.Limiter, *ratelimit.Limiter, *ratelimit.Limiter) {
+	loginConfig := GetLoginConfig()
+	store := ratelimit.NewMemoryStore()
+
+	ip := ratelimit.NewLimiter(store, "login-ip", ratelimit.Rate{Limit: loginConfig.IPLimit, Period: loginConfig.IPPeriod})
+	account := ratelimit.NewLimiter(store, "login-account", ratelimit.Rate{Limit: loginConfig.AccountLimit, Period: loginConfig.AccountPeriod})
+	ipReject := ratelimit.NewLimiter(store, "login-ip-reject", ratelimit.Rate{Limit: 1, Period: loginConfig.IPPeriod})
+	return ip, account, ipReject
+}
+
+func GetLoginLockout() ratelimit.Lockout {
+	loginConfig := GetLoginConfig()
+
+	return ratelimit.Lockout{
+		Threshold: loginConfig.LockoutThreshold,
+		Base:      loginConfig.LockoutBase,
+		Max:       loginConfig.LockoutMax,
+		Window:    loginConfig.LockoutWindow,
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func GetMetricsConfig() MetricsEnv {
+	var metricsConfig MetricsEnv
+	if err := envconfig.Process("METRICS", &metricsConfig); err != nil {
+		log.Fatal("Failed to process metrics env: ", err)
+	}
+
+	return metricsConfig
+}
+
+// ServeMetrics serves the metrics on their own listener so they aren't
+// reachable through the public API, it blocks like http.ListenAndServe.
+func ServeMetrics(metricsConfig MetricsEnv) {
+	mux := http.NewServeMux()
+	mux.Handle(metricsConfig.Path, promhttp.Handler())
+
+	if err := http.ListenAndServe(metricsConfig.Addr, mux); err != nil {
+		log.Fatal("Failed to serve metrics: ", err)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumns used to be double precision, they hold whole rupiah since
+// amounts are stored as money.Money.
+var moneyColumns = map[string][]string{
+	"users":    {"deposit"},
+	"cars":     {"rental_cost_per_day", "rental_cost_per_hour"},
+	"branches": {"one_way_fee"},
+	"rentals":  {"price", "one_way_fee", "hourly_price", "total_price"},
+	"payments": {"total_price"},
+}
+
+// migrateSchema changes existing columns before AutoMigrate runs, it must be
+// safe to run on each start.
+func migrateSchema(db *gorm.DB) error {
+	for table, columns := range moneyColumns {
+		for _, column := range columns {
+			dataType := ""
+			res := db.Raw("SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?", table, column).Scan(&dataType)
+			if res.Error != nil {
+				return res.Error
+			}
+			if dataType == "" || dataType == "bigint" {
+				continue
+			}
+
+			// rounding numeric is half away from zero, 99999.99999 becomes 100000
+			sql := fmt.Sprintf("ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING round(%q::numeric)::bigint", table, column, column)
+			if err := db.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateData backfills columns added after data already existed, every
+// statement must be safe to run on each start.
+func migrateData(db *gorm.DB) error {
+	// coupons 1-3 used to be hard coded as 10%, 20% and 30% discounts
+	if err := db.Exec("UPDATE coupons SET discount_percent = coupon_id * 10 WHERE coupon_id IN (1, 2, 3) AND discount_percent = 0").Error; err != nil {
+		return err
+	}
+
+	// payments made before receipts were issued, same format as receipt.Number
+	return db.Exec("UPDATE payments SET receipt_number = 'RCP-' || to_char(payment_date, 'YYYYMMDD') || '-' || lpad(payment_id::text, 6, '0') WHERE receipt_number IS NULL").Error
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"log"
+	"p2-mini-project/src/notification"
+	"p2-mini-project/src/reminder"
+	"sort"
+
+	"github.com/kelseyhightower/envconfig"
+	"gorm.io/gorm"
+)
+
+// GetNotifier returns the notifier sending on every channel, users turn
+// channels off in their notification preferences.
+func GetNotifier() *notification.Notifier {
+	return notification.NewNotifier(notification.EmailChannel{}, notification.InAppChannel{}, notification.WebhookChannel{})
+}
+
+func GetReminderScheduler(db *gorm.DB) *reminder.Scheduler {
+	var reminderConfig ReminderEnv
+	if err := envconfig.Process("REMINDER", &reminderConfig); err != nil {
+		log.Fatal("Failed to process reminder env: ", err)
+	}
+	if reminderConfig.Interval <= 0 {
+		log.Fatal("REMINDER_INTERVAL must be positive")
+	}
+	levels := reminderConfig.OverdueAfter
+	if !sort.SliceIsSorted(levels, func(i, j int) bool { return levels[i] < levels[j] }) {
+		log.Fatal("REMINDER_OVERDUE_AFTER must be in increasing order")
+	}
+
+	schedule := reminder.Schedule{
+		PickupBefore: reminderConfig.PickupBefore,
+		ReturnBefore: reminderConfig.ReturnBefore,
+		OverdueAfter: levels,
+		AdminLevel:   reminderConfig.AdminLevel,
+	}
+	return reminder.NewScheduler(db, GetNotifier(), GetPricingPolicy().Location, reminderConfig.Interval, schedule)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"log"
+	"log/slog"
+	"net/http"
+	"p2-mini-project/src/logging"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/metrics"
+	"p2-mini-project/src/notification"
+	"p2-mini-project/src/outbox"
+	"p2-mini-project/src/safehttp"
+	"p2-mini-project/src/tracing"
+	"p2-mini-project/src/webhook"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"gorm.io/gorm"
+)
+
+// GetMailer returns the SMTP mailer, or an in-memory mailer that only keeps
+// messages when CONFIG_MAILER_DRIVER is memory.
+func GetMailer() mailer.Mailer {
+	var mailerConfig MailerEnv
+	if err := envconfig.Process("CONFIG", &mailerConfig); err != nil {
+		log.Fatal("Failed to process mailer env: ", err)
+	}
+
+	switch mailerConfig.Driver {
+	case "smtp":
+		return metrics.NewMailer(logging.NewMailer(tracing.NewMailer(mailer.NewSMTPMailer(mailerConfig.SMTPHost, mailerConfig.SMTPPort, mailerConfig.AuthEmail, mailerConfig.AuthPassword, mailerConfig.SenderName)), slog.Default()))
+	case "memory":
+		return metrics.NewMailer(logging.NewMailer(tracing.NewMailer(mailer.NewMemoryMailer()), slog.Default()))
+	}
+
+	log.Fatal("Unknown mailer driver: ", mailerConfig.Driver)
+	return nil
+}
+
+func GetOutboxWorker(db *gorm.DB) *outbox.Worker {
+	var outboxConfig OutboxEnv
+	if err := envconfig.Process("OUTBOX", &outboxConfig); err != nil {
+		log.Fatal("Failed to process outbox env: ", err)
+	}
+	if outboxConfig.BatchSize < 1 || outboxConfig.MaxAttempts < 1 {
+		log.Fatal("OUTBOX_BATCH_SIZE and OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+
+	worker := outbox.NewWorker(db, outbox.Backoff{Base: outboxConfig.BackoffBase, Max: outboxConfig.BackoffMax}, outboxConfig.MaxAttempts, outboxConfig.PollInterval, outboxConfig.BatchSize)
+	worker.Handle(outbox.KindEmail, outbox.EmailHandler(GetMailer()))
+	// webhook URLs are entered by users and admins, they can't reach the
+	// internal network
+	client := &http.Client{Timeout: 10 * time.Second, CheckRedirect: safehttp.CheckRedirect, Transport: metrics.NewTransport(logging.NewTransport(tracing.NewTransport(safehttp.NewTransport(), "webhook"), "webhook", slog.Default()), "webhook")}
+	worker.Handle(notification.KindWebhook, notification.WebhookHandler(client))
+	worker.Handle(webhook.KindDelivery, webhook.DeliveryHandler(db, client))
+
+	return worker
+}
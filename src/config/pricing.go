@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+func GetPricingPolicy() pricing.Policy {
+	var pricingConfig PricingEnv
+	if err := envconfig.Process("PRICING", &pricingConfig); err != nil {
+		log.Fatal("Failed to process pricing env: ", err)
+	}
+	if pricingConfig.QuoteSecret == "" {
+		log.Fatal("QUOTE_SECRET must not be empty")
+	}
+
+	location, err := time.LoadLocation(pricingConfig.TimeZone)
+	if err != nil {
+		log.Fatal("Failed to load pricing timezone: ", err)
+	}
+
+	return pricing.Policy{
+		GracePeriod:     pricingConfig.GracePeriod,
+		Location:        location,
+		VATRate:         pricingConfig.VATRate,
+		QuoteTTL:        pricingConfig.QuoteTTL,
+		QuoteSecret:     []byte(pricingConfig.QuoteSecret),
+		InsurancePerDay: money.Money(pricingConfig.InsurancePerDay),
+		LateFeePercent:  pricingConfig.LateFeePercent,
+	}
+}
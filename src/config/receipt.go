@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log"
+	"p2-mini-project/src/receipt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+func GetReceiptGenerator(location *time.Location) *receipt.Generator {
+	var receiptConfig ReceiptEnv
+	if err := envconfig.Process("RECEIPT", &receiptConfig); err != nil {
+		log.Fatal("Failed to process receipt env: ", err)
+	}
+
+	return &receipt.Generator{
+		Company: receipt.Company{
+			Name:    receiptConfig.CompanyName,
+			Address: receiptConfig.CompanyAddress,
+			Phone:   receiptConfig.CompanyPhone,
+			Email:   receiptConfig.CompanyEmail,
+		},
+		Location: location,
+	}
+}
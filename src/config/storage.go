@@ -0,0 +1,16 @@
+package config
+
+import (
+	"log"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+func GetStorageConfig() StorageEnv {
+	var storageConfig StorageEnv
+	if err := envconfig.Process("STORAGE", &storageConfig); err != nil {
+		log.Fatal("Failed to process storage env: ", err)
+	}
+
+	return storageConfig
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"context"
+	"log"
+	"p2-mini-project/src/tracing"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// SetupTracing installs the tracer provider of the TRACING env, the returned
+// function flushes the spans not exported yet.
+func SetupTracing() func(context.Context) error {
+	tracingConfig := GetTracingConfig()
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    tracingConfig.Exporter,
+		ServiceName: tracingConfig.ServiceName,
+		Endpoint:    tracingConfig.Endpoint,
+		Insecure:    tracingConfig.Insecure,
+		SampleRatio: tracingConfig.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal("Failed to setup tracing: ", err)
+	}
+
+	return shutdown
+}
+
+func GetTracingConfig() TracingEnv {
+	var tracingConfig TracingEnv
+	if err := envconfig.Process("TRACING", &tracingConfig); err != nil {
+		log.Fatal("Failed to process tracing env: ", err)
+	}
+
+	return tracingConfig
+}
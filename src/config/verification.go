@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+	"p2-mini-project/src/helpers"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// SetupVerification sets the secret email verification tokens are signed
+// with, the app doesn't start without it.
+func SetupVerification() {
+	var verificationConfig VerificationEnv
+	if err := envconfig.Process("VERIFICATION", &verificationConfig); err != nil {
+		log.Fatal("Failed to process verification env: ", err)
+	}
+	if verificationConfig.Secret == "" {
+		log.Fatal("VERIFICATION_SECRET must not be empty")
+	}
+
+	helpers.VerificationSecret = []byte(verificationConfig.Secret)
+}
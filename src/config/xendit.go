@@ -0,0 +1,18 @@
+package config
+
+import (
+	"log"
+	"p2-mini-project/src/helpers"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// SetupXendit configures the client of the Xendit invoice requests.
+func SetupXendit() {
+	var xenditConfig XenditEnv
+	if err := envconfig.Process("XENDIT", &xenditConfig); err != nil {
+		log.Fatal("Failed to process xendit env: ", err)
+	}
+
+	helpers.InvoiceClient.Timeout = xenditConfig.Timeout
+}
@@ -1,12 +1,58 @@
 package dto
 
+import (
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
 type User struct {
-	Fullname string  `json:"fullname" binding:"required"`
-	Address  string  `json:"address" binding:"required"`
-	Email    string  `json:"email" binding:"required,email"`
-	Password string  `json:"password,omitempty" binding:"required" swaggerignore:"true"`
-	Role     string  `json:"role" swaggerignore:"true"`
-	Deposit  float64 `json:"deposit"`
+	Fullname string      `json:"fullname" binding:"required"`
+	Address  string      `json:"address" binding:"required"`
+	Email    string      `json:"email" binding:"required,email"`
+	Password string      `json:"password,omitempty" binding:"required" swaggerignore:"true"`
+	Role     string      `json:"role" swaggerignore:"true"`
+	Deposit  money.Money `json:"deposit"`
+	Language string      `json:"language" binding:"omitempty,oneof=en id" enums:"en,id"`
+}
+
+type Language struct {
+	Language string `json:"language" binding:"required,oneof=en id" enums:"en,id"`
+}
+
+type NotificationPreference struct {
+	Event   string `json:"event" binding:"required" example:"rental_created"`
+	Channel string `json:"channel" binding:"required" example:"email"`
+	Enabled bool   `json:"enabled"`
+}
+
+type NotificationPreferences struct {
+	// WebhookURL is left unchanged when omitted and removed when empty, it must
+	// be an https URL of a public host.
+	WebhookURL  *string                  `json:"webhook_url" binding:"omitempty,url" example:"https://example.com/hooks/rental"`
+	Preferences []NotificationPreference `json:"preferences" binding:"dive"`
+}
+
+type NotificationFilter struct {
+	Unread bool `form:"unread"`
+	Limit  int  `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type WebhookEndpoint struct {
+	// URL must be an https URL of a public host.
+	URL         string   `json:"url" binding:"required,url" example:"https://partner.example.com/hooks/rental"`
+	Description string   `json:"description"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=rental.created payment.settled rental.returned car.updated" example:"rental.created"`
+	Active      *bool    `json:"active"`
+}
+
+type WebhookDeliveryFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Event  string `form:"event"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
 }
 
 type Login struct {
@@ -15,28 +61,107 @@ type Login struct {
 }
 
 type Rental struct {
-	ID         int     `json:"rental_id" gorm:"column:rental_id" swaggerignore:"true"`
-	UserID     int     `json:"user_id" swaggerignore:"true"`
-	CarID      int     `json:"car_id" binding:"required"`
-	CouponID   int     `json:"coupon_id"`
-	Price      float64 `json:"price" swaggerignore:"true"`
-	RentalDate string  `json:"rental_date" binding:"required"`
-	ReturnDate string  `json:"return_date" binding:"required"`
+	ID              int                               `json:"rental_id" gorm:"column:rental_id" swaggerignore:"true"`
+	UserID          int                               `json:"user_id" swaggerignore:"true"`
+	CarID           int                               `json:"car_id" binding:"required"`
+	CouponID        int                               `json:"coupon_id"`
+	Price           money.Money                       `json:"price" swaggerignore:"true"`
+	PickupBranchID  *int                              `json:"pickup_branch_id"`
+	DropoffBranchID *int                              `json:"dropoff_branch_id"`
+	OneWayFee       money.Money                       `json:"one_way_fee" swaggerignore:"true"`
+	HourlyPrice     money.Money                       `json:"hourly_price" swaggerignore:"true"`
+	TotalPrice      money.Money                       `json:"total_price" swaggerignore:"true"`
+	Insurance       bool                              `json:"insurance"`
+	PriceLines      datatypes.JSONSlice[pricing.Line] `json:"price_lines,omitempty" swaggerignore:"true"`
+	LateFee         money.Money                       `json:"late_fee" swaggerignore:"true"`
+	ReturnedAt      *time.Time                        `json:"returned_at,omitempty" swaggerignore:"true"`
+	RentalDate      string                            `json:"rental_date" binding:"required" example:"2024-04-18T09:00:00+07:00"`
+	ReturnDate      string                            `json:"return_date" binding:"required" example:"2024-04-20T12:00:00+07:00"`
+	QuoteToken      string                            `json:"quote_token,omitempty" gorm:"-"`
+}
+
+type Quote struct {
+	UserID          int               `json:"user_id"`
+	CarID           int               `json:"car_id"`
+	CouponID        int               `json:"coupon_id"`
+	PickupBranchID  *int              `json:"pickup_branch_id"`
+	DropoffBranchID *int              `json:"dropoff_branch_id"`
+	Insurance       bool              `json:"insurance"`
+	RentalDate      string            `json:"rental_date"`
+	ReturnDate      string            `json:"return_date"`
+	Breakdown       pricing.Breakdown `json:"price_breakdown"`
+	ExpiresAt       time.Time         `json:"expires_at"`
+	Token           string            `json:"quote_token,omitempty"`
 }
 
 type Car struct {
-	CategoryID       int     `json:"category_id"`
-	Name             string  `json:"name"`
-	RentalCostPerDay float64 `json:"rental_cost_per_day"`
-	Capacity         float64 `json:"capacity"`
+	CategoryID        int         `json:"category_id"`
+	Name              string      `json:"name"`
+	PlateNumber       string      `json:"plate_number"`
+	VIN               string      `json:"vin"`
+	Brand             string      `json:"brand"`
+	Model             string      `json:"model"`
+	Year              int         `json:"year"`
+	Transmission      string      `json:"transmission" enums:"manual,automatic"`
+	FuelType          string      `json:"fuel_type" enums:"petrol,diesel,electric,hybrid"`
+	Color             string      `json:"color"`
+	Features          []string    `json:"features"`
+	HomeBranchID      *int        `json:"home_branch_id"`
+	BranchID          *int        `json:"branch_id"`
+	RentalCostPerDay  money.Money `json:"rental_cost_per_day"`
+	RentalCostPerHour money.Money `json:"rental_cost_per_hour"`
+	Capacity          float64     `json:"capacity"`
+}
+
+type CarFilter struct {
+	Brand        string   `form:"brand"`
+	Model        string   `form:"model"`
+	Transmission string   `form:"transmission" binding:"omitempty,oneof=manual automatic"`
+	FuelType     string   `form:"fuel_type" binding:"omitempty,oneof=petrol diesel electric hybrid"`
+	Color        string   `form:"color"`
+	PlateNumber  string   `form:"plate_number"`
+	MinYear      int      `form:"min_year"`
+	MaxYear      int      `form:"max_year"`
+	MinCapacity  float64  `form:"min_capacity"`
+	Features     []string `form:"feature"`
+	BranchID     int      `form:"branch_id"`
+	Status       string   `form:"status" binding:"omitempty,oneof=available rented"`
+}
+
+type Branch struct {
+	Name      string      `json:"name" binding:"required"`
+	Address   string      `json:"address" binding:"required"`
+	City      string      `json:"city" binding:"required"`
+	Phone     string      `json:"phone"`
+	OneWayFee money.Money `json:"one_way_fee" binding:"min=0"`
+}
+
+type CarImage struct {
+	Position  *int  `json:"position" binding:"omitempty,min=0"`
+	IsPrimary *bool `json:"is_primary"`
+}
+
+type PricingRule struct {
+	Name        string  `json:"name" binding:"required"`
+	Type        string  `json:"type" binding:"required,oneof=weekend holiday season long_rental early_bird"`
+	CategoryID  *int    `json:"category_id"`
+	Percent     float64 `json:"percent" binding:"required,min=-100,max=1000"`
+	StartDate   string  `json:"start_date" binding:"required_if=Type holiday,required_if=Type season" example:"2024-12-24"`
+	EndDate     string  `json:"end_date" binding:"required_if=Type holiday,required_if=Type season" example:"2024-12-26"`
+	MinDays     int     `json:"min_days" binding:"required_if=Type long_rental,min=0"`
+	MinLeadDays int     `json:"min_lead_days" binding:"required_if=Type early_bird,min=0"`
+	Active      *bool   `json:"active"`
 }
 
 type Payment struct {
-	PaymentMethodID int     `json:"payment_method_id" binding:"required"`
-	RentalID        int     `json:"rental_id" swaggerignore:"true"`
-	TotalPrice      float64 `json:"total_price" swaggerignore:"true"`
-	PaymentDate     string  `json:"payment_date" swaggerignore:"true"`
-	PaymentStatus   string  `json:"payment_status" swaggerignore:"true"`
+	ID              int                  `json:"payment_id" gorm:"column:payment_id" swaggerignore:"true"`
+	PaymentMethodID int                  `json:"payment_method_id" binding:"required"`
+	RentalID        int                  `json:"rental_id" swaggerignore:"true"`
+	TotalPrice      money.Money          `json:"total_price" swaggerignore:"true"`
+	PaymentDate     string               `json:"payment_date" swaggerignore:"true"`
+	PaymentStatus   string               `json:"payment_status" swaggerignore:"true"`
+	ReceiptNumber   string               `json:"receipt_number" gorm:"default:null" swaggerignore:"true"`
+	Items           []entity.PaymentItem `json:"items" gorm:"-" swaggerignore:"true"`
 }
 
 type RentalAndPayment struct {
@@ -45,25 +170,162 @@ type RentalAndPayment struct {
 }
 
 type RentalHistory struct {
-	RentalID   int               `json:"rental_id"`
-	RentalDate string            `json:"rental_date"`
-	ReturnDate string            `json:"return_date"`
-	UserID     int               `json:"user_id"`
-	User       UserRentalHistory `json:"user"`
-	CarID      int               `json:"car_id"`
-	Car        CarRentalHistory  `json:"car"`
-	TotalPrice float64           `json:"total_price"`
+	RentalID      int               `json:"rental_id"`
+	RentalDate    string            `json:"rental_date"`
+	ReturnDate    string            `json:"return_date"`
+	UserID        int               `json:"user_id"`
+	User          UserRentalHistory `json:"user"`
+	CarID         int               `json:"car_id"`
+	Car           CarRentalHistory  `json:"car"`
+	TotalPrice    money.Money       `json:"total_price"`
+	PaymentStatus string            `json:"payment_status" example:"unpaid"`
+	Status        string            `json:"status" example:"booked"`
 }
 
 type UserRentalHistory struct {
 	Fullname string `json:"fullname"`
+	Email    string `json:"email"`
 	Address  string `json:"address"`
 }
 
 type CarRentalHistory struct {
-	Name string `json:"name"`
+	Name       string `json:"name"`
+	CategoryID int    `json:"category_id"`
+}
+
+// ExportFormat picks the format of admin lists, csv and xlsx download the list
+// as a spreadsheet.
+type ExportFormat struct {
+	Format string `form:"format" binding:"omitempty,oneof=json csv xlsx"`
+}
+
+type RentalHistoryFilter struct {
+	From          string `form:"from" binding:"omitempty,datetime=2006-01-02" example:"2024-04-01"`
+	To            string `form:"to" binding:"omitempty,datetime=2006-01-02" example:"2024-04-30"`
+	UserID        int    `form:"user_id"`
+	CarID         int    `form:"car_id"`
+	CategoryID    int    `form:"category_id"`
+	PaymentStatus string `form:"payment_status" binding:"omitempty,oneof=unpaid settlement"`
+	Status        string `form:"status" binding:"omitempty,oneof=booked ongoing overdue returned"`
+	Q             string `form:"q"`
+	Sort          string `form:"sort" binding:"omitempty,oneof=rental_id rental_date return_date total_price fullname car_name"`
+	Order         string `form:"order" binding:"omitempty,oneof=asc desc"`
+	ExportFormat
+}
+
+type PaymentFilter struct {
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02" example:"2024-04-01"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02" example:"2024-04-30"`
+	UserID int    `form:"user_id"`
+	Status string `form:"status" binding:"omitempty,oneof=settlement"`
+	ExportFormat
+}
+
+type PaymentHistory struct {
+	PaymentID     int         `json:"payment_id"`
+	RentalID      int         `json:"rental_id"`
+	UserID        int         `json:"user_id"`
+	Fullname      string      `json:"fullname"`
+	Email         string      `json:"email"`
+	CarName       string      `json:"car_name"`
+	PaymentMethod string      `json:"payment_method"`
+	TotalPrice    money.Money `json:"total_price"`
+	PaymentStatus string      `json:"payment_status"`
+	PaymentDate   time.Time   `json:"payment_date"`
+	ReceiptNumber string      `json:"receipt_number"`
+}
+
+type WalletTransactionFilter struct {
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02" example:"2024-04-01"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02" example:"2024-04-30"`
+	UserID int    `form:"user_id"`
+	Type   string `form:"type" binding:"omitempty,oneof=topup payment"`
+	ExportFormat
+}
+
+type LoginAttemptFilter struct {
+	From    string `form:"from" binding:"omitempty,datetime=2006-01-02" example:"2024-04-01"`
+	To      string `form:"to" binding:"omitempty,datetime=2006-01-02" example:"2024-04-30"`
+	Email   string `form:"email"`
+	IP      string `form:"ip"`
+	Success string `form:"success" binding:"omitempty,oneof=true false"`
+	Reason  string `form:"reason" binding:"omitempty,oneof=invalid_credentials locked rate_limited"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// APIKey creates an API key acting as UserID, the admin creating it when
+// omitted. Keys without ExpiresAt don't expire.
+type APIKey struct {
+	Name      string     `json:"name" binding:"required,max=255" example:"kiosk jakarta"`
+	UserID    int        `json:"user_id" example:"1"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write cars:read cars:write branches:read admin:branches:write admin:pricing-rules:read admin:pricing-rules:write admin:webhooks:read admin:webhooks:write admin:login-attempts:read admin:payments:read admin:wallet-transactions:read admin:reports:read admin:cars:read admin:cars:write" example:"cars:write"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2025-01-01T00:00:00Z"`
 }
 
 type TopUp struct {
-	Amount float64 `json:"amount"`
+	Amount money.Money `json:"amount" binding:"required,gt=0" example:"100000"`
+}
+
+type ReportFilter struct {
+	From    string `form:"from" binding:"omitempty,datetime=2006-01-02" example:"2024-04-01"`
+	To      string `form:"to" binding:"omitempty,datetime=2006-01-02" example:"2024-04-30"`
+	GroupBy string `form:"group_by" binding:"omitempty,oneof=day week month"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type RevenuePeriod struct {
+	Period   string      `json:"period" example:"2024-04-01"`
+	Payments int         `json:"payments"`
+	Revenue  money.Money `json:"revenue"`
+}
+
+type CategoryRevenue struct {
+	CategoryID int         `json:"category_id"`
+	Category   string      `json:"category"`
+	Rentals    int         `json:"rentals"`
+	Revenue    money.Money `json:"revenue"`
+}
+
+type CarRevenue struct {
+	CarID       int         `json:"car_id"`
+	Name        string      `json:"name"`
+	PlateNumber string      `json:"plate_number"`
+	Rentals     int         `json:"rentals"`
+	Revenue     money.Money `json:"revenue"`
+}
+
+type CarUtilization struct {
+	CarID              int     `json:"car_id"`
+	Name               string  `json:"name"`
+	RentedHours        float64 `json:"rented_hours"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+}
+
+type Utilization struct {
+	FleetSize          int              `json:"fleet_size"`
+	PeriodHours        float64          `json:"period_hours"`
+	RentedHours        float64          `json:"rented_hours"`
+	UtilizationPercent float64          `json:"utilization_percent"`
+	Cars               []CarUtilization `json:"cars"`
+}
+
+type RentalLength struct {
+	Rentals      int     `json:"rentals"`
+	AverageHours float64 `json:"average_hours"`
+	AverageDays  float64 `json:"average_days"`
+}
+
+type CouponUsage struct {
+	CouponID   int         `json:"coupon_id"`
+	CouponName string      `json:"coupon_name"`
+	Rentals    int         `json:"rentals"`
+	Discount   money.Money `json:"discount"`
+}
+
+type TopCustomer struct {
+	UserID   int         `json:"user_id"`
+	Fullname string      `json:"fullname"`
+	Email    string      `json:"email"`
+	Rentals  int         `json:"rentals"`
+	Revenue  money.Money `json:"revenue"`
 }
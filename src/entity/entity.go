@@ -1,28 +1,64 @@
 package entity
 
 import (
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"time"
+
 	"gorm.io/datatypes"
 )
 
 type User struct {
-	ID       int      `json:"user_id" gorm:"primaryKey;column:user_id"`
-	Fullname string   `json:"fullname" gorm:"type:string;size:255;not null;"`
-	Address  string   `json:"address" gorm:"type:string;size:255;not null;"`
-	Email    string   `json:"email" gorm:"type:string;size:255;not null;unique;"`
-	Password string   `json:"password,omitempty" gorm:"type:string;size:255;not null;" swaggerignore:"true"`
-	Role     string   `json:"role" gorm:"type:string;size:255;not null;"`
-	Deposit  float64  `json:"deposit,omitempty" gorm:"not null;default:0.0"`
-	Rentals  []Rental `json:"rentals,omitempty" swaggerignore:"true"`
+	ID              int         `json:"user_id" gorm:"primaryKey;column:user_id"`
+	Fullname        string      `json:"fullname" gorm:"type:string;size:255;not null;"`
+	Address         string      `json:"address" gorm:"type:string;size:255;not null;"`
+	Email           string      `json:"email" gorm:"type:string;size:255;not null;unique;"`
+	Password        string      `json:"password,omitempty" gorm:"type:string;size:255;not null;" swaggerignore:"true"`
+	Role            string      `json:"role" gorm:"type:string;size:255;not null;"`
+	Deposit         money.Money `json:"deposit,omitempty" gorm:"not null;default:0"`
+	Language        string      `json:"language" gorm:"type:string;size:5;not null;default:en"`
+	EmailVerifiedAt *time.Time  `json:"email_verified_at" gorm:"type:timestamptz"`
+	WebhookURL      string      `json:"webhook_url,omitempty" gorm:"type:string;size:512;"`
+	Rentals         []Rental    `json:"rentals,omitempty" swaggerignore:"true"`
 }
 
 type Car struct {
-	ID               int      `json:"car_id" gorm:"primaryKey;column:car_id"`
-	CategoryID       int      `json:"category_id" gorm:"not null"`
-	Name             string   `json:"name" gorm:"type:string;size:255;not null;"`
-	Status           string   `json:"status,omitempty" gorm:"not null"`
-	RentalCostPerDay float64  `json:"rental_cost_per_day" gorm:"not null"`
-	Capacity         float64  `json:"capacity" gorm:"not null"`
-	Rentals          []Rental `json:"rentals,omitempty" swaggerignore:"true"`
+	ID                int                         `json:"car_id" gorm:"primaryKey;column:car_id"`
+	CategoryID        int                         `json:"category_id" gorm:"not null"`
+	Name              string                      `json:"name" gorm:"type:string;size:255;not null;"`
+	PlateNumber       string                      `json:"plate_number" gorm:"type:string;size:20;uniqueIndex;default:null"`
+	VIN               string                      `json:"vin,omitempty" gorm:"column:vin;type:string;size:17;uniqueIndex;default:null" binding:"omitempty,len=17"`
+	Brand             string                      `json:"brand" gorm:"type:string;size:100;"`
+	Model             string                      `json:"model" gorm:"type:string;size:100;"`
+	Year              int                         `json:"year" binding:"omitempty,min=1900"`
+	Transmission      string                      `json:"transmission" gorm:"type:string;size:20;" binding:"omitempty,oneof=manual automatic"`
+	FuelType          string                      `json:"fuel_type" gorm:"type:string;size:20;" binding:"omitempty,oneof=petrol diesel electric hybrid"`
+	Color             string                      `json:"color" gorm:"type:string;size:50;"`
+	Features          datatypes.JSONSlice[string] `json:"features" gorm:"default:'[]'" swaggertype:"array,string"`
+	HomeBranchID      *int                        `json:"home_branch_id" gorm:"index"`
+	BranchID          *int                        `json:"branch_id" gorm:"index"`
+	Status            string                      `json:"status,omitempty" gorm:"not null"`
+	RentalCostPerDay  money.Money                 `json:"rental_cost_per_day" gorm:"not null"`
+	RentalCostPerHour money.Money                 `json:"rental_cost_per_hour" gorm:"not null;default:0"`
+	Capacity          float64                     `json:"capacity" gorm:"not null"`
+	Images            []CarImage                  `json:"images,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Rentals           []Rental                    `json:"rentals,omitempty" swaggerignore:"true"`
+}
+
+type CarImage struct {
+	ID           int       `json:"image_id" gorm:"primaryKey;column:image_id"`
+	CarID        int       `json:"car_id" gorm:"not null;index"`
+	Key          string    `json:"-" gorm:"type:string;size:255;not null;"`
+	ThumbnailKey string    `json:"-" gorm:"type:string;size:255;not null;"`
+	URL          string    `json:"url" gorm:"type:string;size:512;not null;"`
+	ThumbnailURL string    `json:"thumbnail_url" gorm:"type:string;size:512;not null;"`
+	ContentType  string    `json:"content_type" gorm:"type:string;size:50;not null;"`
+	Size         int64     `json:"size" gorm:"not null"`
+	Width        int       `json:"width" gorm:"not null"`
+	Height       int       `json:"height" gorm:"not null"`
+	Position     int       `json:"position" gorm:"not null;default:0"`
+	IsPrimary    bool      `json:"is_primary" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Category struct {
@@ -31,14 +67,53 @@ type Category struct {
 	Cars []Car  `json:"cars,omitempty"`
 }
 
+type Branch struct {
+	ID        int         `json:"branch_id" gorm:"primaryKey;column:branch_id"`
+	Name      string      `json:"name" gorm:"type:string;size:255;not null;"`
+	Address   string      `json:"address" gorm:"type:string;size:255;not null;"`
+	City      string      `json:"city" gorm:"type:string;size:100;not null;"`
+	Phone     string      `json:"phone" gorm:"type:string;size:50;"`
+	OneWayFee money.Money `json:"one_way_fee" gorm:"not null;default:0"`
+}
+
 type Rental struct {
-	ID         int            `json:"rental_id" gorm:"primaryKey;column:rental_id" swaggerignore:"true"`
-	UserID     int            `json:"user_id" gorm:"not null"`
-	CarID      int            `json:"car_id" gorm:"not null"`
-	CouponID   int            `json:"coupon_id" gorm:"not null"`
-	Price      float64        `json:"price" gorm:"not null"`
-	RentalDate datatypes.Date `json:"rental_date" gorm:"not null"`
-	ReturnDate datatypes.Date `json:"return_date" gorm:"not null"`
+	ID              int                               `json:"rental_id" gorm:"primaryKey;column:rental_id" swaggerignore:"true"`
+	UserID          int                               `json:"user_id" gorm:"not null"`
+	CarID           int                               `json:"car_id" gorm:"not null"`
+	CouponID        int                               `json:"coupon_id" gorm:"not null"`
+	Price           money.Money                       `json:"price" gorm:"not null"`
+	PickupBranchID  *int                              `json:"pickup_branch_id" gorm:"index"`
+	DropoffBranchID *int                              `json:"dropoff_branch_id" gorm:"index"`
+	OneWayFee       money.Money                       `json:"one_way_fee" gorm:"not null;default:0"`
+	HourlyPrice     money.Money                       `json:"hourly_price" gorm:"not null;default:0"`
+	TotalPrice      money.Money                       `json:"total_price" gorm:"not null;default:0"`
+	Insurance       bool                              `json:"insurance" gorm:"not null;default:false"`
+	PriceLines      datatypes.JSONSlice[pricing.Line] `json:"price_lines" gorm:"default:'[]'" swaggerignore:"true"`
+	LateFee         money.Money                       `json:"late_fee" gorm:"not null;default:0"`
+	RentalDate      time.Time                         `json:"rental_date" gorm:"type:timestamptz;not null"`
+	ReturnDate      time.Time                         `json:"return_date" gorm:"type:timestamptz;not null"`
+	ReturnedAt      *time.Time                        `json:"returned_at" gorm:"type:timestamptz"`
+	// LateFeeDue is the part of the late fee of a paid rental not charged from
+	// the deposit yet, see helpers.SettleLateFees.
+	LateFeeDue money.Money `json:"late_fee_due" gorm:"not null;default:0"`
+	// InvoiceID is the Xendit invoice, it is stored once the rental is
+	// committed and stays empty when the invoice couldn't be created.
+	InvoiceID string `json:"invoice_id,omitempty" gorm:"type:string;size:100;"`
+}
+
+type PricingRule struct {
+	ID          int             `json:"pricing_rule_id" gorm:"primaryKey;column:pricing_rule_id"`
+	Name        string          `json:"name" gorm:"type:string;size:255;not null;"`
+	Type        string          `json:"type" gorm:"type:string;size:20;not null;"`
+	CategoryID  *int            `json:"category_id" gorm:"index"`
+	Percent     float64         `json:"percent" gorm:"not null"`
+	StartDate   *datatypes.Date `json:"start_date,omitempty" swaggertype:"string" format:"date"`
+	EndDate     *datatypes.Date `json:"end_date,omitempty" swaggertype:"string" format:"date"`
+	MinDays     int             `json:"min_days" gorm:"not null;default:0"`
+	MinLeadDays int             `json:"min_lead_days" gorm:"not null;default:0"`
+	Active      bool            `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
 type Payment struct {
@@ -46,15 +121,171 @@ type Payment struct {
 	RentalID        int            `json:"rental_id" gorm:"unique;not null" `
 	Rental          Rental         `json:"rental" swaggerignore:"true"`
 	PaymentMethodID int            `json:"payment_method_id" gorm:"not null"`
-	TotalPrice      float64        `json:"total_price" gorm:"not null"`
+	TotalPrice      money.Money    `json:"total_price" gorm:"not null"`
 	PaymentStatus   string         `json:"payment_status" gorm:"not null;default:settlement"`
 	PaymentDate     datatypes.Date `json:"payment_date" gorm:"not null"`
+	ReceiptNumber   string         `json:"receipt_number" gorm:"type:string;size:30;uniqueIndex;default:null"`
+	Items           []PaymentItem  `json:"items,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// PaymentItem is a line of a payment, the payment total is the sum of its
+// item amounts. Discounts have a negative amount.
+type PaymentItem struct {
+	ID          int         `json:"payment_item_id" gorm:"primaryKey;column:payment_item_id"`
+	PaymentID   int         `json:"payment_id" gorm:"not null;index"`
+	Code        string      `json:"code" gorm:"type:string;size:50;not null;"`
+	Description string      `json:"description" gorm:"type:string;size:255;not null;"`
+	Quantity    int         `json:"quantity" gorm:"not null"`
+	UnitPrice   money.Money `json:"unit_price" gorm:"not null"`
+	Amount      money.Money `json:"amount" gorm:"not null"`
+}
+
+type WalletTransaction struct {
+	ID           int         `json:"wallet_transaction_id" gorm:"primaryKey;column:wallet_transaction_id"`
+	UserID       int         `json:"user_id" gorm:"not null;index"`
+	Type         string      `json:"type" gorm:"type:string;size:20;not null;"`
+	Amount       money.Money `json:"amount" gorm:"not null"`
+	BalanceAfter money.Money `json:"balance_after" gorm:"not null"`
+	Reference    string      `json:"reference" gorm:"type:string;size:100;"`
+	CreatedAt    time.Time   `json:"created_at"`
+	// InvoiceID is the Xendit invoice of a top up.
+	InvoiceID string `json:"invoice_id,omitempty" gorm:"type:string;size:100;"`
+}
+
+// OutboxMessage is a side effect waiting to be delivered by the outbox
+// worker, dead messages exhausted their attempts.
+type OutboxMessage struct {
+	ID            int            `json:"outbox_message_id" gorm:"primaryKey;column:outbox_message_id"`
+	Kind          string         `json:"kind" gorm:"type:string;size:50;not null;"`
+	Payload       datatypes.JSON `json:"payload" gorm:"not null"`
+	Status        string         `json:"status" gorm:"type:string;size:20;not null;index:idx_outbox_due,priority:1"`
+	Attempts      int            `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time      `json:"next_attempt_at" gorm:"type:timestamptz;not null;index:idx_outbox_due,priority:2"`
+	LastError     string         `json:"last_error" gorm:"type:text"`
+	CreatedAt     time.Time      `json:"created_at"`
+	SentAt        *time.Time     `json:"sent_at" gorm:"type:timestamptz"`
+}
+
+type Notification struct {
+	ID        int        `json:"notification_id" gorm:"primaryKey;column:notification_id"`
+	UserID    int        `json:"user_id" gorm:"not null;index"`
+	Event     string     `json:"event" gorm:"type:string;size:50;not null;"`
+	Title     string     `json:"title" gorm:"type:string;size:255;not null;"`
+	Body      string     `json:"body" gorm:"type:text;not null"`
+	ReadAt    *time.Time `json:"read_at" gorm:"type:timestamptz"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	ID      int    `json:"-" gorm:"primaryKey;column:notification_preference_id"`
+	UserID  int    `json:"-" gorm:"not null;uniqueIndex:idx_notification_preference"`
+	Event   string `json:"event" gorm:"type:string;size:50;not null;uniqueIndex:idx_notification_preference"`
+	Channel string `json:"channel" gorm:"type:string;size:20;not null;uniqueIndex:idx_notification_preference"`
+	Enabled bool   `json:"enabled" gorm:"not null"`
+}
+
+// RentalNotice records a reminder or alert sent for a rental so every notice
+// is sent once.
+type RentalNotice struct {
+	ID        int       `json:"rental_notice_id" gorm:"primaryKey;column:rental_notice_id"`
+	RentalID  int       `json:"rental_id" gorm:"not null;uniqueIndex:idx_rental_notice"`
+	Kind      string    `json:"kind" gorm:"type:string;size:50;not null;uniqueIndex:idx_rental_notice"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID          int                         `json:"webhook_id" gorm:"primaryKey;column:webhook_endpoint_id"`
+	URL         string                      `json:"url" gorm:"type:string;size:512;not null;"`
+	Description string                      `json:"description" gorm:"type:string;size:255;"`
+	Events      datatypes.JSONSlice[string] `json:"events" gorm:"not null;default:'[]'" swaggertype:"array,string"`
+	Secret      string                      `json:"secret,omitempty" gorm:"type:string;size:100;not null;"`
+	Active      bool                        `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at"`
+}
+
+// WebhookDelivery is an event sent to a webhook endpoint, Status is the
+// result of the last attempt.
+type WebhookDelivery struct {
+	ID             int              `json:"delivery_id" gorm:"primaryKey;column:webhook_delivery_id"`
+	EndpointID     int              `json:"webhook_id" gorm:"column:webhook_endpoint_id;not null;index"`
+	Endpoint       WebhookEndpoint  `json:"-" gorm:"foreignKey:EndpointID;constraint:OnDelete:CASCADE;"`
+	Event          string           `json:"event" gorm:"type:string;size:50;not null;"`
+	Payload        datatypes.JSON   `json:"payload" gorm:"not null" swaggertype:"object"`
+	Status         string           `json:"status" gorm:"type:string;size:20;not null;"`
+	Attempts       int              `json:"attempts" gorm:"not null;default:0"`
+	ResponseStatus int              `json:"response_status" gorm:"not null;default:0"`
+	LastError      string           `json:"last_error" gorm:"type:text"`
+	CreatedAt      time.Time        `json:"created_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at" gorm:"type:timestamptz"`
+	AttemptLog     []WebhookAttempt `json:"attempt_log,omitempty" gorm:"foreignKey:DeliveryID;constraint:OnDelete:CASCADE;"`
+}
+
+type WebhookAttempt struct {
+	ID             int    `json:"attempt_id" gorm:"primaryKey;column:webhook_attempt_id"`
+	DeliveryID     int    `json:"delivery_id" gorm:"column:webhook_delivery_id;not null;index"`
+	ResponseStatus int    `json:"response_status" gorm:"not null;default:0"`
+	ResponseBody   string `json:"response_body" gorm:"type:text"`
+	Error          string `json:"error" gorm:"type:text"`
+	// Duration is in milliseconds.
+	Duration  int64     `json:"duration_ms" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IdempotencyKey stores the response of a request sent with an
+// Idempotency-Key header so a retry returns the same response.
+type IdempotencyKey struct {
+	ID          int    `json:"-" gorm:"primaryKey;column:idempotency_key_id"`
+	UserID      int    `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_key"`
+	Key         string `json:"key" gorm:"type:string;size:255;not null;uniqueIndex:idx_idempotency_key"`
+	RequestHash string `json:"request_hash" gorm:"type:string;size:64;not null;"`
+	// Token tells the claims of a key apart, a request only stores or
+	// releases its own claim.
+	Token        string    `json:"-" gorm:"type:string;size:32;not null;default:''"`
+	Status       string    `json:"status" gorm:"type:string;size:20;not null;"`
+	ResponseCode int       `json:"response_code" gorm:"not null;default:0"`
+	ContentType  string    `json:"content_type" gorm:"type:string;size:100;"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// LoginAttempt is a login request, failed attempts in a row lock the email
+// out, see ratelimit.Lockout. UserID is only set for registered emails.
+type LoginAttempt struct {
+	ID        int       `json:"login_attempt_id" gorm:"primaryKey;column:login_attempt_id"`
+	Email     string    `json:"email" gorm:"type:string;size:255;not null;index:idx_login_attempt_email,priority:1"`
+	UserID    *int      `json:"user_id"`
+	IP        string    `json:"ip" gorm:"type:string;size:64;not null;index"`
+	UserAgent string    `json:"user_agent" gorm:"type:string;size:512;"`
+	Success   bool      `json:"success" gorm:"not null"`
+	Reason    string    `json:"reason,omitempty" gorm:"type:string;size:30;"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_login_attempt_email,priority:2"`
+}
+
+// APIKey lets a service act as User without logging in, limited to Scopes.
+// Only the SHA-256 hash of the key is stored, Prefix tells keys apart.
+type APIKey struct {
+	ID         int                         `json:"api_key_id" gorm:"primaryKey;column:api_key_id"`
+	Name       string                      `json:"name" gorm:"type:string;size:255;not null;"`
+	Prefix     string                      `json:"prefix" gorm:"type:string;size:16;not null;"`
+	KeyHash    string                      `json:"-" gorm:"type:string;size:64;not null;uniqueIndex"`
+	UserID     int                         `json:"user_id" gorm:"not null;index"`
+	User       User                        `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Scopes     datatypes.JSONSlice[string] `json:"scopes" gorm:"not null;default:'[]'" swaggertype:"array,string"`
+	CreatedBy  int                         `json:"created_by" gorm:"not null"`
+	ExpiresAt  *time.Time                  `json:"expires_at" gorm:"type:timestamptz"`
+	RevokedAt  *time.Time                  `json:"revoked_at" gorm:"type:timestamptz"`
+	LastUsedAt *time.Time                  `json:"last_used_at" gorm:"type:timestamptz"`
+	LastUsedIP string                      `json:"last_used_ip" gorm:"type:string;size:64;"`
+	CreatedAt  time.Time                   `json:"created_at"`
 }
 
 type Coupon struct {
-	ID         int      `json:"coupon_id" gorm:"primaryKey;column:coupon_id"`
-	CouponName string   `json:"coupon_name" gorm:"type:string;size:255;not null;"`
-	Payments   []Rental `json:"payments,omitempty"`
+	ID              int        `json:"coupon_id" gorm:"primaryKey;column:coupon_id"`
+	CouponName      string     `json:"coupon_name" gorm:"type:string;size:255;not null;"`
+	DiscountPercent float64    `json:"discount_percent" gorm:"not null;default:0"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	Payments        []Rental   `json:"payments,omitempty"`
 }
 
 type PaymentMethod struct {
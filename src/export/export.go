@@ -0,0 +1,171 @@
+// Package export writes tabular data as CSV or XLSX spreadsheets one row at a
+// time, so large exports never have to be loaded into memory.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"p2-mini-project/src/money"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const sheetName = "Sheet1"
+
+// Writer writes the rows of a single table, Close must be called after the
+// last row.
+type Writer interface {
+	Write(values ...interface{}) error
+	Close() error
+}
+
+// IsFormat reports whether format is a spreadsheet format.
+func IsFormat(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename names the export of name made at t, e.g.
+// "rental-history-20240418-093000.csv".
+func Filename(name, format string, t time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", name, t.Format("20060102-150405"), format)
+}
+
+// NewWriter returns a Writer of format writing to w, times are written in
+// location.
+func NewWriter(w io.Writer, format string, location *time.Location) (Writer, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w), location: location}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, location)
+	}
+	return nil, fmt.Errorf("export: unknown format %q", format)
+}
+
+// csvWriter flushes every row, the http response buffers and chunks the
+// output by itself.
+type csvWriter struct {
+	w        *csv.Writer
+	location *time.Location
+	record   []string
+}
+
+func (cw *csvWriter) Write(values ...interface{}) error {
+	cw.record = cw.record[:0]
+	for _, v := range values {
+		cw.record = append(cw.record, format(v, cw.location))
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// xlsxWriter uses the excelize stream writer, it keeps rows on disk once the
+// sheet grows large. The zip archive can only be written out on Close.
+type xlsxWriter struct {
+	w        io.Writer
+	file     *excelize.File
+	stream   *excelize.StreamWriter
+	location *time.Location
+	row      int
+}
+
+func newXLSXWriter(w io.Writer, location *time.Location) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(sheetName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{w: w, file: file, stream: stream, location: location}, nil
+}
+
+func (xw *xlsxWriter) Write(values ...interface{}) error {
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case money.Money:
+			row[i] = v.Int64()
+		case int, int64, float64, bool:
+			row[i] = v
+		default:
+			row[i] = format(v, xw.location)
+		}
+	}
+
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.stream.SetRow(cell, row)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := xw.file.WriteTo(xw.w)
+	return err
+}
+
+// format writes money as a plain number so spreadsheets can sum it.
+func format(v interface{}, location *time.Location) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case money.Money:
+		return strconv.FormatInt(v.Int64(), 10)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.In(location).Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return format(*v, location)
+	}
+	return fmt.Sprint(v)
+}
+
+// escapeFormula keeps spreadsheet apps from evaluating user input such as
+// "=HYPERLINK(...)" as a formula, the cell is prefixed with a quote.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
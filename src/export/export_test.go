@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"p2-mini-project/src/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestCSVWriter(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	buf := new(bytes.Buffer)
+
+	w, err := NewWriter(buf, FormatCSV, jakarta)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write("id", "name", "amount", "created_at", "verified_at"))
+	assert.Nil(t, w.Write(1, "Budi, S.Kom", money.Money(150000), time.Date(2024, 4, 18, 2, 0, 0, 0, time.UTC), (*time.Time)(nil)))
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, "id,name,amount,created_at,verified_at\n1,\"Budi, S.Kom\",150000,2024-04-18T09:00:00+07:00,\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	w, err := NewWriter(buf, FormatXLSX, time.UTC)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write("id", "name", "amount"))
+	assert.Nil(t, w.Write(1, "Budi", money.Money(150000)))
+	assert.Nil(t, w.Close())
+
+	file, err := excelize.OpenReader(buf)
+	assert.Nil(t, err)
+	defer file.Close()
+
+	rows, err := file.GetRows(sheetName)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"id", "name", "amount"}, {"1", "Budi", "150000"}}, rows)
+}
+
+func TestNewWriter_unknownFormat(t *testing.T) {
+	_, err := NewWriter(new(bytes.Buffer), "pdf", nil)
+	assert.NotNil(t, err)
+}
+
+func TestWriter_escapesFormulas(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	w, err := NewWriter(buf, FormatCSV, time.UTC)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write("=HYPERLINK(\"http://evil\")", "+62812", "-1", "@SUM(A1)", "Budi", -1, money.Money(-5000)))
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, "\"'=HYPERLINK(\"\"http://evil\"\")\",'+62812,'-1,'@SUM(A1),Budi,-1,-5000\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	w, err = NewWriter(buf, FormatXLSX, time.UTC)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write("=1+1", "Budi"))
+	assert.Nil(t, w.Close())
+
+	file, err := excelize.OpenReader(buf)
+	assert.Nil(t, err)
+	defer file.Close()
+
+	rows, err := file.GetRows(sheetName)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"'=1+1", "Budi"}}, rows)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+// Admin godoc
+// @Summary Get all API keys
+// @Description Get all API keys including revoked ones, keys are only returned when they are created
+// @Tags 	 Admin
+// @Produce  json
+// @Success 200 {object} object{message=string,api_keys=[]entity.APIKey}
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/api-keys [get]
+func (ks *APIKeyService) GetAllAPIKeys(c *gin.Context) {
+	apiKeys := []entity.APIKey{}
+
+	if res := ks.db.WithContext(c.Request.Context()).Order("api_key_id").Find(&apiKeys); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllAPIKeys: failed to get all API keys", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "success get all API keys",
+		"api_keys": apiKeys,
+	})
+}
+
+// Admin godoc
+// @Summary Create API key
+// @Description Create an API key for a service to act as a user with the X-API-Key header, the key is only in this response. Reads need the <resource>:read scope and writes the <resource>:write scope, which grants reads too. Only admins' keys may have admin scopes
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param api_key body dto.APIKey true "Create new API key"
+// @Success 201 {object} object{message=string,key=string,api_key=entity.APIKey}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/api-keys [post]
+func (ks *APIKeyService) CreateAPIKey(c *gin.Context) {
+	admin_id := int(c.GetFloat64("user_id"))
+	req := new(dto.APIKey)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "CreateAPIKey: invalid body request", err))
+		return
+	}
+	if req.UserID == 0 {
+		req.UserID = admin_id
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.Error(httputil.NewFieldError("CreateAPIKey: invalid expiry", "expires_at", "expires_at must be in the future", nil))
+		return
+	}
+
+	db := ks.db.WithContext(c.Request.Context())
+
+	user := entity.User{}
+	if res := db.Select("user_id", "role").Take(&user, req.UserID); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			c.Error(httputil.NewError(http.StatusNotFound, "CreateAPIKey: user id not found", res.Error))
+			return
+		}
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateAPIKey: failed to get user", res.Error))
+		return
+	}
+	if user.Role != "admin" {
+		for _, scope := range req.Scopes {
+			if helpers.IsAdminScope(scope) {
+				msg := fmt.Sprintf("CreateAPIKey: user %d isn't an admin and can't have the %s scope", user.ID, scope)
+				c.Error(httputil.NewError(http.StatusUnprocessableEntity, msg, errors.New("admin scope for user")))
+				return
+			}
+		}
+	}
+
+	key, prefix, hash, err := helpers.NewAPIKey()
+	if err != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateAPIKey: failed to generate API key", err))
+		return
+	}
+
+	apiKey := entity.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		UserID:    user.ID,
+		Scopes:    req.Scopes,
+		CreatedBy: admin_id,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if res := db.Omit("User").Create(&apiKey); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateAPIKey: failed to create new API key", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "success create new API key",
+		"key":     key,
+		"api_key": apiKey,
+	})
+}
+
+// Admin godoc
+// @Summary Revoke API key
+// @Description Revoke API key by id, requests with it are rejected from then on
+// @Tags 	 Admin
+// @Produce  json
+// @Param    api_key_id    path     int  true  "API key id"
+// @Success 200 {object} object{message=string}
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/api-keys/{api_key_id} [delete]
+func (ks *APIKeyService) RevokeAPIKey(c *gin.Context) {
+	api_key_id := c.Param("api_key_id")
+
+	res := ks.db.WithContext(c.Request.Context()).Model(&entity.APIKey{}).
+		Where("api_key_id = ? AND revoked_at IS NULL", api_key_id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		msg := fmt.Sprintf("RevokeAPIKey: failed to revoke API key with ID [%s]", api_key_id)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "RevokeAPIKey: API key id not found or already revoked", errors.New("API key id not found")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success revoke API key with ID: " + api_key_id,
+	})
+}
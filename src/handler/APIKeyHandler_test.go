@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func apiKeyContext(body string) (*httptest.ResponseRecorder, *gin.Context) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctx.Set("user_id", float64(1))
+	return w, ctx
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	apiKeyService := NewAPIKeyService(db)
+
+	mock.ExpectQuery(`SELECT "user_id","role" FROM "users" WHERE "users"."user_id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}).AddRow(7, "user"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys"`).
+		WithArgs("kiosk", sqlmock.AnyArg(), sqlmock.AnyArg(), 7, 1, nil, nil, nil, "", sqlmock.AnyArg(), `["cars:write"]`).
+		WillReturnRows(sqlmock.NewRows([]string{"scopes", "api_key_id"}).AddRow(`["cars:write"]`, 3))
+	mock.ExpectCommit()
+
+	w, ctx := apiKeyContext(`{"name": "kiosk", "user_id": 7, "scopes": ["cars:write"]}`)
+	apiKeyService.CreateAPIKey(ctx)
+
+	var res struct {
+		Key    string `json:"key"`
+		APIKey struct {
+			ID      int    `json:"api_key_id"`
+			Prefix  string `json:"prefix"`
+			KeyHash string `json:"key_hash"`
+		} `json:"api_key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 3, res.APIKey.ID)
+	assert.True(t, strings.HasPrefix(res.Key, res.APIKey.Prefix))
+	assert.Empty(t, res.APIKey.KeyHash, "the hash isn't returned")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAPIKey_adminScopeForUser(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	apiKeyService := NewAPIKeyService(db)
+
+	mock.ExpectQuery(`SELECT "user_id","role" FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}).AddRow(7, "user"))
+
+	_, ctx := apiKeyContext(`{"name": "kiosk", "user_id": 7, "scopes": ["cars:write", "admin:reports:read"]}`)
+	apiKeyService.CreateAPIKey(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, ctx.Errors.Last().Err.(*httputil.HTTPError).Status)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,24 +1,32 @@
 package handler
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"p2-mini-project/src/dto"
 	"p2-mini-project/src/entity"
+	"p2-mini-project/src/export"
+	"p2-mini-project/src/helpers"
 	"p2-mini-project/src/httputil"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type AdminService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	location *time.Location
 }
 
-func NewAdminService(db *gorm.DB) *AdminService {
-	return &AdminService{db: db}
+// NewAdminService writes the times of exports in location.
+func NewAdminService(db *gorm.DB, location *time.Location) *AdminService {
+	return &AdminService{db: db, location: location}
 }
 
 // Admin godoc
@@ -29,9 +37,10 @@ func NewAdminService(db *gorm.DB) *AdminService {
 // @Produce  json
 // @Param car body dto.Car true "Create new car"
 // @Success 201 {object} object{message=string,car=entity.Car}
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /admin/cars [post]
 func (as *AdminService) CreateNewCar(c *gin.Context) {
 	car := new(entity.Car)
@@ -41,8 +50,25 @@ func (as *AdminService) CreateNewCar(c *gin.Context) {
 		return
 	}
 
+	car.PlateNumber = helpers.NormalizePlateNumber(car.PlateNumber)
+	if car.PlateNumber == "" {
+		c.Error(httputil.NewError(http.StatusBadRequest, "CreateNewCar: invalid body request", errors.New("plate_number is required")))
+		return
+	}
+	car.VIN = strings.ToUpper(car.VIN)
+	car.Features = helpers.NormalizeFeatures(car.Features)
+
+	if car.BranchID == nil {
+		car.BranchID = car.HomeBranchID
+	}
+
 	car.Status = "available"
-	if res := as.db.Create(&car); res.Error != nil {
+	res := as.db.WithContext(c.Request.Context()).Create(&car)
+	if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+		c.Error(httputil.NewError(http.StatusConflict, "CreateNewCar: plate number or vin already registered", res.Error).WithCode(httputil.CodeCarAlreadyRegistered))
+		return
+	}
+	if res.Error != nil {
 		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateNewCar: failed to create new car", res.Error))
 		return
 	}
@@ -62,10 +88,11 @@ func (as *AdminService) CreateNewCar(c *gin.Context) {
 // @Param    car    query     int  true  "car update by car_id"
 // @Param car body dto.Car true "Update car"
 // @Success 200 {object} object{message=string,car=entity.Car}
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /admin/cars/{car_id} [put]
 func (as *AdminService) UpdateCar(c *gin.Context) {
 	car_id := c.Param("car_id")
@@ -78,15 +105,50 @@ func (as *AdminService) UpdateCar(c *gin.Context) {
 	}
 
 	car.ID, _ = strconv.Atoi(car_id)
-
-	res := as.db.Model(&car).Updates(entity.Car{CategoryID: car.CategoryID, Name: car.Name, RentalCostPerDay: car.RentalCostPerDay, Capacity: car.Capacity})
-	if res.Error != nil {
-		msg := fmt.Sprintf("UpdateCar: failed to update car with ID [%d]", car.ID)
-		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
-		return
+	car.PlateNumber = helpers.NormalizePlateNumber(car.PlateNumber)
+	car.VIN = strings.ToUpper(car.VIN)
+	if car.Features != nil {
+		car.Features = helpers.NormalizeFeatures(car.Features)
 	}
-	if res.RowsAffected == 0 {
-		c.Error(httputil.NewError(http.StatusNotFound, "UpdateCar: car id not found", errors.New("car id not found")))
+
+	txErr := as.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&car).Updates(entity.Car{
+			CategoryID:        car.CategoryID,
+			Name:              car.Name,
+			PlateNumber:       car.PlateNumber,
+			VIN:               car.VIN,
+			Brand:             car.Brand,
+			Model:             car.Model,
+			Year:              car.Year,
+			Transmission:      car.Transmission,
+			FuelType:          car.FuelType,
+			Color:             car.Color,
+			Features:          car.Features,
+			HomeBranchID:      car.HomeBranchID,
+			BranchID:          car.BranchID,
+			RentalCostPerDay:  car.RentalCostPerDay,
+			RentalCostPerHour: car.RentalCostPerHour,
+			Capacity:          car.Capacity,
+		})
+		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+			return httputil.NewError(http.StatusConflict, "UpdateCar: plate number or vin already registered", res.Error).WithCode(httputil.CodeCarAlreadyRegistered)
+		}
+		if res.Error != nil {
+			msg := fmt.Sprintf("UpdateCar: failed to update car with ID [%d]", car.ID)
+			return httputil.NewError(http.StatusInternalServerError, msg, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return httputil.NewError(http.StatusNotFound, "UpdateCar: car id not found", errors.New("car id not found"))
+		}
+
+		// partners get the whole car, not only the updated fields
+		if err := helpers.PublishCarUpdated(tx, car.ID); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
 		return
 	}
 
@@ -104,14 +166,14 @@ func (as *AdminService) UpdateCar(c *gin.Context) {
 // @Produce  json
 // @Param    car    query     int  true  "car delete by car_id"
 // @Success 200 {object} object{message=string}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /admin/cars/{car_id} [delete]
 func (as *AdminService) DeleteCar(c *gin.Context) {
 	car_id := c.Param("car_id")
 
-	res := as.db.Delete(&entity.Car{}, car_id)
+	res := as.db.WithContext(c.Request.Context()).Delete(&entity.Car{}, car_id)
 	if res.Error != nil {
 		msg := fmt.Sprintf("DeleteCar: failed to delete car with ID [%s]", car_id)
 		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
@@ -129,17 +191,49 @@ func (as *AdminService) DeleteCar(c *gin.Context) {
 
 // Admin godoc
 // @Summary Get all users
-// @Description Get all users
+// @Description Get all users, as JSON or as a csv or xlsx spreadsheet
 // @Tags 	 Admin
 // @Produce  json
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param    format  query  string  false  "response format, defaults to json" Enums(json, csv, xlsx)
 // @Success 200 {object} object{message=string,users=[]entity.User}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /admin/users [get]
 func (as *AdminService) GetAllUsers(c *gin.Context) {
+	filter := new(dto.ExportFormat)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetAllUsers: invalid query params", err))
+		return
+	}
+
+	query := as.db.WithContext(c.Request.Context()).Model(&entity.User{}).Omit("password").Order("user_id")
+
+	if export.IsFormat(filter.Format) {
+		rows, err := query.Rows()
+		if err != nil {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllUsers: failed to get all users", err))
+			return
+		}
+		defer rows.Close()
+
+		header := []interface{}{"user_id", "fullname", "email", "address", "role", "deposit", "language", "email_verified_at"}
+		as.exportRows(c, "users", filter.Format, header, rows, func() ([]interface{}, error) {
+			var user entity.User
+			if err := as.db.ScanRows(rows, &user); err != nil {
+				return nil, err
+			}
+			return []interface{}{user.ID, user.Fullname, user.Email, user.Address, user.Role, user.Deposit, user.Language, user.EmailVerifiedAt}, nil
+		})
+		return
+	}
+
 	users := new([]entity.User)
 
-	res := as.db.Omit("password").Find(&users)
+	res := query.Find(&users)
 	if res.Error != nil {
 		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllUsers: failed to get all users", res.Error))
 		return
@@ -151,31 +245,85 @@ func (as *AdminService) GetAllUsers(c *gin.Context) {
 	})
 }
 
+// rentalStatus derives the status of a rental, overdue rentals ended without
+// being returned.
+const rentalStatus = `CASE WHEN r.returned_at IS NOT NULL THEN 'returned'
+	WHEN r.rental_date > now() THEN 'booked'
+	WHEN r.return_date < now() THEN 'overdue'
+	ELSE 'ongoing' END`
+
+var rentalHistorySorts = map[string]string{
+	"rental_id":   "r.rental_id",
+	"rental_date": "r.rental_date",
+	"return_date": "r.return_date",
+	"total_price": "total_price",
+	"fullname":    "u.fullname",
+	"car_name":    "c.name",
+}
+
 // Admin godoc
 // @Summary Get rental history
-// @Description Get rental history
+// @Description Get rental history including unpaid rentals, as JSON or as a csv or xlsx spreadsheet
 // @Tags 	 Admin
 // @Produce  json
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param    from            query  string  false  "first rental date" format(date)
+// @Param    to              query  string  false  "last rental date" format(date)
+// @Param    user_id         query  int     false  "rented by user"
+// @Param    car_id          query  int     false  "rented car"
+// @Param    category_id     query  int     false  "category of the rented car"
+// @Param    payment_status  query  string  false  "payment status" Enums(unpaid, settlement)
+// @Param    status          query  string  false  "rental status" Enums(booked, ongoing, overdue, returned)
+// @Param    q               query  string  false  "search user name, user email and car name"
+// @Param    sort            query  string  false  "sort column, defaults to rental_date" Enums(rental_id, rental_date, return_date, total_price, fullname, car_name)
+// @Param    order           query  string  false  "sort order, defaults to desc" Enums(asc, desc)
+// @Param    format          query  string  false  "response format, defaults to json" Enums(json, csv, xlsx)
 // @Success 200 {object} object{message=string,rental_history=[]dto.RentalHistory}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /admin/rental-history [get]
 func (as *AdminService) GetRentalHistory(c *gin.Context) {
-	history := new([]dto.RentalHistory)
+	filter := new(dto.RentalHistoryFilter)
 
-	rows, err := as.db.Raw("select r.rental_id, r.rental_date, r.return_date, u.user_id, u.fullname, u.address, c.car_id, c.name, p.total_price from rentals r join users u on r.user_id = u.user_id join cars c on r.car_id = c.car_id join payments p on r.rental_id = p.rental_id").Rows()
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetRentalHistory: invalid query params", err))
+		return
+	}
+
+	rows, err := as.rentalHistoryQuery(as.db.WithContext(c.Request.Context()), filter).Rows()
 	if err != nil {
 		c.Error(httputil.NewError(http.StatusInternalServerError, "GetRentalHistory: failed to query", err))
 		return
 	}
+	defer rows.Close()
+
+	scan := func(h *dto.RentalHistory) error {
+		return rows.Scan(&h.RentalID, &h.RentalDate, &h.ReturnDate, &h.UserID, &h.User.Fullname, &h.User.Email, &h.User.Address, &h.CarID, &h.Car.Name, &h.Car.CategoryID, &h.TotalPrice, &h.PaymentStatus, &h.Status)
+	}
+
+	if export.IsFormat(filter.Format) {
+		header := []interface{}{"rental_id", "rental_date", "return_date", "user_id", "fullname", "email", "address", "car_id", "car_name", "category_id", "total_price", "payment_status", "status"}
+		as.exportRows(c, "rental-history", filter.Format, header, rows, func() ([]interface{}, error) {
+			var h dto.RentalHistory
+			if err := scan(&h); err != nil {
+				return nil, err
+			}
+			return []interface{}{h.RentalID, h.RentalDate, h.ReturnDate, h.UserID, h.User.Fullname, h.User.Email, h.User.Address, h.CarID, h.Car.Name, h.Car.CategoryID, h.TotalPrice, h.PaymentStatus, h.Status}, nil
+		})
+		return
+	}
+
+	history := []dto.RentalHistory{}
 	for rows.Next() {
 		var h dto.RentalHistory
-		err := rows.Scan(&h.RentalID, &h.RentalDate, &h.ReturnDate, &h.UserID, &h.User.Fullname, &h.User.Address, &h.CarID, &h.Car.Name, &h.TotalPrice)
+		err := scan(&h)
 		if err != nil {
 			c.Error(httputil.NewError(http.StatusInternalServerError, "GetRentalHistory: failed to scan query", err))
 			return
 		}
-		*history = append(*history, h)
+		history = append(history, h)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -183,3 +331,307 @@ func (as *AdminService) GetRentalHistory(c *gin.Context) {
 		"rental_history": history,
 	})
 }
+
+// rentalHistoryQuery selects the rentals matching filter, rentals without a
+// payment are unpaid and priced with their rental total.
+func (as *AdminService) rentalHistoryQuery(db *gorm.DB, filter *dto.RentalHistoryFilter) *gorm.DB {
+	query := db.Table("rentals r").
+		Select("r.rental_id, r.rental_date, r.return_date, u.user_id, u.fullname, u.email, u.address, c.car_id, c.name, c.category_id, " +
+			"coalesce(p.total_price, r.total_price) AS total_price, coalesce(p.payment_status, 'unpaid') AS payment_status, " + rentalStatus + " AS status").
+		Joins("JOIN users u ON u.user_id = r.user_id").
+		Joins("JOIN cars c ON c.car_id = r.car_id").
+		Joins("LEFT JOIN payments p ON p.rental_id = r.rental_id")
+
+	if filter.From != "" {
+		query = query.Where("r.rental_date >= ?", as.date(filter.From))
+	}
+	if filter.To != "" {
+		query = query.Where("r.rental_date < ?", as.date(filter.To).AddDate(0, 0, 1))
+	}
+	if filter.UserID != 0 {
+		query = query.Where("r.user_id = ?", filter.UserID)
+	}
+	if filter.CarID != 0 {
+		query = query.Where("r.car_id = ?", filter.CarID)
+	}
+	if filter.CategoryID != 0 {
+		query = query.Where("c.category_id = ?", filter.CategoryID)
+	}
+	if filter.PaymentStatus != "" {
+		query = query.Where("coalesce(p.payment_status, 'unpaid') = ?", filter.PaymentStatus)
+	}
+	if filter.Status != "" {
+		query = query.Where(rentalStatus+" = ?", filter.Status)
+	}
+	if q := strings.TrimSpace(filter.Q); q != "" {
+		pattern := "%" + helpers.EscapeLike(q) + "%"
+		query = query.Where(`u.fullname ILIKE ? ESCAPE '\' OR u.email ILIKE ? ESCAPE '\' OR c.name ILIKE ? ESCAPE '\'`, pattern, pattern, pattern)
+	}
+
+	sort, ok := rentalHistorySorts[filter.Sort]
+	if !ok {
+		sort = rentalHistorySorts["rental_date"]
+	}
+	order := "DESC"
+	if filter.Order == "asc" {
+		order = "ASC"
+	}
+	return query.Order(sort + " " + order + ", r.rental_id " + order)
+}
+
+// Admin godoc
+// @Summary Get all payments
+// @Description Get all payments, as JSON or as a csv or xlsx spreadsheet
+// @Tags 	 Admin
+// @Produce  json
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param    from     query  string  false  "first payment date" format(date)
+// @Param    to       query  string  false  "last payment date" format(date)
+// @Param    user_id  query  int     false  "paid by user"
+// @Param    status   query  string  false  "payment status" Enums(settlement)
+// @Param    format   query  string  false  "response format, defaults to json" Enums(json, csv, xlsx)
+// @Success 200 {object} object{message=string,payments=[]dto.PaymentHistory}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/payments [get]
+func (as *AdminService) GetAllPayments(c *gin.Context) {
+	filter := new(dto.PaymentFilter)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetAllPayments: invalid query params", err))
+		return
+	}
+
+	query := as.db.WithContext(c.Request.Context()).Table("payments p").
+		Select("p.payment_id, p.rental_id, u.user_id, u.fullname, u.email, c.name AS car_name, pm.payment_name AS payment_method, p.total_price, p.payment_status, p.payment_date, coalesce(p.receipt_number, '') AS receipt_number").
+		Joins("JOIN rentals r ON r.rental_id = p.rental_id").
+		Joins("JOIN users u ON u.user_id = r.user_id").
+		Joins("JOIN cars c ON c.car_id = r.car_id").
+		Joins("JOIN payment_methods pm ON pm.payment_method_id = p.payment_method_id").
+		Order("p.payment_id")
+	if filter.From != "" {
+		query = query.Where("p.payment_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("p.payment_date <= ?", filter.To)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("u.user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("p.payment_status = ?", filter.Status)
+	}
+
+	if export.IsFormat(filter.Format) {
+		rows, err := query.Rows()
+		if err != nil {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllPayments: failed to get payments", err))
+			return
+		}
+		defer rows.Close()
+
+		header := []interface{}{"payment_id", "rental_id", "user_id", "fullname", "email", "car_name", "payment_method", "total_price", "payment_status", "payment_date", "receipt_number"}
+		as.exportRows(c, "payments", filter.Format, header, rows, func() ([]interface{}, error) {
+			var p dto.PaymentHistory
+			if err := as.db.ScanRows(rows, &p); err != nil {
+				return nil, err
+			}
+			return []interface{}{p.PaymentID, p.RentalID, p.UserID, p.Fullname, p.Email, p.CarName, p.PaymentMethod, p.TotalPrice, p.PaymentStatus, p.PaymentDate.Format("2006-01-02"), p.ReceiptNumber}, nil
+		})
+		return
+	}
+
+	payments := []dto.PaymentHistory{}
+
+	res := query.Scan(&payments)
+	if res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllPayments: failed to get payments", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "success get all payments",
+		"payments": payments,
+	})
+}
+
+// Admin godoc
+// @Summary Get wallet transactions
+// @Description Get the wallet ledger of all users, as JSON or as a csv or xlsx spreadsheet
+// @Tags 	 Admin
+// @Produce  json
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param    from     query  string  false  "first transaction date" format(date)
+// @Param    to       query  string  false  "last transaction date" format(date)
+// @Param    user_id  query  int     false  "wallet owner"
+// @Param    type     query  string  false  "transaction type" Enums(topup, payment)
+// @Param    format   query  string  false  "response format, defaults to json" Enums(json, csv, xlsx)
+// @Success 200 {object} object{message=string,wallet_transactions=[]entity.WalletTransaction}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/wallet-transactions [get]
+func (as *AdminService) GetWalletTransactions(c *gin.Context) {
+	filter := new(dto.WalletTransactionFilter)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetWalletTransactions: invalid query params", err))
+		return
+	}
+
+	query := as.db.WithContext(c.Request.Context()).Model(&entity.WalletTransaction{}).Order("wallet_transaction_id")
+	if filter.From != "" {
+		query = query.Where("created_at >= ?", as.date(filter.From))
+	}
+	if filter.To != "" {
+		query = query.Where("created_at < ?", as.date(filter.To).AddDate(0, 0, 1))
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	if export.IsFormat(filter.Format) {
+		rows, err := query.Rows()
+		if err != nil {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "GetWalletTransactions: failed to get wallet transactions", err))
+			return
+		}
+		defer rows.Close()
+
+		header := []interface{}{"wallet_transaction_id", "user_id", "type", "amount", "balance_after", "reference", "created_at"}
+		as.exportRows(c, "wallet-transactions", filter.Format, header, rows, func() ([]interface{}, error) {
+			var t entity.WalletTransaction
+			if err := as.db.ScanRows(rows, &t); err != nil {
+				return nil, err
+			}
+			return []interface{}{t.ID, t.UserID, t.Type, t.Amount, t.BalanceAfter, t.Reference, t.CreatedAt}, nil
+		})
+		return
+	}
+
+	transactions := []entity.WalletTransaction{}
+
+	res := query.Find(&transactions)
+	if res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetWalletTransactions: failed to get wallet transactions", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "success get wallet transactions",
+		"wallet_transactions": transactions,
+	})
+}
+
+// Admin godoc
+// @Summary Get login attempts
+// @Description Get the latest login attempts, newest first
+// @Tags 	 Admin
+// @Produce  json
+// @Param    from     query  string  false  "first attempt date" format(date)
+// @Param    to       query  string  false  "last attempt date" format(date)
+// @Param    email    query  string  false  "attempted email"
+// @Param    ip       query  string  false  "client IP"
+// @Param    success  query  bool    false  "successful attempts only, or failed attempts only"
+// @Param    reason   query  string  false  "rejection reason" Enums(invalid_credentials, locked, rate_limited)
+// @Param    limit    query  int     false  "maximum attempts, defaults to 100" minimum(1) maximum(1000)
+// @Success 200 {object} object{message=string,login_attempts=[]entity.LoginAttempt}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/login-attempts [get]
+func (as *AdminService) GetLoginAttempts(c *gin.Context) {
+	filter := &dto.LoginAttemptFilter{Limit: 100}
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetLoginAttempts: invalid query params", err))
+		return
+	}
+
+	query := as.db.WithContext(c.Request.Context()).Model(&entity.LoginAttempt{}).
+		Order("created_at DESC, login_attempt_id DESC").
+		Limit(filter.Limit)
+	if filter.From != "" {
+		query = query.Where("created_at >= ?", as.date(filter.From))
+	}
+	if filter.To != "" {
+		query = query.Where("created_at < ?", as.date(filter.To).AddDate(0, 0, 1))
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", helpers.NormalizeEmail(filter.Email))
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.Success != "" {
+		query = query.Where("success = ?", filter.Success == "true")
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+
+	attempts := []entity.LoginAttempt{}
+
+	res := query.Find(&attempts)
+	if res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetLoginAttempts: failed to get login attempts", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "success get login attempts",
+		"login_attempts": attempts,
+	})
+}
+
+// date reads a date validated by the binding in the admin location.
+func (as *AdminService) date(value string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", value, as.location)
+	return t
+}
+
+// exportRows streams rows as a spreadsheet download, next returns the values
+// of the current row. Errors are reported as usual until the first bytes are
+// sent, after that they end the download early and are only logged.
+func (as *AdminService) exportRows(c *gin.Context, name, format string, header []interface{}, rows *sql.Rows, next func() ([]interface{}, error)) {
+	w, err := export.NewWriter(c.Writer, format, as.location)
+	if err != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "exportRows: failed to create "+format+" writer", err))
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename(name, format, time.Now().In(as.location))))
+	c.Status(http.StatusOK)
+
+	err = w.Write(header...)
+	for err == nil && rows.Next() {
+		var values []interface{}
+		if values, err = next(); err == nil {
+			err = w.Write(values...)
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		return
+	}
+
+	if c.Writer.Written() {
+		slog.ErrorContext(c.Request.Context(), "exportRows: failed to export "+name, "error", err)
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	c.Error(httputil.NewError(http.StatusInternalServerError, "exportRows: failed to export "+name, err))
+}
@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"p2-mini-project/src/dto"
+	"p2-mini-project/src/httputil"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
@@ -57,7 +59,7 @@ func TestCreateNewCar_shouldSuccess(t *testing.T) {
 	sqlDB, db, mock := DbMock(t)
 	defer sqlDB.Close()
 
-	adminService := NewAdminService(db)
+	adminService := NewAdminService(db, time.UTC)
 
 	addRow := sqlmock.NewRows([]string{"category_id", "name", "rental_cost_per_day", "capacity"}).AddRow(1, "test", 50000, 123)
 	expectedSQL := "INSERT INTO \"cars\" (.+) VALUES (.+)"
@@ -72,22 +74,44 @@ func TestCreateNewCar_shouldSuccess(t *testing.T) {
 		Header: make(http.Header),
 	}
 
-	MockJsonPost(ctx, dto.Car{CategoryID: 1, Name: "toyota vios", RentalCostPerDay: 30000, Capacity: 4})
+	MockJsonPost(ctx, dto.Car{CategoryID: 1, Name: "toyota vios", PlateNumber: "b 1234 xyz", Brand: "Toyota", Model: "Vios", Year: 2022, Transmission: "automatic", FuelType: "petrol", Features: []string{"GPS"}, RentalCostPerDay: 30000, Capacity: 4})
 	adminService.CreateNewCar(ctx)
 
 	assert.Equal(t, http.StatusCreated, ctx.Writer.Status())
 	assert.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestCreateNewCar_withoutPlateNumber_shouldFail(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	adminService := NewAdminService(db, time.UTC)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+
+	ctx.Request = &http.Request{
+		Header: make(http.Header),
+	}
+
+	MockJsonPost(ctx, dto.Car{CategoryID: 1, Name: "toyota vios", RentalCostPerDay: 30000, Capacity: 4})
+	adminService.CreateNewCar(ctx)
+
+	assert.Len(t, ctx.Errors, 1)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateCar_shouldSuccess(t *testing.T) {
 	sqlDB, db, mock := DbMock(t)
 	defer sqlDB.Close()
 
-	adminService := NewAdminService(db)
+	adminService := NewAdminService(db, time.UTC)
 
 	updUserSQL := "UPDATE \"cars\" SET .+"
 	mock.ExpectBegin()
 	mock.ExpectExec(updUserSQL).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT \\* FROM \"cars\" .+").WillReturnRows(sqlmock.NewRows([]string{"car_id", "name"}).AddRow(1, "toyota vios"))
+	mock.ExpectQuery("SELECT \\* FROM \"webhook_endpoints\" WHERE active = .+").WillReturnRows(sqlmock.NewRows([]string{"webhook_endpoint_id"}))
 	mock.ExpectCommit()
 
 	w := httptest.NewRecorder()
@@ -109,7 +133,7 @@ func TestDeleteCar_shouldSuccess(t *testing.T) {
 	sqlDB, db, mock := DbMock(t)
 	defer sqlDB.Close()
 
-	adminService := NewAdminService(db)
+	adminService := NewAdminService(db, time.UTC)
 
 	delSQL := "DELETE FROM \"cars\" WHERE \"cars\".\"car_id\" = .+"
 	mock.ExpectBegin()
@@ -134,7 +158,7 @@ func TestGetAllUsers_shouldSuccess(t *testing.T) {
 	sqlDB, db, mock := DbMock(t)
 	defer sqlDB.Close()
 
-	adminService := NewAdminService(db)
+	adminService := NewAdminService(db, time.UTC)
 
 	users := sqlmock.NewRows([]string{"user_id", "full_name", "address", "email", "password", "role", "deposit"}).
 		AddRow(1, "user", "jl. user", "user@email.com", "user123", "user", 0.0).
@@ -147,9 +171,7 @@ func TestGetAllUsers_shouldSuccess(t *testing.T) {
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
-	ctx.Request = &http.Request{
-		Header: make(http.Header),
-	}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/admin/users", nil)
 
 	adminService.GetAllUsers(ctx)
 
@@ -161,25 +183,85 @@ func TestGetRentalHistory_shouldSuccess(t *testing.T) {
 	sqlDB, db, mock := DbMock(t)
 	defer sqlDB.Close()
 
-	adminService := NewAdminService(db)
+	adminService := NewAdminService(db, time.UTC)
 
-	all_history := sqlmock.NewRows([]string{"rental_id", "rental_date", "return_date", "user_id", "fullname", "address", "car_id", "name", "total_price"}).
-		AddRow(1, "2024-04-18", "2024-04-20", 1, "user", "jl user123", 1, "toyota", 30000).
-		AddRow(2, "2024-04-18", "2024-04-20", 2, "user", "jl user124", 2, "camry", 50000)
+	all_history := sqlmock.NewRows([]string{"rental_id", "rental_date", "return_date", "user_id", "fullname", "email", "address", "car_id", "name", "category_id", "total_price", "payment_status", "status"}).
+		AddRow(1, "2024-04-18", "2024-04-20", 1, "user", "user@email.com", "jl user123", 1, "toyota", 1, 30000, "settlement", "returned").
+		AddRow(2, "2024-04-18", "2024-04-20", 2, "user", "user2@email.com", "jl user124", 2, "camry", 1, 50000, "unpaid", "booked")
 
-	expectedSQL := "select r.rental_id, r.rental_date, r.return_date, u.user_id, u.fullname, u.address, c.car_id, c.name, p.total_price from rentals r join users u on r.user_id = u.user_id join cars c on r.car_id = c.car_id join payments p on r.rental_id = p.rental_id"
+	expectedSQL := `SELECT r.rental_id, r.rental_date, r.return_date, u.user_id, u.fullname, u.email, u.address, c.car_id, c.name, c.category_id, (.+) FROM rentals r JOIN users u ON u.user_id = r.user_id JOIN cars c ON c.car_id = r.car_id LEFT JOIN payments p ON p.rental_id = r.rental_id ORDER BY r.rental_date DESC, r.rental_id DESC`
 	mock.ExpectQuery(expectedSQL).WillReturnRows(all_history)
 
-	log.Default().Println("test 123")
 	w := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(w)
 
-	ctx.Request = &http.Request{
-		Header: make(http.Header),
-	}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/admin/rental-history", nil)
 
 	adminService.GetRentalHistory(ctx)
 
 	assert.Equal(t, http.StatusOK, ctx.Writer.Status())
 	assert.Nil(t, mock.ExpectationsWereMet())
 }
+
+func TestGetRentalHistory_withFilters(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	adminService := NewAdminService(db, time.UTC)
+
+	expectedSQL := `FROM rentals r (.+) WHERE r.rental_date >= \$1 AND r.rental_date < \$2 AND r.user_id = \$3 AND c.category_id = \$4 AND coalesce\(p.payment_status, 'unpaid'\) = \$5 AND \(u.fullname ILIKE \$6 ESCAPE '\\' OR u.email ILIKE \$7 ESCAPE '\\' OR c.name ILIKE \$8 ESCAPE '\\'\) ORDER BY total_price ASC, r.rental_id ASC`
+	mock.ExpectQuery(expectedSQL).
+		WithArgs(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 7, 2, "unpaid", `%budi\_%`, `%budi\_%`, `%budi\_%`).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id"}))
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/admin/rental-history?from=2024-04-01&to=2024-04-30&user_id=7&category_id=2&payment_status=unpaid&q=budi_&sort=total_price&order=asc", nil)
+
+	adminService.GetRentalHistory(ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"success get rental history","rental_history":[]}`, w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRentalHistory_invalidFilter(t *testing.T) {
+	sqlDB, db, _ := DbMock(t)
+	defer sqlDB.Close()
+
+	adminService := NewAdminService(db, time.UTC)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/admin/rental-history?status=lost", nil)
+
+	adminService.GetRentalHistory(ctx)
+
+	assert.Equal(t, http.StatusBadRequest, ctx.Errors.Last().Err.(*httputil.HTTPError).Status)
+}
+
+func TestGetRentalHistory_exportCSV(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	adminService := NewAdminService(db, time.UTC)
+
+	all_history := sqlmock.NewRows([]string{"rental_id", "rental_date", "return_date", "user_id", "fullname", "email", "address", "car_id", "name", "category_id", "total_price", "payment_status", "status"}).
+		AddRow(1, "2024-04-18", "2024-04-20", 1, "user", "user@email.com", "jl user123", 1, "toyota", 1, 30000, "settlement", "returned")
+	mock.ExpectQuery("FROM rentals r").WillReturnRows(all_history)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/admin/rental-history?format=csv", nil)
+
+	adminService.GetRentalHistory(ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "rental-history-")
+	assert.Equal(t, "rental_id,rental_date,return_date,user_id,fullname,email,address,car_id,car_name,category_id,total_price,payment_status,status\n"+
+		"1,2024-04-18,2024-04-20,1,user,user@email.com,jl user123,1,toyota,1,30000,settlement,returned\n", w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,22 +1,33 @@
 package handler
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"p2-mini-project/src/dto"
 	"p2-mini-project/src/entity"
 	"p2-mini-project/src/helpers"
 	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/ratelimit"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	db *gorm.DB
+	db             *gorm.DB
+	accountLimiter *ratelimit.Limiter
+	// ipRejectLimiter samples the logins rejected by the IP limit, only the
+	// ones it allows are recorded.
+	ipRejectLimiter *ratelimit.Limiter
+	lockout         ratelimit.Lockout
 }
 
-func NewAuthService(db *gorm.DB) *AuthService {
-	return &AuthService{db: db}
+func NewAuthService(db *gorm.DB, accountLimiter, ipRejectLimiter *ratelimit.Limiter, lockout ratelimit.Lockout) *AuthService {
+	return &AuthService{db: db, accountLimiter: accountLimiter, ipRejectLimiter: ipRejectLimiter, lockout: lockout}
 }
 
 // Auth godoc
@@ -27,32 +38,47 @@ func NewAuthService(db *gorm.DB) *AuthService {
 // @Produce  json
 // @Param user body dto.User true "Create new user"
 // @Success 201 {object} object{message=string,user=entity.User}
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users/register [post]
 func (as *AuthService) RegisterHandler(c *gin.Context) {
-	user := new(dto.User)
+	req := new(dto.User)
 
-	if err := c.ShouldBindJSON(&user); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.Error(httputil.NewError(http.StatusBadRequest, "RegisterHandler: invalid body request", err))
 		return
 	}
 
-	hashedPassword := helpers.HashPassword(user.Password)
-	user.Password = hashedPassword
-	user.Role = "user"
-
-	if res := as.db.Create(&user); res.Error != nil {
-		c.Error(httputil.NewError(http.StatusInternalServerError, "RegisterHandler: register failed", res.Error))
-		return
+	user := &entity.User{
+		Fullname: req.Fullname,
+		Address:  req.Address,
+		Email:    req.Email,
+		Password: helpers.HashPassword(req.Password),
+		Role:     "user",
+		Language: req.Language,
+	}
+	if user.Language == "" {
+		user.Language = mailer.DefaultLanguage
 	}
 
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
+	txErr := as.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if res := tx.Create(&user); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "RegisterHandler: register failed", res.Error)
+		}
+
+		verifyURL, err := verificationURL(c, user)
+		if err != nil {
+			return err
+		}
+		if err := helpers.SendRegisterEmail(tx, user, verifyURL); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
+		return
 	}
-	url := scheme + "://" + c.Request.Host + c.Request.URL.Path + "?user=" + user.Fullname
-	helpers.SendSuccessRegister(user.Email, url)
 
 	user.Password = ""
 
@@ -62,17 +88,133 @@ func (as *AuthService) RegisterHandler(c *gin.Context) {
 	})
 }
 
+// Auth godoc
+// @Summary Verify email
+// @Description Verify the email address of a user with the token sent by email
+// @Tags 	 User
+// @Produce  json
+// @Param    token  query  string  true  "verification token"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/verify [get]
+func (as *AuthService) VerifyEmail(c *gin.Context) {
+	user_id, email, err := helpers.ParseVerificationToken(c.Query("token"), time.Now())
+	if err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "VerifyEmail: invalid token", err).WithCode(httputil.CodeInvalidVerificationToken))
+		return
+	}
+
+	// the email may have changed since the token was sent, verifying twice
+	// keeps the first verification time
+	res := as.db.WithContext(c.Request.Context()).Model(&entity.User{}).Where("user_id = ? AND email = ?", user_id, email).Update("email_verified_at", gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()))
+	if res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "VerifyEmail: failed to verify email", res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "VerifyEmail: user not found", errors.New("no user with the email of the token")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "email verified",
+	})
+}
+
+// User godoc
+// @Summary Resend verification email
+// @Description Send a new verification email to the current user
+// @Tags 	 User
+// @Produce  json
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/verification [post]
+func (as *AuthService) ResendVerification(c *gin.Context) {
+	user, err := helpers.GetUserByID(as.db.WithContext(c.Request.Context()), int(c.GetFloat64("user_id")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeEmailAlreadyVerified, "ResendVerification: email already verified", "email is verified at "+user.EmailVerifiedAt.Format(time.RFC3339)))
+		return
+	}
+
+	verifyURL, err := verificationURL(c, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := helpers.SendVerificationEmail(as.db.WithContext(c.Request.Context()), user, verifyURL); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "verification email sent",
+	})
+}
+
+// User godoc
+// @Summary Update language
+// @Description Change the language of emails sent to the current user
+// @Tags 	 User
+// @Accept   json
+// @Produce  json
+// @Param language body dto.Language true "email language"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/language [put]
+func (as *AuthService) UpdateLanguage(c *gin.Context) {
+	req := new(dto.Language)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UpdateLanguage: invalid body request", err))
+		return
+	}
+
+	if res := as.db.WithContext(c.Request.Context()).Model(&entity.User{}).Where("user_id = ?", int(c.GetFloat64("user_id"))).Update("language", req.Language); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "UpdateLanguage: failed to update language", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success update language to " + req.Language,
+	})
+}
+
+func verificationURL(c *gin.Context, user *entity.User) (string, *httputil.HTTPError) {
+	token, err := helpers.CreateVerificationToken(user.ID, user.Email, time.Now())
+	if err != nil {
+		return "", httputil.NewError(http.StatusInternalServerError, "verificationURL: failed to create verification token", err)
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/api/v1/users/verify?token=" + url.QueryEscape(token), nil
+}
+
 // Auth godoc
 // @Summary User login
-// @Description User do login
+// @Description User do login, unknown emails and wrong passwords get the same response. Logins are rate limited per client IP and per email, failed logins in a row lock the email out for a while
 // @Tags 	 User
 // @Accept   json
 // @Produce  json
 // @Param user body dto.Login true "login user"
 // @Success 200 {object} object{message=string,token=string}
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 429 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Header  429 {integer} Retry-After "seconds to wait before the next login"
 // @Router /users/login [post]
 func (as *AuthService) LoginHandler(c *gin.Context) {
 	login := new(dto.Login)
@@ -82,10 +224,32 @@ func (as *AuthService) LoginHandler(c *gin.Context) {
 		return
 	}
 
+	ctx := c.Request.Context()
+	db := as.db.WithContext(ctx)
+	email := helpers.NormalizeEmail(login.Email)
+
+	if !as.allowLogin(c, as.accountLimiter, email, email) {
+		return
+	}
+
+	lockedUntil, httpErr := helpers.GetLockedUntil(db, email, as.lockout, time.Now())
+	if httpErr != nil {
+		c.Error(httpErr)
+		return
+	}
+	if wait := time.Until(lockedUntil); wait > 0 {
+		as.recordLoginAttempt(c, email, nil, helpers.LoginLocked)
+		c.Header("Retry-After", httputil.RetryAfter(wait))
+		c.Error(httputil.NewCodedError(http.StatusTooManyRequests, httputil.CodeAccountLocked, "LoginHandler: account locked", "too many failed logins, try again later"))
+		return
+	}
+
 	user := new(entity.User)
-	res := as.db.Where("email = ?", login.Email).First(&user)
-	if res.Error == gorm.ErrRecordNotFound {
-		c.Error(httputil.NewError(http.StatusNotFound, "LoginHandler: email not found", res.Error))
+	res := db.Where("lower(email) = ?", email).First(&user)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		helpers.CheckHashPassword(helpers.DummyPassword(), login.Password)
+		as.recordLoginAttempt(c, email, nil, helpers.LoginInvalidCredentials)
+		c.Error(invalidCredentials(res.Error))
 		return
 	}
 	if res.Error != nil {
@@ -94,7 +258,8 @@ func (as *AuthService) LoginHandler(c *gin.Context) {
 	}
 
 	if err := helpers.CheckHashPassword(user.Password, login.Password); err != nil {
-		c.Error(httputil.NewError(http.StatusBadRequest, "LoginHandler: password not match", err))
+		as.recordLoginAttempt(c, email, &user.ID, helpers.LoginInvalidCredentials)
+		c.Error(invalidCredentials(err))
 		return
 	}
 
@@ -103,9 +268,68 @@ func (as *AuthService) LoginHandler(c *gin.Context) {
 		c.Error(httputil.NewError(http.StatusInternalServerError, "failed create token", err))
 		return
 	}
+	as.recordLoginAttempt(c, email, &user.ID, "")
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "login successful",
 		"token":   tokenString,
 	})
 }
+
+// allowLogin takes a login of email from the key bucket of limiter, a
+// rejected login is recorded and answered with 429. Logins are let through
+// when the limiter store fails.
+func (as *AuthService) allowLogin(c *gin.Context, limiter *ratelimit.Limiter, key, email string) bool {
+	ok, wait, err := limiter.Allow(c.Request.Context(), key)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "LoginHandler: failed to check rate limit", "error", err)
+		return true
+	}
+	if !ok {
+		as.recordLoginAttempt(c, email, nil, helpers.LoginRateLimited)
+		c.Header("Retry-After", httputil.RetryAfter(wait))
+		c.Error(httputil.NewCodedError(http.StatusTooManyRequests, httputil.CodeTooManyRequests, "LoginHandler: too many logins", "too many logins, try again later"))
+	}
+	return ok
+}
+
+// RecordIPRateLimited records a login rejected by the IP limit, before its
+// body is read. It is sampled by ipRejectLimiter so a client spraying logins
+// shows up in the login attempts without a row for every request.
+func (as *AuthService) RecordIPRateLimited(c *gin.Context) {
+	ok, _, err := as.ipRejectLimiter.Allow(c.Request.Context(), c.ClientIP())
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "LoginHandler: failed to check rate limit", "error", err)
+		return
+	}
+	if ok {
+		as.recordLoginAttempt(c, "", nil, helpers.LoginRateLimited)
+	}
+}
+
+// invalidCredentials is the response to unknown emails and wrong passwords
+// alike, so logins don't tell which emails are registered.
+func invalidCredentials(err error) *httputil.HTTPError {
+	return httputil.NewError(http.StatusUnauthorized, "LoginHandler: invalid email or password", err).WithCode(httputil.CodeInvalidCredentials)
+}
+
+// recordLoginAttempt records a login, attempts without a reason succeeded.
+// A failure to record it is only logged.
+func (as *AuthService) recordLoginAttempt(c *gin.Context, email string, user_id *int, reason string) {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	attempt := entity.LoginAttempt{
+		Email:     email,
+		UserID:    user_id,
+		IP:        c.ClientIP(),
+		UserAgent: userAgent,
+		Success:   reason == "",
+		Reason:    reason,
+	}
+	if res := as.db.WithContext(c.Request.Context()).Create(&attempt); res.Error != nil {
+		slog.ErrorContext(c.Request.Context(), "LoginHandler: failed to record login attempt", "error", res.Error)
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/middleware"
+	"p2-mini-project/src/ratelimit"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLockout = ratelimit.Lockout{Threshold: 5, Base: time.Minute, Max: time.Hour, Window: 24 * time.Hour}
+
+func loginContext(body string) (*httptest.ResponseRecorder, *gin.Context) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	return w, ctx
+}
+
+func expectFailures(mock sqlmock.Sqlmock, count int, last interface{}) {
+	mock.ExpectQuery(`SELECT count\(\*\) AS count, max\(created_at\) AS last FROM "login_attempts" WHERE \(email = \$1 AND reason = \$2 AND created_at > \$3\) AND \(created_at > \(SELECT coalesce\(max\(created_at\), \$4\) FROM login_attempts WHERE email = \$5 AND success\)\)`).
+		WithArgs("budi@mail.com", helpers.LoginInvalidCredentials, sqlmock.AnyArg(), sqlmock.AnyArg(), "budi@mail.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "last"}).AddRow(count, last))
+}
+
+func expectAttempt(mock sqlmock.Sqlmock, success bool, reason string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "login_attempts"`).
+		WithArgs("budi@mail.com", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), success, reason, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"login_attempt_id"}).AddRow(1))
+	mock.ExpectCommit()
+}
+
+func TestLoginHandler_invalidCredentials(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	authService := NewAuthService(db, nil, nil, testLockout)
+
+	// unknown email
+	expectFailures(mock, 0, nil)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE lower\(email\) = \$1`).
+		WithArgs("budi@mail.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	expectAttempt(mock, false, helpers.LoginInvalidCredentials)
+
+	_, ctx := loginContext(`{"email": "budi@mail.com", "password": "secret"}`)
+	authService.LoginHandler(ctx)
+	unknown := ctx.Errors.Last().Err.(*httputil.HTTPError)
+
+	// wrong password, the email is looked up normalized
+	expectFailures(mock, 1, time.Now())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE lower\(email\) = \$1`).
+		WithArgs("budi@mail.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "password"}).AddRow(1, "budi@mail.com", helpers.HashPassword("correct")))
+	expectAttempt(mock, false, helpers.LoginInvalidCredentials)
+
+	_, ctx = loginContext(`{"email": "Budi@Mail.com", "password": "secret"}`)
+	authService.LoginHandler(ctx)
+	wrong := ctx.Errors.Last().Err.(*httputil.HTTPError)
+
+	assert.Equal(t, http.StatusUnauthorized, unknown.Status)
+	assert.Equal(t, httputil.CodeInvalidCredentials, unknown.Code)
+	assert.Equal(t, unknown.Problem("", ""), wrong.Problem("", ""), "unknown emails and wrong passwords get the same response")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginHandler_locked(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	authService := NewAuthService(db, nil, nil, testLockout)
+
+	expectFailures(mock, 6, time.Now())
+	expectAttempt(mock, false, helpers.LoginLocked)
+
+	w, ctx := loginContext(`{"email": "Budi@mail.com", "password": "secret"}`)
+	authService.LoginHandler(ctx)
+
+	err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+	assert.Equal(t, http.StatusTooManyRequests, err.Status)
+	assert.Equal(t, httputil.CodeAccountLocked, err.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginHandler_rateLimited(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), "login-account", ratelimit.Rate{Limit: 1, Period: time.Minute})
+	authService := NewAuthService(db, limiter, nil, ratelimit.Lockout{})
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE lower\(email\) = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	expectAttempt(mock, false, helpers.LoginInvalidCredentials)
+	_, ctx := loginContext(`{"email": "budi@mail.com", "password": "secret"}`)
+	authService.LoginHandler(ctx)
+
+	expectAttempt(mock, false, helpers.LoginRateLimited)
+	w, ctx := loginContext(`{"email": "budi@mail.com", "password": "secret"}`)
+	authService.LoginHandler(ctx)
+
+	err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+	assert.Equal(t, http.StatusTooManyRequests, err.Status)
+	assert.Equal(t, httputil.CodeTooManyRequests, err.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginHandler_ipRateLimited(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	store := ratelimit.NewMemoryStore()
+	ipLimiter := ratelimit.NewLimiter(store, "login-ip", ratelimit.Rate{Limit: 1, Period: time.Minute})
+	ipRejectLimiter := ratelimit.NewLimiter(store, "login-ip-reject", ratelimit.Rate{Limit: 1, Period: time.Minute})
+	authService := NewAuthService(db, nil, ipRejectLimiter, ratelimit.Lockout{})
+
+	r := gin.New()
+	r.POST("/users/login", middleware.RateLimitMiddleware(ipLimiter, authService.RecordIPRateLimited), authService.LoginHandler)
+	login := func(body string) *gin.Context {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		r.HandleContext(ctx)
+		return ctx
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	expectAttempt(mock, false, helpers.LoginInvalidCredentials)
+	login(`{"email": "budi@mail.com", "password": "secret"}`)
+
+	// logins over the IP limit are rejected before the body is read, only
+	// the first rejection of the window is recorded
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "login_attempts"`).
+		WithArgs("", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), false, helpers.LoginRateLimited, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"login_attempt_id"}).AddRow(2))
+	mock.ExpectCommit()
+	for _, body := range []string{`{"email": "budi@mail.com", "password": "secret"}`, `not json`} {
+		ctx := login(body)
+		assert.Equal(t, http.StatusTooManyRequests, ctx.Errors.Last().Err.(*httputil.HTTPError).Status)
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestVerifyEmail_userNotFound(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	helpers.VerificationSecret = []byte("secret")
+	defer func() { helpers.VerificationSecret = nil }()
+	token, _ := helpers.CreateVerificationToken(7, "budi@mail.com", time.Now())
+
+	authService := NewAuthService(db, nil, nil, testLockout)
+
+	// the email was changed after the token was sent
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "email_verified_at"=COALESCE\(email_verified_at, \$1\) WHERE user_id = \$2 AND email = \$3`).
+		WithArgs(sqlmock.AnyArg(), 7, "budi@mail.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/users/verify?token="+token, nil)
+	authService.VerifyEmail(ctx)
+
+	assert.Equal(t, http.StatusNotFound, ctx.Errors.Last().Err.(*httputil.HTTPError).Status)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BranchService struct {
+	db *gorm.DB
+}
+
+func NewBranchService(db *gorm.DB) *BranchService {
+	return &BranchService{db: db}
+}
+
+// Branch godoc
+// @Summary Get all branches
+// @Description Get all branches
+// @Tags 	 Branch
+// @Produce  json
+// @Success 200 {object} object{message=string,branches=[]entity.Branch}
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /branches [get]
+func (bs *BranchService) GetAllBranches(c *gin.Context) {
+	branches := new([]entity.Branch)
+
+	if res := bs.db.WithContext(c.Request.Context()).Order("branch_id").Find(&branches); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllBranches: failed to get all branches", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "success get all branches",
+		"branches": branches,
+	})
+}
+
+// Admin godoc
+// @Summary Create branch
+// @Description Create new branch
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param branch body dto.Branch true "Create new branch"
+// @Success 201 {object} object{message=string,branch=entity.Branch}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/branches [post]
+func (bs *BranchService) CreateBranch(c *gin.Context) {
+	req := new(dto.Branch)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "CreateBranch: invalid body request", err))
+		return
+	}
+
+	branch := entity.Branch{Name: req.Name, Address: req.Address, City: req.City, Phone: req.Phone, OneWayFee: req.OneWayFee}
+	if res := bs.db.WithContext(c.Request.Context()).Create(&branch); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateBranch: failed to create new branch", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "success create new branch",
+		"branch":  branch,
+	})
+}
+
+// Admin godoc
+// @Summary Update branch
+// @Description Update branch by id
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param    branch_id    path     int  true  "branch id"
+// @Param branch body dto.Branch true "Update branch"
+// @Success 200 {object} object{message=string,branch=entity.Branch}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/branches/{branch_id} [put]
+func (bs *BranchService) UpdateBranch(c *gin.Context) {
+	branch_id := c.Param("branch_id")
+
+	req := new(dto.Branch)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UpdateBranch: invalid body request", err))
+		return
+	}
+
+	branch := entity.Branch{Name: req.Name, Address: req.Address, City: req.City, Phone: req.Phone, OneWayFee: req.OneWayFee}
+	branch.ID, _ = strconv.Atoi(branch_id)
+
+	res := bs.db.WithContext(c.Request.Context()).Model(&branch).Select("name", "address", "city", "phone", "one_way_fee").Updates(branch)
+	if res.Error != nil {
+		msg := fmt.Sprintf("UpdateBranch: failed to update branch with ID [%d]", branch.ID)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "UpdateBranch: branch id not found", errors.New("branch id not found")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success update branch with ID: " + branch_id,
+		"branch":  branch,
+	})
+}
+
+// Admin godoc
+// @Summary Delete branch
+// @Description Delete branch by id, branches that still have cars assigned can't be deleted
+// @Tags 	 Admin
+// @Produce  json
+// @Param    branch_id    path     int  true  "branch id"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/branches/{branch_id} [delete]
+func (bs *BranchService) DeleteBranch(c *gin.Context) {
+	branch_id := c.Param("branch_id")
+
+	var cars int64
+	if res := bs.db.WithContext(c.Request.Context()).Model(&entity.Car{}).Where("branch_id = ? OR home_branch_id = ?", branch_id, branch_id).Count(&cars); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "DeleteBranch: failed to count branch cars", res.Error))
+		return
+	}
+	if cars > 0 {
+		msg := fmt.Sprintf("branch still has %d cars assigned", cars)
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeBranchInUse, "DeleteBranch: branch is in use", msg))
+		return
+	}
+
+	res := bs.db.WithContext(c.Request.Context()).Delete(&entity.Branch{}, branch_id)
+	if res.Error != nil {
+		msg := fmt.Sprintf("DeleteBranch: failed to delete branch with ID [%s]", branch_id)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "DeleteBranch: branch id not found", errors.New("branch id not found")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success delete branch with ID: " + branch_id,
+	})
+}
@@ -3,24 +3,35 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"p2-mini-project/src/dto"
 	"p2-mini-project/src/entity"
 	"p2-mini-project/src/helpers"
 	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/metrics"
+	"p2-mini-project/src/notification"
+	"p2-mini-project/src/pricing"
+	"p2-mini-project/src/receipt"
+	"p2-mini-project/src/webhook"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 type CarService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	policy   pricing.Policy
+	receipts *receipt.Generator
+	notifier *notification.Notifier
 }
 
-func NewCarService(db *gorm.DB) *CarService {
-	return &CarService{db: db}
+func NewCarService(db *gorm.DB, policy pricing.Policy, receipts *receipt.Generator, notifier *notification.Notifier) *CarService {
+	return &CarService{db: db, policy: policy, receipts: receipts, notifier: notifier}
 }
 
 // Car godoc
@@ -28,14 +39,34 @@ func NewCarService(db *gorm.DB) *CarService {
 // @Description Get all cars
 // @Tags 	 Car
 // @Produce  json
+// @Param    brand         query  string  false  "filter by brand"
+// @Param    model         query  string  false  "filter by model (partial match)"
+// @Param    transmission  query  string  false  "filter by transmission" Enums(manual, automatic)
+// @Param    fuel_type     query  string  false  "filter by fuel type" Enums(petrol, diesel, electric, hybrid)
+// @Param    color         query  string  false  "filter by color"
+// @Param    plate_number  query  string  false  "filter by plate number"
+// @Param    min_year      query  int     false  "minimum production year"
+// @Param    max_year      query  int     false  "maximum production year"
+// @Param    min_capacity  query  number  false  "minimum capacity"
+// @Param    feature       query  []string  false  "cars having all of the features" collectionFormat(multi)
+// @Param    branch_id     query  int     false  "cars currently located at the branch"
+// @Param    status        query  string  false  "filter by status" Enums(available, rented)
 // @Success 200 {object} object{message=string,cars=[]entity.Car}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /cars [get]
 func (cs *CarService) GetAllCars(c *gin.Context) {
+	filter := new(dto.CarFilter)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetAllCars: invalid query params", err))
+		return
+	}
+
 	cars := new([]entity.Car)
 
-	if res := cs.db.Find(&cars); res.Error != nil {
+	if res := helpers.FilterCars(helpers.PreloadCarImages(cs.db.WithContext(c.Request.Context())), filter).Find(&cars); res.Error != nil {
 		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllCars: fail to get all cars", res.Error))
 		return
 	}
@@ -53,23 +84,50 @@ func (cs *CarService) GetAllCars(c *gin.Context) {
 // @Accept   json
 // @Produce  json
 // @Param    category    query     int  true  "cars search by category_id"
+// @Param    brand         query  string  false  "filter by brand"
+// @Param    model         query  string  false  "filter by model (partial match)"
+// @Param    transmission  query  string  false  "filter by transmission" Enums(manual, automatic)
+// @Param    fuel_type     query  string  false  "filter by fuel type" Enums(petrol, diesel, electric, hybrid)
+// @Param    color         query  string  false  "filter by color"
+// @Param    plate_number  query  string  false  "filter by plate number"
+// @Param    min_year      query  int     false  "minimum production year"
+// @Param    max_year      query  int     false  "maximum production year"
+// @Param    min_capacity  query  number  false  "minimum capacity"
+// @Param    feature       query  []string  false  "cars having all of the features" collectionFormat(multi)
+// @Param    branch_id     query  int     false  "cars currently located at the branch"
+// @Param    status        query  string  false  "filter by status" Enums(available, rented)
 // @Success 200 {object} object{message=string,cars=[]entity.Car}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /cars/{category_id} [get]
 func (cs *CarService) GetAllCarsByCategory(c *gin.Context) {
 	c.Writer.Header().Set("Content-Type", "application/json")
 
 	id := c.Param("category_id")
 
-	cars := new([]entity.Car)
+	filter := new(dto.CarFilter)
 
-	res := cs.db.Where("category_id = ?", id).Find(&cars)
-	if res.RowsAffected == 0 {
-		c.Error(httputil.NewError(http.StatusNotFound, "GetAllCarsByCategory: cateogry id not found", errors.New("cateogry id not found")))
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetAllCarsByCategory: invalid query params", err))
 		return
 	}
+
+	db := cs.db.WithContext(c.Request.Context())
+
+	if res := db.Take(&entity.Category{}, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			c.Error(httputil.NewError(http.StatusNotFound, "GetAllCarsByCategory: category id not found", res.Error))
+			return
+		}
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllCarsByCategory: fail to get category", res.Error))
+		return
+	}
+
+	cars := []entity.Car{}
+
+	res := helpers.FilterCars(helpers.PreloadCarImages(db), filter).Where("category_id = ?", id).Find(&cars)
 	if res.Error != nil {
 		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllCarsByCategory: fail to get all cars by category", res.Error))
 		return
@@ -82,72 +140,217 @@ func (cs *CarService) GetAllCarsByCategory(c *gin.Context) {
 }
 
 // Car godoc
-// @Summary Rent a car
-// @Description Rent a car
+// @Summary Quote a car rental
+// @Description Price a rental without creating it, the returned quote_token can be sent to rent a car to lock the price until the quote expires
 // @Tags 	 Car
 // @Accept   json
 // @Produce  json
-// @Param rental body dto.Rental true "user rent a car"
-// @Success 201 {object} object{message=string,rental=entity.Rental,invoice=entity.Invoice}
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
-// @Router /cars [post]
-func (cs *CarService) RentalCar(c *gin.Context) {
-	c.Writer.Header().Set("Content-Type", "application/json")
-
+// @Param rental body dto.Rental true "rental to quote"
+// @Success 200 {object} object{message=string,quote=dto.Quote}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /cars/quote [post]
+func (cs *CarService) QuoteRentalCar(c *gin.Context) {
 	rental := new(dto.Rental)
 
 	if err := c.ShouldBindJSON(&rental); err != nil {
-		c.Error(httputil.NewError(http.StatusBadRequest, "RentalCar: invalid body request", err))
+		c.Error(httputil.NewError(http.StatusBadRequest, "QuoteRentalCar: invalid body request", err))
 		return
 	}
-	price, err := helpers.GetPrice(cs.db, rental)
+	rental.UserID = int(c.GetFloat64("user_id"))
+
+	car, err := cs.prepareRental(cs.db.WithContext(c.Request.Context()), rental)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	err = helpers.CheckCarStatus(cs.db, rental.CarID)
+
+	now := time.Now()
+	breakdown, err := helpers.QuoteRental(cs.db.WithContext(c.Request.Context()), rental, car, cs.policy, now)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	rental.UserID = int(c.GetFloat64("user_id"))
+	quote := &dto.Quote{
+		UserID:          rental.UserID,
+		CarID:           rental.CarID,
+		CouponID:        rental.CouponID,
+		PickupBranchID:  rental.PickupBranchID,
+		DropoffBranchID: rental.DropoffBranchID,
+		RentalDate:      rental.RentalDate,
+		ReturnDate:      rental.ReturnDate,
+		Insurance:       rental.Insurance,
+		Breakdown:       *breakdown,
+		ExpiresAt:       now.Add(cs.policy.QuoteTTL).Truncate(time.Second),
+	}
+	token, errSign := helpers.SignQuote(quote, cs.policy.QuoteSecret)
+	if errSign != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "QuoteRentalCar: failed to sign quote", errSign))
+		return
+	}
+	quote.Token = token
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success quote rental car",
+		"quote":   quote,
+	})
+}
+
+// prepareRental validates the rental period, car and branches and fills in
+// the car prices shared by quoting and renting.
+func (cs *CarService) prepareRental(db *gorm.DB, rental *dto.Rental) (*entity.Car, *httputil.HTTPError) {
+	err := helpers.NormalizeRentalDates(rental, cs.policy)
+	if err != nil {
+		return nil, err
+	}
+	price, err := helpers.GetPrice(db, rental)
+	if err != nil {
+		return nil, err
+	}
+	err = helpers.CheckCarStatus(db, rental.CarID)
+	if err != nil {
+		return nil, err
+	}
+
+	car, err := helpers.GetCarByID(db, rental.CarID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = helpers.ResolveRentalBranches(db, rental, car)
+	if err != nil {
+		return nil, err
+	}
+
 	rental.Price = price
+	rental.HourlyPrice = pricing.HourlyRate(car.RentalCostPerDay, car.RentalCostPerHour)
+
+	return car, nil
+}
+
+// Car godoc
+// @Summary Rent a car
+// @Description Rent a car, send quote_token from quote a car rental to pay the quoted price. The invoice is created after the rental, it is null when the payment gateway fails and the rental can still be paid
+// @Tags 	 Car
+// @Accept   json
+// @Produce  json
+// @Param rental body dto.Rental true "user rent a car"
+// @Param    Idempotency-Key  header  string  false  "retries with the same key return the first response"
+// @Success 201 {object} object{message=string,rental=entity.Rental,price_breakdown=pricing.Breakdown,invoice=entity.Invoice}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /cars [post]
+func (cs *CarService) RentalCar(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "application/json")
 
-	// create rental
-	if res := cs.db.Create(&rental); res.Error != nil {
-		c.Error(httputil.NewError(http.StatusInternalServerError, "RentalCar: failed to rental car", res.Error))
+	rental := new(dto.Rental)
+
+	if err := c.ShouldBindJSON(&rental); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "RentalCar: invalid body request", err))
 		return
 	}
+	rental.UserID = int(c.GetFloat64("user_id"))
 
-	user, err := helpers.GetUserByID(cs.db, rental.UserID)
+	car, err := cs.prepareRental(cs.db.WithContext(c.Request.Context()), rental)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	car, err := helpers.GetCarByID(cs.db, rental.CarID)
+	var breakdown *pricing.Breakdown
+	if rental.QuoteToken != "" {
+		quote, errQuote := helpers.VerifyQuote(rental.QuoteToken, cs.policy.QuoteSecret, time.Now())
+		if errQuote != nil {
+			c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeQuoteInvalid, "RentalCar: invalid quote", errQuote.Error()))
+			return
+		}
+		err = helpers.MatchQuote(quote, rental, cs.policy)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		breakdown = &quote.Breakdown
+	} else {
+		breakdown, err = helpers.QuoteRental(cs.db.WithContext(c.Request.Context()), rental, car, cs.policy, time.Now())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	rental.TotalPrice = breakdown.Total
+	rental.PriceLines = breakdown.Lines
+
+	user, err := helpers.GetUserByID(cs.db.WithContext(c.Request.Context()), rental.UserID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	totalPrice := helpers.CalculateTotalPriceWithFormatStr(rental)
-	invoiceRes, errInvoice := helpers.CreateInvoiceRental(&totalPrice, user, car)
-	if errInvoice != nil {
-		c.Error(httputil.NewError(http.StatusInternalServerError, "RentalCar: failed to create invoice", errInvoice))
+	rentalDate, _ := cs.policy.ParseTime(rental.RentalDate)
+	returnDate, _ := cs.policy.ParseTime(rental.ReturnDate)
+	db := cs.db.WithContext(c.Request.Context())
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		// create rental
+		if res := tx.Create(&rental); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "RentalCar: failed to rental car", res.Error)
+		}
+
+		if err := helpers.PublishWebhook(tx, webhook.EventRentalCreated, helpers.NewRentalWebhook(rental, rentalDate, returnDate)); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
 		return
 	}
+	metrics.RentalsCreated.Inc()
+
+	// the invoice is created once the rental is committed so no row is locked
+	// while Xendit is called, the rental can still be paid without it
+	invoiceRes, errInvoice := helpers.CreateInvoiceRental(c.Request.Context(), breakdown, user, car)
+	if errInvoice != nil {
+		slog.ErrorContext(c.Request.Context(), "RentalCar: failed to create invoice", "rental_id", rental.ID, "error", errInvoice)
+		invoiceRes = nil
+	}
 
-	helpers.SendSuccessRental(user.Email, invoiceRes.InvoiceUrl)
+	txErr = db.Transaction(func(tx *gorm.DB) error {
+		data := mailer.Data{
+			"RentalID":   rental.ID,
+			"CarName":    car.Name,
+			"RentalDate": cs.policy.Local(rentalDate),
+			"ReturnDate": cs.policy.Local(returnDate),
+			"Total":      rental.TotalPrice,
+		}
+		if invoiceRes != nil {
+			if res := tx.Model(&entity.Rental{}).Where("rental_id = ?", rental.ID).Update("invoice_id", invoiceRes.ID); res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "RentalCar: failed to store invoice", res.Error)
+			}
+			data["InvoiceURL"] = invoiceRes.InvoiceUrl
+		}
+
+		if err := helpers.Notify(tx, cs.notifier, notification.EventRentalCreated, user, data); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		// the rental is created, only the invoice link is missing
+		slog.ErrorContext(c.Request.Context(), "RentalCar: failed to store invoice and notify", "rental_id", rental.ID, "error", txErr)
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "success rental a car",
-		"rental":  rental,
-		"invoice": invoiceRes,
+		"message":         "success rental a car",
+		"rental":          rental,
+		"price_breakdown": breakdown,
+		"invoice":         invoiceRes,
 	})
 }
 
@@ -159,11 +362,14 @@ func (cs *CarService) RentalCar(c *gin.Context) {
 // @Produce  json
 // @Param    pay    query     int  true  "pay rental car by rental_id"
 // @Param pay body dto.Payment true "user pay rented a car"
+// @Param    Idempotency-Key  header  string  false  "retries with the same key return the first response"
 // @Success 200 {object} object{message=string,payment=entity.Payment}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.Problem
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /cars/pay/{rental_id} [post]
 func (cs *CarService) PayRentalCar(c *gin.Context) {
 	c.Writer.Header().Set("Content-Type", "application/json")
@@ -177,7 +383,7 @@ func (cs *CarService) PayRentalCar(c *gin.Context) {
 		return
 	}
 
-	rental, err := helpers.GetRentalByID(cs.db, rental_id)
+	rental, err := helpers.GetRentalByID(cs.db.WithContext(c.Request.Context()), rental_id)
 	if err != nil {
 		c.Error(err)
 		return
@@ -185,63 +391,115 @@ func (cs *CarService) PayRentalCar(c *gin.Context) {
 
 	payment.PaymentStatus = "settlement"
 	payment.PaymentDate = time.Now().Format("2006-01-02")
-	payment.TotalPrice = helpers.CalculateTotalPriceWithFormatRFC(rental)
+	breakdown, err := helpers.GetRentalBreakdown(cs.db.WithContext(c.Request.Context()), rental, cs.policy)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	payment.TotalPrice = breakdown.Total
 	payment.RentalID = rental.ID
 
 	isLoginUser := helpers.CheckAuthorizeUser(int(c.GetFloat64("user_id")), rental.UserID)
 	if !isLoginUser {
-		c.Error(httputil.NewError(http.StatusUnauthorized, "PayRentalCar: failed to pay rental car", errors.New("only authorize user can do this action")))
+		c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeNotRentalOwner, "PayRentalCar: failed to pay rental car", "only authorize user can do this action"))
 		return
 	}
 
-	currDeposit, err := helpers.GetUserDeposit(cs.db, rental.UserID)
+	currDeposit, err := helpers.GetUserDeposit(cs.db.WithContext(c.Request.Context()), rental.UserID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 	// check balance and rental cost
 	if currDeposit < payment.TotalPrice {
-		paymentError := fmt.Sprintf("your deposit is %.2f while total payment is %.2f", currDeposit, payment.TotalPrice)
-		c.Error(httputil.NewError(http.StatusBadRequest, "PayRentalCar: your deposit is not enough", errors.New(paymentError)))
+		paymentError := fmt.Sprintf("your deposit is %s while total payment is %s", currDeposit, payment.TotalPrice)
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeInsufficientDeposit, "PayRentalCar: your deposit is not enough", paymentError))
 		return
 	}
-	updatedDeposit := currDeposit - payment.TotalPrice
 
-	txErr := cs.db.Transaction(func(tx *gorm.DB) error {
+	txErr := cs.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
 
 		// update deposit
-		if res := tx.Model(&entity.User{}).Where("user_id = ?", rental.UserID).Update("deposit", updatedDeposit); res.Error != nil {
-			return httputil.NewError(http.StatusInternalServerError, "PayRentalCar: failed to update deposit", res.Error)
+		if err := helpers.DebitDeposit(tx, rental.UserID, payment.TotalPrice, helpers.WalletPayment, fmt.Sprintf("rental:%d", rental.ID)); err != nil {
+			return err
 		}
 
 		// update car status
 		if res := tx.Model(&entity.Car{}).Where("car_id = ?", rental.CarID).Update("status", "rented"); res.Error != nil {
 			return httputil.NewError(http.StatusInternalServerError, "PayRentalCar: failed to update car status", res.Error)
 		}
+		if err := helpers.PublishCarUpdated(tx, rental.CarID); err != nil {
+			return err
+		}
 
 		// create payment
 		res := tx.Create(&payment)
 		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
-			return httputil.NewError(http.StatusBadRequest, "PayRentalCar: already paid", res.Error)
+			return httputil.NewCodedError(http.StatusBadRequest, httputil.CodeRentalAlreadyPaid, "PayRentalCar: already paid", "rental is already paid")
 		}
 		if res.Error != nil {
 			return httputil.NewError(http.StatusInternalServerError, "PayRentalCar: failed to create payment", res.Error)
 		}
 
+		// create payment items
+		payment.Items = helpers.NewPaymentItems(payment.ID, breakdown.Lines)
+		if res := tx.Create(&payment.Items); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "PayRentalCar: failed to create payment items", res.Error)
+		}
+
+		payment.ReceiptNumber = receipt.Number(payment.ID, time.Now())
+		if res := tx.Model(&entity.Payment{}).Where("payment_id = ?", payment.ID).Update("receipt_number", payment.ReceiptNumber); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "PayRentalCar: failed to create receipt number", res.Error)
+		}
+
+		if err := helpers.PublishWebhook(tx, webhook.EventPaymentSettled, payment); err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if txErr != nil {
 		c.Error(txErr)
 		return
 	}
-
-	email, err := helpers.GetUserEmail(cs.db, rental.UserID)
-	if err != nil {
-		c.Error(err)
-		return
+	metrics.PaymentsSettled.Inc()
+	metrics.PaymentAmount.Add(float64(payment.TotalPrice))
+
+	// the receipt is rendered from the committed payment so no row is locked
+	// while the PDF is drawn, a receipt that fails to render can still be
+	// downloaded later
+	db := cs.db.WithContext(c.Request.Context())
+	attachments := []mailer.Attachment{}
+	paid, httpErr := helpers.GetPaymentByID(db, payment.ID)
+	if httpErr == nil {
+		var pdf []byte
+		if pdf, httpErr = helpers.RenderReceipt(db, cs.receipts, paid); httpErr == nil {
+			attachments = append(attachments, mailer.Attachment{Name: "receipt-" + paid.ReceiptNumber + ".pdf", Data: pdf})
+		}
+	}
+	if httpErr != nil {
+		slog.ErrorContext(c.Request.Context(), "PayRentalCar: failed to render receipt", "payment_id", payment.ID, "error", httpErr)
 	}
 
-	helpers.SendSuccessPayment(email, payment.TotalPrice)
+	txErr = db.Transaction(func(tx *gorm.DB) error {
+		user, err := helpers.GetUserByID(tx, rental.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := helpers.Notify(tx, cs.notifier, notification.EventPaymentSucceeded, user, mailer.Data{
+			"RentalID":      rental.ID,
+			"ReceiptNumber": payment.ReceiptNumber,
+			"Total":         payment.TotalPrice,
+		}, attachments...); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		// the payment is settled, only the notification is missing
+		slog.ErrorContext(c.Request.Context(), "PayRentalCar: failed to notify", "payment_id", payment.ID, "error", txErr)
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "success pay rental car",
@@ -251,20 +509,21 @@ func (cs *CarService) PayRentalCar(c *gin.Context) {
 
 // Car godoc
 // @Summary Return rented car
-// @Description Return rented car
+// @Description Return rented car, a car returned after the return date is billed a late fee from the user deposit. The return never fails on the deposit, late_fee_due is the late fee the deposit couldn't cover, it is charged after the next top up
 // @Tags 	 Car
 // @Accept   json
 // @Produce  json
 // @Param    rental    query     int  true  "return rental car by rental_id"
-// @Success 200 {object} object{message=string}
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Success 200 {object} object{message=string,late_fee=pricing.Breakdown,late_fee_due=int}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /cars/return/{rental_id} [post]
 func (cs *CarService) ReturnRentalCar(c *gin.Context) {
 	rental_id, _ := strconv.Atoi(c.Param("rental_id"))
 
-	rental, err := helpers.GetRentalByID(cs.db, rental_id)
+	rental, err := helpers.GetRentalByID(cs.db.WithContext(c.Request.Context()), rental_id)
 	if err != nil {
 		c.Error(err)
 		return
@@ -272,27 +531,115 @@ func (cs *CarService) ReturnRentalCar(c *gin.Context) {
 
 	isLoginUser := helpers.CheckAuthorizeUser(int(c.GetFloat64("user_id")), rental.UserID)
 	if !isLoginUser {
-		c.Error(httputil.NewError(http.StatusUnauthorized, "ReturnRentalCar: failed to return rental car", errors.New("only authorize user can do this action")))
+		c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeNotRentalOwner, "ReturnRentalCar: failed to return rental car", "only authorize user can do this action"))
 		return
 	}
 
-	car, err := helpers.GetCarByID(cs.db, rental.CarID)
+	car, err := helpers.GetCarByID(cs.db.WithContext(c.Request.Context()), rental.CarID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	if car.Status == "available" {
-		c.Error(httputil.NewError(http.StatusBadRequest, "ReturnRentalCar: car already return", errors.New("car status is available")))
+	if car.Status == "available" || rental.ReturnedAt != nil {
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeCarAlreadyReturned, "ReturnRentalCar: car already return", "car status is available"))
 		return
 	}
 
-	// update status
-	if res := cs.db.Model(&entity.Car{}).Where("car_id = ?", rental.CarID).Update("status", "available"); res.Error != nil {
-		c.Error(httputil.NewError(http.StatusInternalServerError, "ReturnRentalCar: failed to update status", res.Error))
+	payment, err := helpers.GetPaymentByRentalID(cs.db.WithContext(c.Request.Context()), rental.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	returnDate, errParse := cs.policy.ParseTime(rental.ReturnDate)
+	if errParse != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "ReturnRentalCar: invalid return_date", errParse))
+		return
+	}
+	returnedAt := time.Now()
+	lateFee := cs.policy.LateFee(rental.Price, rental.HourlyPrice, returnDate, returnedAt)
+
+	txErr := cs.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		rentalUpdates := map[string]interface{}{"returned_at": returnedAt, "late_fee": lateFee.Total}
+
+		if len(lateFee.Lines) > 0 && payment != nil {
+			// paid rentals owe the late fee, it is charged from the deposit
+			// after the return so a low deposit can't keep the car rented
+			rentalUpdates["late_fee_due"] = lateFee.Total
+			items := helpers.NewPaymentItems(payment.ID, lateFee.Lines)
+			if res := tx.Create(&items); res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "ReturnRentalCar: failed to create payment items", res.Error)
+			}
+			if res := tx.Model(payment).Update("total_price", gorm.Expr("total_price + ?", lateFee.Total)); res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "ReturnRentalCar: failed to update payment", res.Error)
+			}
+		} else if len(lateFee.Lines) > 0 {
+			// unpaid rentals pay the late fee with the rental
+			breakdown, err := helpers.GetRentalBreakdown(tx, rental, cs.policy)
+			if err != nil {
+				return err
+			}
+			rentalUpdates["price_lines"] = datatypes.NewJSONSlice(append(breakdown.Lines, lateFee.Lines...))
+			rentalUpdates["total_price"] = breakdown.Total + lateFee.Total
+		}
+
+		if res := tx.Model(&entity.Rental{}).Where("rental_id = ?", rental.ID).Updates(rentalUpdates); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "ReturnRentalCar: failed to update rental", res.Error)
+		}
+
+		// update status and move the car to the drop-off branch
+		updates := map[string]interface{}{"status": "available"}
+		if rental.DropoffBranchID != nil {
+			updates["branch_id"] = *rental.DropoffBranchID
+		}
+		if res := tx.Model(&entity.Car{}).Where("car_id = ?", rental.CarID).Updates(updates); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "ReturnRentalCar: failed to update status", res.Error)
+		}
+		if err := helpers.PublishCarUpdated(tx, rental.CarID); err != nil {
+			return err
+		}
+
+		user, err := helpers.GetUserByID(tx, rental.UserID)
+		if err != nil {
+			return err
+		}
+		if err := helpers.Notify(tx, cs.notifier, notification.EventRentalReturned, user, mailer.Data{
+			"RentalID":   rental.ID,
+			"CarName":    car.Name,
+			"ReturnedAt": cs.policy.Local(returnedAt),
+			"LateFee":    lateFee.Total,
+		}); err != nil {
+			return err
+		}
+
+		if err := helpers.PublishWebhook(tx, webhook.EventRentalReturned, gin.H{
+			"rental_id":         rental.ID,
+			"car_id":            rental.CarID,
+			"user_id":           rental.UserID,
+			"dropoff_branch_id": rental.DropoffBranchID,
+			"returned_at":       returnedAt,
+			"late_fee":          lateFee,
+		}); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
 		return
 	}
 
+	lateFeeDue, err := helpers.SettleLateFees(cs.db.WithContext(c.Request.Context()), rental.UserID)
+	if err != nil {
+		// the car is returned, the fee stays due until the next settlement
+		slog.WarnContext(c.Request.Context(), "ReturnRentalCar: failed to settle late fees", "rental_id", rental.ID, "error", err)
+		lateFeeDue = lateFee.Total
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "success return rental car",
+		"message":      "success return rental car",
+		"late_fee":     lateFee,
+		"late_fee_due": lateFeeDue,
 	})
 }
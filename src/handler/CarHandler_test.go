@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/pricing"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func categoryContext(query string) (*httptest.ResponseRecorder, *gin.Context) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/cars/1"+query, nil)
+	ctx.Params = gin.Params{{Key: "category_id", Value: "1"}}
+	return w, ctx
+}
+
+func TestGetAllCarsByCategory_noMatches(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	carService := NewCarService(db, pricing.Policy{}, nil, nil)
+
+	mock.ExpectQuery(`SELECT \* FROM "categories" WHERE "categories"."category_id" = \$1`).
+		WithArgs("1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "type"}).AddRow(1, "SUV"))
+	mock.ExpectQuery(`SELECT \* FROM "cars" WHERE lower\(brand\) = lower\(\$1\) AND category_id = \$2`).
+		WithArgs("Toyota", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"car_id"}))
+
+	w, ctx := categoryContext("?brand=Toyota")
+	carService.GetAllCarsByCategory(ctx)
+
+	assert.Empty(t, ctx.Errors)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message": "success get all cars by category", "cars": []}`, w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllCarsByCategory_unknownCategory(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	carService := NewCarService(db, pricing.Policy{}, nil, nil)
+
+	mock.ExpectQuery(`SELECT \* FROM "categories"`).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id"}))
+
+	_, ctx := categoryContext("")
+	carService.GetAllCarsByCategory(ctx)
+
+	assert.Equal(t, http.StatusNotFound, ctx.Errors.Last().Err.(*httputil.HTTPError).Status)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
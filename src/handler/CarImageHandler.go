@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/storage"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxImagesPerUpload = 10
+
+type CarImageService struct {
+	db           *gorm.DB
+	storage      storage.Storage
+	maxImageSize int64
+}
+
+func NewCarImageService(db *gorm.DB, storage storage.Storage, maxImageSize int64) *CarImageService {
+	return &CarImageService{db: db, storage: storage, maxImageSize: maxImageSize}
+}
+
+// Admin godoc
+// @Summary Upload car images
+// @Description Upload one or more jpeg/png images for a car, a thumbnail is generated for each image
+// @Tags 	 Admin
+// @Accept   multipart/form-data
+// @Produce  json
+// @Param    car_id  path      int   true  "car id"
+// @Param    images  formData  file  true  "car images"
+// @Success 201 {object} object{message=string,images=[]entity.CarImage}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 413 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/cars/{car_id}/images [post]
+func (cis *CarImageService) UploadCarImages(c *gin.Context) {
+	car_id, _ := strconv.Atoi(c.Param("car_id"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cis.maxImageSize*maxImagesPerUpload+(1<<20))
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UploadCarImages: invalid multipart form", err))
+		return
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UploadCarImages: invalid multipart form", errors.New("images is required")))
+		return
+	}
+	if len(files) > maxImagesPerUpload {
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeTooManyImages, "UploadCarImages: too many images", fmt.Sprintf("maximum %d images per upload", maxImagesPerUpload)))
+		return
+	}
+
+	car := new(entity.Car)
+	res := cis.db.WithContext(c.Request.Context()).Preload("Images").Where("car_id = ?", car_id).First(&car)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		c.Error(httputil.NewError(http.StatusNotFound, "UploadCarImages: car id not found", res.Error))
+		return
+	}
+	if res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "UploadCarImages: failed to get car", res.Error))
+		return
+	}
+
+	position := 0
+	hasPrimary := false
+	for _, image := range car.Images {
+		position = max(position, image.Position+1)
+		hasPrimary = hasPrimary || image.IsPrimary
+	}
+
+	images := []entity.CarImage{}
+	for _, file := range files {
+		image, httpErr := cis.storeImage(c, car_id, file)
+		if httpErr != nil {
+			cis.deleteFiles(c, images)
+			c.Error(httpErr)
+			return
+		}
+		image.Position = position
+		image.IsPrimary = !hasPrimary
+		position++
+		hasPrimary = true
+
+		images = append(images, *image)
+	}
+
+	if res := cis.db.WithContext(c.Request.Context()).Create(&images); res.Error != nil {
+		cis.deleteFiles(c, images)
+		c.Error(httputil.NewError(http.StatusInternalServerError, "UploadCarImages: failed to save images", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "success upload car images",
+		"images":  images,
+	})
+}
+
+// Admin godoc
+// @Summary Update car image
+// @Description Change the position of a car image or make it the primary image
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param    car_id    path  int  true  "car id"
+// @Param    image_id  path  int  true  "image id"
+// @Param image body dto.CarImage true "image position and primary flag"
+// @Success 200 {object} object{message=string,image=entity.CarImage}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/cars/{car_id}/images/{image_id} [patch]
+func (cis *CarImageService) UpdateCarImage(c *gin.Context) {
+	req := new(dto.CarImage)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UpdateCarImage: invalid body request", err))
+		return
+	}
+
+	image, err := cis.getCarImage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	txErr := cis.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if req.IsPrimary != nil && *req.IsPrimary {
+			if res := tx.Model(&entity.CarImage{}).Where("car_id = ? AND image_id <> ?", image.CarID, image.ID).Update("is_primary", false); res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "UpdateCarImage: failed to update primary image", res.Error)
+			}
+			image.IsPrimary = true
+		}
+		if req.Position != nil {
+			image.Position = *req.Position
+		}
+
+		if res := tx.Model(image).Select("position", "is_primary").Updates(image); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "UpdateCarImage: failed to update image", res.Error)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success update car image",
+		"image":   image,
+	})
+}
+
+// Admin godoc
+// @Summary Delete car image
+// @Description Delete a car image, the next image becomes primary when the primary image is deleted
+// @Tags 	 Admin
+// @Produce  json
+// @Param    car_id    path  int  true  "car id"
+// @Param    image_id  path  int  true  "image id"
+// @Success 200 {object} object{message=string}
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/cars/{car_id}/images/{image_id} [delete]
+func (cis *CarImageService) DeleteCarImage(c *gin.Context) {
+	image, err := cis.getCarImage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	txErr := cis.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if res := tx.Delete(image); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "DeleteCarImage: failed to delete image", res.Error)
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+
+		next := new(entity.CarImage)
+		res := tx.Where("car_id = ?", image.CarID).Order("position, image_id").First(&next)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "DeleteCarImage: failed to get next image", res.Error)
+		}
+		if res := tx.Model(next).Update("is_primary", true); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "DeleteCarImage: failed to update primary image", res.Error)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
+		return
+	}
+
+	cis.deleteFiles(c, []entity.CarImage{*image})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success delete car image with ID: " + c.Param("image_id"),
+	})
+}
+
+func (cis *CarImageService) getCarImage(c *gin.Context) (*entity.CarImage, *httputil.HTTPError) {
+	image := new(entity.CarImage)
+
+	res := cis.db.WithContext(c.Request.Context()).Where("image_id = ? AND car_id = ?", c.Param("image_id"), c.Param("car_id")).First(&image)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, httputil.NewError(http.StatusNotFound, "getCarImage: image id not found", res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "getCarImage: failed to get image", res.Error)
+	}
+
+	return image, nil
+}
+
+func (cis *CarImageService) storeImage(c *gin.Context, car_id int, file *multipart.FileHeader) (*entity.CarImage, *httputil.HTTPError) {
+	if file.Size > cis.maxImageSize {
+		msg := fmt.Sprintf("%s is %d bytes, maximum is %d bytes", file.Filename, file.Size, cis.maxImageSize)
+		return nil, httputil.NewCodedError(http.StatusRequestEntityTooLarge, httputil.CodeImageTooLarge, "UploadCarImages: image is too large", msg)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, httputil.NewError(http.StatusBadRequest, "UploadCarImages: failed to read image", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, cis.maxImageSize+1))
+	if err != nil {
+		return nil, httputil.NewError(http.StatusBadRequest, "UploadCarImages: failed to read image", err)
+	}
+
+	img, contentType, ext, err := helpers.DecodeImage(data)
+	if err != nil {
+		return nil, httputil.NewError(http.StatusBadRequest, "UploadCarImages: invalid image "+file.Filename, err).WithCode(httputil.CodeInvalidImage)
+	}
+
+	thumbnail := new(bytes.Buffer)
+	if err := helpers.EncodeImage(thumbnail, helpers.CreateThumbnail(img, helpers.ThumbnailSize), contentType); err != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "UploadCarImages: failed to create thumbnail", err)
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "UploadCarImages: failed to generate file name", err)
+	}
+	key := fmt.Sprintf("cars/%d/%s%s", car_id, hex.EncodeToString(name), ext)
+	thumbnailKey := fmt.Sprintf("cars/%d/%s_thumb%s", car_id, hex.EncodeToString(name), ext)
+
+	if err := cis.storage.Put(c.Request.Context(), key, bytes.NewReader(data), contentType); err != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "UploadCarImages: failed to store image", err)
+	}
+	if err := cis.storage.Put(c.Request.Context(), thumbnailKey, thumbnail, contentType); err != nil {
+		cis.storage.Delete(c.Request.Context(), key)
+		return nil, httputil.NewError(http.StatusInternalServerError, "UploadCarImages: failed to store thumbnail", err)
+	}
+
+	return &entity.CarImage{
+		CarID:        car_id,
+		Key:          key,
+		ThumbnailKey: thumbnailKey,
+		URL:          cis.storage.URL(key),
+		ThumbnailURL: cis.storage.URL(thumbnailKey),
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Width:        img.Bounds().Dx(),
+		Height:       img.Bounds().Dy(),
+	}, nil
+}
+
+// deleteFiles removes stored objects on a best effort basis, a leftover file
+// is harmless while a failed request must not be masked by cleanup errors.
+func (cis *CarImageService) deleteFiles(c *gin.Context, images []entity.CarImage) {
+	for _, image := range images {
+		cis.storage.Delete(c.Request.Context(), image.Key)
+		cis.storage.Delete(c.Request.Context(), image.ThumbnailKey)
+	}
+}
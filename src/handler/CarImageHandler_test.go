@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/storage"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func pngImage(t *testing.T, width, height int) []byte {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func uploadContext(t *testing.T, files ...[]byte) (*httptest.ResponseRecorder, *gin.Context) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for _, file := range files {
+		part, err := mw.CreateFormFile("images", "car.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(file)
+	}
+	mw.Close()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/cars/1/images", body)
+	ctx.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	ctx.Params = gin.Params{{Key: "car_id", Value: "1"}}
+	return w, ctx
+}
+
+func carImageService(t *testing.T, maxImageSize int64) (*CarImageService, sqlmock.Sqlmock, string) {
+	sqlDB, db, mock := DbMock(t)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	root := t.TempDir()
+	localStorage, err := storage.NewLocalStorage(root, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCarImageService(db, localStorage, maxImageSize), mock, root
+}
+
+func expectCar(mock sqlmock.Sqlmock, images *sqlmock.Rows) {
+	mock.ExpectQuery(`SELECT \* FROM "cars" WHERE car_id = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"car_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "car_images" WHERE "car_images"."car_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(images)
+}
+
+func storedFiles(t *testing.T, root string) []string {
+	files, err := filepath.Glob(filepath.Join(root, "cars", "1", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestUploadCarImages_shouldSuccess(t *testing.T) {
+	cis, mock, root := carImageService(t, 1<<20)
+
+	expectCar(mock, sqlmock.NewRows([]string{"image_id", "car_id", "position", "is_primary"}).AddRow(1, 1, 0, true))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "car_images"`).
+		WillReturnRows(sqlmock.NewRows([]string{"image_id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	w, ctx := uploadContext(t, pngImage(t, 800, 600))
+	cis.UploadCarImages(ctx)
+
+	assert.Empty(t, ctx.Errors)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"position":1,"is_primary":false`)
+	assert.Len(t, storedFiles(t, root), 2, "the image and its thumbnail are stored")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadCarImages_tooManyImages(t *testing.T) {
+	cis, mock, _ := carImageService(t, 1<<20)
+
+	files := [][]byte{}
+	for i := 0; i <= maxImagesPerUpload; i++ {
+		files = append(files, pngImage(t, 1, 1))
+	}
+	_, ctx := uploadContext(t, files...)
+	cis.UploadCarImages(ctx)
+
+	err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+	assert.Equal(t, httputil.CodeTooManyImages, err.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadCarImages_imageTooLarge(t *testing.T) {
+	cis, mock, root := carImageService(t, 100)
+
+	expectCar(mock, sqlmock.NewRows([]string{"image_id"}))
+
+	_, ctx := uploadContext(t, pngImage(t, 1, 1), pngImage(t, 400, 400))
+	cis.UploadCarImages(ctx)
+
+	err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.Status)
+	assert.Equal(t, httputil.CodeImageTooLarge, err.Code)
+	assert.Empty(t, storedFiles(t, root), "images stored before the failure are deleted")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadCarImages_invalidImage(t *testing.T) {
+	cis, mock, root := carImageService(t, 1<<20)
+
+	expectCar(mock, sqlmock.NewRows([]string{"image_id"}))
+
+	_, ctx := uploadContext(t, []byte("GIF89a not a supported image"))
+	cis.UploadCarImages(ctx)
+
+	err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+	assert.Equal(t, httputil.CodeInvalidImage, err.Code)
+	assert.Empty(t, storedFiles(t, root))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCarImage_shouldSuccess(t *testing.T) {
+	cis, mock, root := carImageService(t, 1<<20)
+
+	dir := filepath.Join(root, "cars", "1")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "a.png"), []byte("image"), 0o644)
+	os.WriteFile(filepath.Join(dir, "a_thumb.png"), []byte("thumbnail"), 0o644)
+
+	mock.ExpectQuery(`SELECT \* FROM "car_images" WHERE image_id = \$1 AND car_id = \$2`).
+		WithArgs("1", "1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"image_id", "car_id", "key", "thumbnail_key", "is_primary"}).AddRow(1, 1, "cars/1/a.png", "cars/1/a_thumb.png", true))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "car_images" WHERE "car_images"."image_id" = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "car_images" WHERE car_id = \$1 ORDER BY position, image_id`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"image_id", "car_id"}).AddRow(2, 1))
+	mock.ExpectExec(`UPDATE "car_images" SET "is_primary"=\$1 WHERE "image_id" = \$2`).
+		WithArgs(true, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/admin/cars/1/images/1", nil)
+	ctx.Params = gin.Params{{Key: "car_id", Value: "1"}, {Key: "image_id", Value: "1"}}
+	cis.DeleteCarImage(ctx)
+
+	assert.Empty(t, ctx.Errors)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, storedFiles(t, root), "the image and its thumbnail are deleted")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/notification"
+	"p2-mini-project/src/safehttp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultNotificationLimit = 50
+
+type NotificationService struct {
+	db       *gorm.DB
+	notifier *notification.Notifier
+}
+
+func NewNotificationService(db *gorm.DB, notifier *notification.Notifier) *NotificationService {
+	return &NotificationService{db: db, notifier: notifier}
+}
+
+// Notification godoc
+// @Summary Get notifications
+// @Description Get the in-app notifications of the current user, newest first
+// @Tags 	 Notification
+// @Produce  json
+// @Param    unread  query  bool  false  "only unread notifications"
+// @Param    limit   query  int   false  "maximum notifications returned, defaults to 50"
+// @Success 200 {object} object{message=string,unread_count=int,notifications=[]entity.Notification}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/notifications [get]
+func (ns *NotificationService) GetNotifications(c *gin.Context) {
+	filter := new(dto.NotificationFilter)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetNotifications: invalid query params", err))
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultNotificationLimit
+	}
+
+	user_id := int(c.GetFloat64("user_id"))
+
+	query := ns.db.WithContext(c.Request.Context()).Where("user_id = ?", user_id)
+	if filter.Unread {
+		query = query.Where("read_at IS NULL")
+	}
+
+	notifications := []entity.Notification{}
+	if res := query.Order("created_at desc, notification_id desc").Limit(filter.Limit).Find(&notifications); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetNotifications: failed to get notifications", res.Error))
+		return
+	}
+
+	var unread int64
+	if res := ns.db.WithContext(c.Request.Context()).Model(&entity.Notification{}).Where("user_id = ? AND read_at IS NULL", user_id).Count(&unread); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetNotifications: failed to count unread notifications", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "success get notifications",
+		"unread_count":  unread,
+		"notifications": notifications,
+	})
+}
+
+// Notification godoc
+// @Summary Mark notification as read
+// @Description Mark an in-app notification of the current user as read
+// @Tags 	 Notification
+// @Produce  json
+// @Param    notification_id  path  int  true  "notification id"
+// @Success 200 {object} object{message=string,notification=entity.Notification}
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/notifications/{notification_id}/read [post]
+func (ns *NotificationService) ReadNotification(c *gin.Context) {
+	notification_id, _ := strconv.Atoi(c.Param("notification_id"))
+
+	n, err := helpers.GetNotificationByID(ns.db.WithContext(c.Request.Context()), int(c.GetFloat64("user_id")), notification_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if n.ReadAt == nil {
+		now := time.Now()
+		if res := ns.db.WithContext(c.Request.Context()).Model(n).Update("read_at", now); res.Error != nil {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "ReadNotification: failed to update notification", res.Error))
+			return
+		}
+		n.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "success read notification",
+		"notification": n,
+	})
+}
+
+// Notification godoc
+// @Summary Mark all notifications as read
+// @Description Mark every unread in-app notification of the current user as read
+// @Tags 	 Notification
+// @Produce  json
+// @Success 200 {object} object{message=string,read=int}
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/notifications/read [post]
+func (ns *NotificationService) ReadAllNotifications(c *gin.Context) {
+	res := ns.db.WithContext(c.Request.Context()).Model(&entity.Notification{}).Where("user_id = ? AND read_at IS NULL", int(c.GetFloat64("user_id"))).Update("read_at", time.Now())
+	if res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "ReadAllNotifications: failed to update notifications", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success read all notifications",
+		"read":    res.RowsAffected,
+	})
+}
+
+// Notification godoc
+// @Summary Get notification preferences
+// @Description Get the channels the current user receives every notification event on
+// @Tags 	 Notification
+// @Produce  json
+// @Success 200 {object} object{message=string,webhook_url=string,preferences=[]dto.NotificationPreference}
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/notification-preferences [get]
+func (ns *NotificationService) GetNotificationPreferences(c *gin.Context) {
+	user, err := helpers.GetUserByID(ns.db.WithContext(c.Request.Context()), int(c.GetFloat64("user_id")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	prefs, err := helpers.GetNotificationPreferences(ns.db.WithContext(c.Request.Context()), user.ID, ns.notifier.Channels())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "success get notification preferences",
+		"webhook_url": user.WebhookURL,
+		"preferences": prefs,
+	})
+}
+
+// Notification godoc
+// @Summary Update notification preferences
+// @Description Turn channels on or off per event and set the webhook URL notifications are posted to, events and channels left out keep their preference
+// @Tags 	 Notification
+// @Accept   json
+// @Produce  json
+// @Param preferences body dto.NotificationPreferences true "notification preferences"
+// @Success 200 {object} object{message=string,webhook_url=string,preferences=[]dto.NotificationPreference}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/notification-preferences [put]
+func (ns *NotificationService) UpdateNotificationPreferences(c *gin.Context) {
+	req := new(dto.NotificationPreferences)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UpdateNotificationPreferences: invalid body request", err))
+		return
+	}
+
+	if req.WebhookURL != nil && *req.WebhookURL != "" {
+		if err := safehttp.ValidateURL(*req.WebhookURL); err != nil {
+			c.Error(httputil.NewFieldError("UpdateNotificationPreferences: invalid webhook url", "webhook_url", "must be an https url of a public host", err))
+			return
+		}
+	}
+
+	user_id := int(c.GetFloat64("user_id"))
+	channels := ns.notifier.Channels()
+
+	txErr := ns.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if req.WebhookURL != nil {
+			if res := tx.Model(&entity.User{}).Where("user_id = ?", user_id).Update("webhook_url", *req.WebhookURL); res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "UpdateNotificationPreferences: failed to update webhook url", res.Error)
+			}
+		}
+		if err := helpers.SaveNotificationPreferences(tx, user_id, req.Preferences, channels); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
+		return
+	}
+
+	user, err := helpers.GetUserByID(ns.db.WithContext(c.Request.Context()), user_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	prefs, err := helpers.GetNotificationPreferences(ns.db.WithContext(c.Request.Context()), user_id, channels)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "success update notification preferences",
+		"webhook_url": user.WebhookURL,
+		"preferences": prefs,
+	})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/notification"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateNotificationPreferences_internalWebhookURL(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	notificationService := NewNotificationService(db, notification.NewNotifier(notification.WebhookChannel{}))
+
+	for _, url := range []string{"http://example.com/hooks", "https://127.0.0.1/hooks", "https://169.254.169.254/latest"} {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodPut, "/users/me/notification-preferences", strings.NewReader(`{"webhook_url": "`+url+`"}`))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		ctx.Set("user_id", float64(1))
+
+		notificationService.UpdateNotificationPreferences(ctx)
+
+		err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Status)
+		assert.Equal(t, "webhook_url", err.Fields[0].Field)
+	}
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/receipt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PaymentService struct {
+	db       *gorm.DB
+	receipts *receipt.Generator
+}
+
+func NewPaymentService(db *gorm.DB, receipts *receipt.Generator) *PaymentService {
+	return &PaymentService{db: db, receipts: receipts}
+}
+
+// User godoc
+// @Summary Download payment receipt
+// @Description Download the PDF receipt of a payment of the current user
+// @Tags 	 User
+// @Produce  application/pdf
+// @Param    payment_id  path  int  true  "payment id"
+// @Success 200 {file} file
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/me/payments/{payment_id}/receipt [get]
+func (ps *PaymentService) GetPaymentReceipt(c *gin.Context) {
+	payment_id, _ := strconv.Atoi(c.Param("payment_id"))
+
+	payment, err := helpers.GetPaymentByID(ps.db.WithContext(c.Request.Context()), payment_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	isLoginUser := helpers.CheckAuthorizeUser(int(c.GetFloat64("user_id")), payment.Rental.UserID)
+	if !isLoginUser {
+		c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeNotRentalOwner, "GetPaymentReceipt: failed to get receipt", "only authorize user can do this action"))
+		return
+	}
+
+	pdf, err := helpers.RenderReceipt(ps.db.WithContext(c.Request.Context()), ps.receipts, payment)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="receipt-`+payment.ReceiptNumber+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PricingRuleService struct {
+	db *gorm.DB
+}
+
+func NewPricingRuleService(db *gorm.DB) *PricingRuleService {
+	return &PricingRuleService{db: db}
+}
+
+// Admin godoc
+// @Summary Get all pricing rules
+// @Description Get all pricing rules
+// @Tags 	 Admin
+// @Produce  json
+// @Success 200 {object} object{message=string,pricing_rules=[]entity.PricingRule}
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/pricing-rules [get]
+func (ps *PricingRuleService) GetAllPricingRules(c *gin.Context) {
+	rules := new([]entity.PricingRule)
+
+	if res := ps.db.WithContext(c.Request.Context()).Order("pricing_rule_id").Find(&rules); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllPricingRules: failed to get all pricing rules", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "success get all pricing rules",
+		"pricing_rules": rules,
+	})
+}
+
+// Admin godoc
+// @Summary Create pricing rule
+// @Description Create new pricing rule, percent is a surcharge when positive and a discount when negative
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param rule body dto.PricingRule true "Create new pricing rule"
+// @Success 201 {object} object{message=string,pricing_rule=entity.PricingRule}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/pricing-rules [post]
+func (ps *PricingRuleService) CreatePricingRule(c *gin.Context) {
+	req := new(dto.PricingRule)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "CreatePricingRule: invalid body request", err))
+		return
+	}
+
+	rule, err := helpers.NewPricingRule(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if res := ps.db.WithContext(c.Request.Context()).Create(&rule); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreatePricingRule: failed to create pricing rule", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "success create new pricing rule",
+		"pricing_rule": rule,
+	})
+}
+
+// Admin godoc
+// @Summary Update pricing rule
+// @Description Update pricing rule by id
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param    rule_id    path     int  true  "pricing rule id"
+// @Param rule body dto.PricingRule true "Update pricing rule"
+// @Success 200 {object} object{message=string,pricing_rule=entity.PricingRule}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/pricing-rules/{rule_id} [put]
+func (ps *PricingRuleService) UpdatePricingRule(c *gin.Context) {
+	rule_id := c.Param("rule_id")
+
+	req := new(dto.PricingRule)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UpdatePricingRule: invalid body request", err))
+		return
+	}
+
+	rule, err := helpers.NewPricingRule(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	rule.ID, _ = strconv.Atoi(rule_id)
+
+	res := ps.db.WithContext(c.Request.Context()).Model(&rule).Select("name", "type", "category_id", "percent", "start_date", "end_date", "min_days", "min_lead_days", "active").Updates(rule)
+	if res.Error != nil {
+		msg := fmt.Sprintf("UpdatePricingRule: failed to update pricing rule with ID [%d]", rule.ID)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "UpdatePricingRule: pricing rule id not found", errors.New("pricing rule id not found")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "success update pricing rule with ID: " + rule_id,
+		"pricing_rule": rule,
+	})
+}
+
+// Admin godoc
+// @Summary Delete pricing rule
+// @Description Delete pricing rule by id
+// @Tags 	 Admin
+// @Produce  json
+// @Param    rule_id    path     int  true  "pricing rule id"
+// @Success 200 {object} object{message=string}
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/pricing-rules/{rule_id} [delete]
+func (ps *PricingRuleService) DeletePricingRule(c *gin.Context) {
+	rule_id := c.Param("rule_id")
+
+	res := ps.db.WithContext(c.Request.Context()).Delete(&entity.PricingRule{}, rule_id)
+	if res.Error != nil {
+		msg := fmt.Sprintf("DeletePricingRule: failed to delete pricing rule with ID [%s]", rule_id)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "DeletePricingRule: pricing rule id not found", errors.New("pricing rule id not found")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success delete pricing rule with ID: " + rule_id,
+	})
+}
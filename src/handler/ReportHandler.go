@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/pricing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReportService struct {
+	db     *gorm.DB
+	policy pricing.Policy
+}
+
+func NewReportService(db *gorm.DB, policy pricing.Policy) *ReportService {
+	return &ReportService{db: db, policy: policy}
+}
+
+// period binds the report filter and returns its period.
+func (rs *ReportService) period(c *gin.Context, name string) (*dto.ReportFilter, *helpers.ReportPeriod, bool) {
+	filter := new(dto.ReportFilter)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, name+": invalid query params", err))
+		return nil, nil, false
+	}
+
+	period, err := helpers.GetReportPeriod(filter, rs.policy, time.Now())
+	if err != nil {
+		c.Error(err)
+		return nil, nil, false
+	}
+	return filter, period, true
+}
+
+func reportResponse(message string, period *helpers.ReportPeriod, key string, data interface{}) gin.H {
+	return gin.H{
+		"message": message,
+		"from":    period.From.Format("2006-01-02"),
+		"to":      period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		key:       data,
+	}
+}
+
+// Report godoc
+// @Summary Revenue report
+// @Description Revenue of settled payments by payment date, grouped by day, week or month
+// @Tags 	 Report
+// @Produce  json
+// @Param    from      query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to        query  string  false  "last date, defaults to today" format(date)
+// @Param    group_by  query  string  false  "period of every row, defaults to day" Enums(day, week, month)
+// @Success 200 {object} object{message=string,from=string,to=string,revenue=[]dto.RevenuePeriod}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/revenue [get]
+func (rs *ReportService) GetRevenue(c *gin.Context) {
+	filter, period, ok := rs.period(c, "GetRevenue")
+	if !ok {
+		return
+	}
+
+	revenue, err := helpers.GetRevenueByPeriod(rs.db.WithContext(c.Request.Context()), period, filter.GroupBy)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get revenue report", period, "revenue", revenue))
+}
+
+// Report godoc
+// @Summary Revenue by category report
+// @Description Revenue of settled payments by car category
+// @Tags 	 Report
+// @Produce  json
+// @Param    from  query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to    query  string  false  "last date, defaults to today" format(date)
+// @Success 200 {object} object{message=string,from=string,to=string,revenue=[]dto.CategoryRevenue}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/revenue/categories [get]
+func (rs *ReportService) GetRevenueByCategory(c *gin.Context) {
+	_, period, ok := rs.period(c, "GetRevenueByCategory")
+	if !ok {
+		return
+	}
+
+	revenue, err := helpers.GetRevenueByCategory(rs.db.WithContext(c.Request.Context()), period)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get revenue by category report", period, "revenue", revenue))
+}
+
+// Report godoc
+// @Summary Revenue by car report
+// @Description Revenue of settled payments by car
+// @Tags 	 Report
+// @Produce  json
+// @Param    from  query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to    query  string  false  "last date, defaults to today" format(date)
+// @Success 200 {object} object{message=string,from=string,to=string,revenue=[]dto.CarRevenue}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/revenue/cars [get]
+func (rs *ReportService) GetRevenueByCar(c *gin.Context) {
+	_, period, ok := rs.period(c, "GetRevenueByCar")
+	if !ok {
+		return
+	}
+
+	revenue, err := helpers.GetRevenueByCar(rs.db.WithContext(c.Request.Context()), period)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get revenue by car report", period, "revenue", revenue))
+}
+
+// Report godoc
+// @Summary Fleet utilization report
+// @Description Share of the period every car and the whole fleet was rented for
+// @Tags 	 Report
+// @Produce  json
+// @Param    from  query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to    query  string  false  "last date, defaults to today" format(date)
+// @Success 200 {object} object{message=string,from=string,to=string,utilization=dto.Utilization}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/utilization [get]
+func (rs *ReportService) GetUtilization(c *gin.Context) {
+	_, period, ok := rs.period(c, "GetUtilization")
+	if !ok {
+		return
+	}
+
+	utilization, err := helpers.GetUtilization(rs.db.WithContext(c.Request.Context()), period, time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get utilization report", period, "utilization", utilization))
+}
+
+// Report godoc
+// @Summary Rental length report
+// @Description Average length of the paid rentals starting in the period
+// @Tags 	 Report
+// @Produce  json
+// @Param    from  query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to    query  string  false  "last date, defaults to today" format(date)
+// @Success 200 {object} object{message=string,from=string,to=string,rental_length=dto.RentalLength}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/rental-length [get]
+func (rs *ReportService) GetRentalLength(c *gin.Context) {
+	_, period, ok := rs.period(c, "GetRentalLength")
+	if !ok {
+		return
+	}
+
+	length, err := helpers.GetRentalLength(rs.db.WithContext(c.Request.Context()), period)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get rental length report", period, "rental_length", length))
+}
+
+// Report godoc
+// @Summary Coupon usage report
+// @Description Number of paid rentals starting in the period that used every coupon and the discount they cost
+// @Tags 	 Report
+// @Produce  json
+// @Param    from  query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to    query  string  false  "last date, defaults to today" format(date)
+// @Success 200 {object} object{message=string,from=string,to=string,coupons=[]dto.CouponUsage}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/coupons [get]
+func (rs *ReportService) GetCouponUsage(c *gin.Context) {
+	_, period, ok := rs.period(c, "GetCouponUsage")
+	if !ok {
+		return
+	}
+
+	usage, err := helpers.GetCouponUsage(rs.db.WithContext(c.Request.Context()), period)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get coupon usage report", period, "coupons", usage))
+}
+
+// Report godoc
+// @Summary Top customers report
+// @Description Customers with the highest settled payments
+// @Tags 	 Report
+// @Produce  json
+// @Param    from   query  string  false  "first date, defaults to 30 days ago" format(date)
+// @Param    to     query  string  false  "last date, defaults to today" format(date)
+// @Param    limit  query  int     false  "number of customers, defaults to 10"
+// @Success 200 {object} object{message=string,from=string,to=string,customers=[]dto.TopCustomer}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/reports/top-customers [get]
+func (rs *ReportService) GetTopCustomers(c *gin.Context) {
+	filter, period, ok := rs.period(c, "GetTopCustomers")
+	if !ok {
+		return
+	}
+
+	customers, err := helpers.GetTopCustomers(rs.db.WithContext(c.Request.Context()), period, filter.Limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reportResponse("success get top customers report", period, "customers", customers))
+}
@@ -1,35 +1,43 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
 	"p2-mini-project/src/dto"
 	"p2-mini-project/src/entity"
 	"p2-mini-project/src/helpers"
 	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/metrics"
+	"p2-mini-project/src/notification"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier *notification.Notifier
 }
 
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *gorm.DB, notifier *notification.Notifier) *UserService {
+	return &UserService{db: db, notifier: notifier}
 }
 
 // User godoc
 // @Summary User top up
-// @Description User top up
+// @Description User top up, the invoice is created after the deposit is credited and is null when the payment gateway fails
 // @Tags 	 User
 // @Accept   json
 // @Produce  json
 // @Param car body dto.TopUp true "top up"
+// @Param    Idempotency-Key  header  string  false  "retries with the same key return the first response"
 // @Success 201 {object} object{message=string,invoice=entity.Invoice}
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 401 {object} httputil.HTTPError
-// @Failure 500 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users/topup [post]
 func (us *UserService) TopUp(c *gin.Context) {
 
@@ -42,26 +50,58 @@ func (us *UserService) TopUp(c *gin.Context) {
 
 	user_id := int(c.GetFloat64("user_id"))
 
-	user, err := helpers.GetUserByID(us.db, user_id)
+	user, err := helpers.GetUserByID(us.db.WithContext(c.Request.Context()), user_id)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	updatedDeposit := user.Deposit + topup.Amount
-
-	if res := us.db.Model(&entity.User{}).Where("user_id = ?", user_id).Update("deposit", updatedDeposit); res.Error != nil {
-		c.Error(httputil.NewError(http.StatusInternalServerError, "TopUp: failed to top up", res.Error))
+	db := us.db.WithContext(c.Request.Context())
+	var transaction *entity.WalletTransaction
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		var err *httputil.HTTPError
+		if transaction, err = helpers.TopUpDeposit(tx, user_id, topup.Amount); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
 		return
 	}
+	metrics.TopUps.Inc()
+	metrics.TopUpAmount.Add(float64(topup.Amount))
+
+	if _, err := helpers.SettleLateFees(db, user_id); err != nil {
+		slog.WarnContext(c.Request.Context(), "TopUp: failed to settle late fees", "user_id", user_id, "error", err)
+	}
 
-	invoiceRes, errInvoice := helpers.CreateInvoiceTopUp(user, topup.Amount)
+	// the invoice is created once the deposit is committed so no row is
+	// locked while Xendit is called
+	invoiceRes, errInvoice := helpers.CreateInvoiceTopUp(c.Request.Context(), user, topup.Amount)
 	if errInvoice != nil {
-		c.Error(httputil.NewError(http.StatusInternalServerError, "TopUp: failed to create invoice", errInvoice))
-		return
+		slog.ErrorContext(c.Request.Context(), "TopUp: failed to create invoice", "wallet_transaction_id", transaction.ID, "error", errInvoice)
+		invoiceRes = nil
 	}
 
-	helpers.SendSuccessTopUp(user.Email, invoiceRes.InvoiceUrl)
+	txErr = db.Transaction(func(tx *gorm.DB) error {
+		data := mailer.Data{"Amount": topup.Amount}
+		if invoiceRes != nil {
+			if res := tx.Model(transaction).Update("invoice_id", invoiceRes.ID); res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "TopUp: failed to store invoice", res.Error)
+			}
+			data["InvoiceURL"] = invoiceRes.InvoiceUrl
+		}
+
+		if err := helpers.Notify(tx, us.notifier, notification.EventTopUpSucceeded, user, data); err != nil {
+			return err
+		}
+		return nil
+	})
+	if txErr != nil {
+		// the deposit is credited, only the invoice link is missing
+		slog.ErrorContext(c.Request.Context(), "TopUp: failed to store invoice and notify", "wallet_transaction_id", transaction.ID, "error", txErr)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "success top up",
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/notification"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTopUp_invoiceAfterCommit(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	defaultClient := helpers.InvoiceClient
+	t.Cleanup(func() { helpers.InvoiceClient = defaultClient })
+	helpers.InvoiceClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		// the deposit is committed before the payment gateway is called, the
+		// next expectation is the transaction storing the invoice
+		assert.Contains(t, mock.ExpectationsWereMet().Error(), "ExpectedBegin")
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"id": "inv-1", "invoice_url": "https://checkout.xendit.co/inv-1"}`))}, nil
+	})}
+
+	userService := NewUserService(db, notification.NewNotifier())
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE user_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "fullname", "email", "language"}).AddRow(1, "Budi", "budi@mail.com", "en"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "users" SET "deposit"=deposit \+ \$1`).
+		WithArgs(100000, 1, 100000).
+		WillReturnRows(sqlmock.NewRows([]string{"deposit"}).AddRow(100000))
+	mock.ExpectQuery(`INSERT INTO "wallet_transactions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_transaction_id"}).AddRow(5))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT "rental_id","late_fee_due" FROM "rentals"`).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id", "late_fee_due"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "wallet_transactions" SET "invoice_id"=\$1 WHERE "wallet_transaction_id" = \$2`).
+		WithArgs("inv-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT "channel" FROM "notification_preferences"`).
+		WillReturnRows(sqlmock.NewRows([]string{"channel"}))
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(`{"amount": 100000}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctx.Set("user_id", float64(1))
+
+	userService.TopUp(ctx)
+
+	assert.Empty(t, ctx.Errors)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"invoice_url":"https://checkout.xendit.co/inv-1"`)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTopUp_invalidAmount(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	userService := NewUserService(db, nil)
+
+	for _, body := range []string{`{"amount": 0}`, `{"amount": -50000}`, `{}`} {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(body))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		ctx.Set("user_id", float64(1))
+
+		userService.TopUp(ctx)
+
+		err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Status, body)
+		assert.Equal(t, httputil.CodeValidationFailed, err.Code, body)
+		assert.Equal(t, "amount", err.Fields[0].Field, body)
+	}
+	assert.Nil(t, mock.ExpectationsWereMet(), "the deposit isn't touched")
+}
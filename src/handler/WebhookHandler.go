@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/safehttp"
+	"p2-mini-project/src/webhook"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultDeliveryLimit = 50
+
+type WebhookService struct {
+	db *gorm.DB
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{db: db}
+}
+
+// Admin godoc
+// @Summary Get all webhooks
+// @Description Get all webhook endpoints, secrets are only returned when an endpoint is created
+// @Tags 	 Admin
+// @Produce  json
+// @Success 200 {object} object{message=string,webhooks=[]entity.WebhookEndpoint}
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks [get]
+func (ws *WebhookService) GetAllWebhooks(c *gin.Context) {
+	endpoints := []entity.WebhookEndpoint{}
+
+	if res := ws.db.WithContext(c.Request.Context()).Omit("secret").Order("webhook_endpoint_id").Find(&endpoints); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllWebhooks: failed to get all webhooks", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "success get all webhooks",
+		"webhooks": endpoints,
+	})
+}
+
+// Admin godoc
+// @Summary Create webhook
+// @Description Register a webhook endpoint subscribed to events, the response has the secret the payloads are signed with
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param webhook body dto.WebhookEndpoint true "Create new webhook"
+// @Success 201 {object} object{message=string,webhook=entity.WebhookEndpoint}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks [post]
+func (ws *WebhookService) CreateWebhook(c *gin.Context) {
+	req := new(dto.WebhookEndpoint)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "CreateWebhook: invalid body request", err))
+		return
+	}
+	if err := safehttp.ValidateURL(req.URL); err != nil {
+		c.Error(httputil.NewFieldError("CreateWebhook: invalid url", "url", "must be an https url of a public host", err))
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateWebhook: failed to generate secret", err))
+		return
+	}
+
+	endpoint := entity.WebhookEndpoint{URL: req.URL, Description: req.Description, Events: req.Events, Secret: secret, Active: true}
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+	if res := ws.db.WithContext(c.Request.Context()).Create(&endpoint); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "CreateWebhook: failed to create new webhook", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "success create new webhook",
+		"webhook": endpoint,
+	})
+}
+
+// Admin godoc
+// @Summary Update webhook
+// @Description Update webhook endpoint by id, the secret is kept
+// @Tags 	 Admin
+// @Accept   json
+// @Produce  json
+// @Param    webhook_id    path     int  true  "webhook id"
+// @Param webhook body dto.WebhookEndpoint true "Update webhook"
+// @Success 200 {object} object{message=string,webhook=entity.WebhookEndpoint}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks/{webhook_id} [put]
+func (ws *WebhookService) UpdateWebhook(c *gin.Context) {
+	webhook_id, _ := strconv.Atoi(c.Param("webhook_id"))
+
+	req := new(dto.WebhookEndpoint)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "UpdateWebhook: invalid body request", err))
+		return
+	}
+	if err := safehttp.ValidateURL(req.URL); err != nil {
+		c.Error(httputil.NewFieldError("UpdateWebhook: invalid url", "url", "must be an https url of a public host", err))
+		return
+	}
+
+	endpoint, err := helpers.GetWebhookByID(ws.db.WithContext(c.Request.Context()), webhook_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	endpoint.URL, endpoint.Description, endpoint.Events = req.URL, req.Description, req.Events
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+
+	res := ws.db.WithContext(c.Request.Context()).Model(endpoint).Select("url", "description", "events", "active").Updates(endpoint)
+	if res.Error != nil {
+		msg := fmt.Sprintf("UpdateWebhook: failed to update webhook with ID [%d]", webhook_id)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	endpoint.Secret = ""
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success update webhook with ID: " + strconv.Itoa(webhook_id),
+		"webhook": endpoint,
+	})
+}
+
+// Admin godoc
+// @Summary Delete webhook
+// @Description Delete webhook endpoint by id with its deliveries
+// @Tags 	 Admin
+// @Produce  json
+// @Param    webhook_id    path     int  true  "webhook id"
+// @Success 200 {object} object{message=string}
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks/{webhook_id} [delete]
+func (ws *WebhookService) DeleteWebhook(c *gin.Context) {
+	webhook_id := c.Param("webhook_id")
+
+	res := ws.db.WithContext(c.Request.Context()).Delete(&entity.WebhookEndpoint{}, webhook_id)
+	if res.Error != nil {
+		msg := fmt.Sprintf("DeleteWebhook: failed to delete webhook with ID [%s]", webhook_id)
+		c.Error(httputil.NewError(http.StatusInternalServerError, msg, res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.Error(httputil.NewError(http.StatusNotFound, "DeleteWebhook: webhook id not found", errors.New("webhook id not found")))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success delete webhook with ID: " + webhook_id,
+	})
+}
+
+// Admin godoc
+// @Summary Get webhook deliveries
+// @Description Get the deliveries of a webhook endpoint, newest first
+// @Tags 	 Admin
+// @Produce  json
+// @Param    webhook_id  path   int     true   "webhook id"
+// @Param    status      query  string  false  "filter by status" Enums(pending, succeeded, failed)
+// @Param    event       query  string  false  "filter by event"
+// @Param    limit       query  int     false  "maximum deliveries returned, defaults to 50"
+// @Success 200 {object} object{message=string,deliveries=[]entity.WebhookDelivery}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks/{webhook_id}/deliveries [get]
+func (ws *WebhookService) GetWebhookDeliveries(c *gin.Context) {
+	webhook_id, _ := strconv.Atoi(c.Param("webhook_id"))
+
+	filter := new(dto.WebhookDeliveryFilter)
+
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.Error(httputil.NewError(http.StatusBadRequest, "GetWebhookDeliveries: invalid query params", err))
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultDeliveryLimit
+	}
+
+	if _, err := helpers.GetWebhookByID(ws.db.WithContext(c.Request.Context()), webhook_id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := ws.db.WithContext(c.Request.Context()).Where("webhook_endpoint_id = ?", webhook_id)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+
+	deliveries := []entity.WebhookDelivery{}
+	if res := query.Order("webhook_delivery_id desc").Limit(filter.Limit).Find(&deliveries); res.Error != nil {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetWebhookDeliveries: failed to get deliveries", res.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "success get webhook deliveries",
+		"deliveries": deliveries,
+	})
+}
+
+// Admin godoc
+// @Summary Get webhook delivery
+// @Description Get a delivery of a webhook endpoint with the log of its attempts
+// @Tags 	 Admin
+// @Produce  json
+// @Param    webhook_id   path  int  true  "webhook id"
+// @Param    delivery_id  path  int  true  "delivery id"
+// @Success 200 {object} object{message=string,delivery=entity.WebhookDelivery}
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks/{webhook_id}/deliveries/{delivery_id} [get]
+func (ws *WebhookService) GetWebhookDelivery(c *gin.Context) {
+	webhook_id, _ := strconv.Atoi(c.Param("webhook_id"))
+	delivery_id, _ := strconv.Atoi(c.Param("delivery_id"))
+
+	delivery, err := helpers.GetWebhookDelivery(ws.db.WithContext(c.Request.Context()), webhook_id, delivery_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "success get webhook delivery",
+		"delivery": delivery,
+	})
+}
+
+// Admin godoc
+// @Summary Redeliver webhook delivery
+// @Description Send a delivery again with a new set of retries
+// @Tags 	 Admin
+// @Produce  json
+// @Param    webhook_id   path  int  true  "webhook id"
+// @Param    delivery_id  path  int  true  "delivery id"
+// @Success 202 {object} object{message=string}
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /admin/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [post]
+func (ws *WebhookService) RedeliverWebhook(c *gin.Context) {
+	webhook_id, _ := strconv.Atoi(c.Param("webhook_id"))
+	delivery_id, _ := strconv.Atoi(c.Param("delivery_id"))
+
+	endpoint, err := helpers.GetWebhookByID(ws.db.WithContext(c.Request.Context()), webhook_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !endpoint.Active {
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeWebhookDisabled, "RedeliverWebhook: webhook is disabled", "webhook is disabled"))
+		return
+	}
+
+	delivery, err := helpers.GetWebhookDelivery(ws.db.WithContext(c.Request.Context()), webhook_id, delivery_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	txErr := ws.db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(delivery).Update("status", webhook.StatusPending); res.Error != nil {
+			return httputil.NewError(http.StatusInternalServerError, "RedeliverWebhook: failed to update delivery", res.Error)
+		}
+		if err := webhook.Enqueue(tx, delivery.ID); err != nil {
+			return httputil.NewError(http.StatusInternalServerError, "RedeliverWebhook: failed to queue delivery", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		c.Error(txErr)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "success redeliver webhook delivery with ID: " + strconv.Itoa(delivery_id),
+	})
+}
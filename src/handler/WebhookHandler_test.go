@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateWebhook_internalURL(t *testing.T) {
+	sqlDB, db, mock := DbMock(t)
+	defer sqlDB.Close()
+
+	webhookService := NewWebhookService(db)
+
+	for _, url := range []string{"http://partner.example.com/hooks", "https://localhost:8081/api/v1/admin/cars", "https://10.0.0.5/hooks"} {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(`{"url": "`+url+`", "events": ["rental.created"]}`))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+
+		webhookService.CreateWebhook(ctx)
+
+		err := ctx.Errors.Last().Err.(*httputil.HTTPError)
+		assert.Equal(t, http.StatusBadRequest, err.Status)
+		assert.Equal(t, "url", err.Fields[0].Field)
+	}
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiKeyPrefix       = "rk_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+// NewAPIKey returns a random API key, its prefix and its hash.
+func NewAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:apiKeyPrefixLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by. Keys are
+// random so a plain SHA-256 is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequiredScope returns the scope a request to resource needs, reads need
+// "<resource>:read" and anything else "<resource>:write".
+func RequiredScope(resource string, method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return resource + ":read"
+	}
+	return resource + ":write"
+}
+
+// HasScope reports whether scopes grant required, the write scope of a
+// resource grants its read scope too.
+func HasScope(scopes []string, required string) bool {
+	resource, isRead := strings.CutSuffix(required, ":read")
+	for _, scope := range scopes {
+		if scope == required || (isRead && scope == resource+":write") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdminScope reports whether scope is for an admin resource, only keys of
+// admins may have them.
+func IsAdminScope(scope string) bool {
+	return strings.HasPrefix(scope, "admin:")
+}
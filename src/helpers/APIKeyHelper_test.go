@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(key, "rk_"))
+	assert.Equal(t, key[:11], prefix)
+	assert.Equal(t, HashAPIKey(key), hash)
+	assert.NotContains(t, hash, key[3:])
+}
+
+func TestHasScope(t *testing.T) {
+	scopes := []string{"cars:write", "admin:reports:read"}
+
+	assert.True(t, HasScope(scopes, RequiredScope("cars", http.MethodPost)))
+	assert.True(t, HasScope(scopes, RequiredScope("cars", http.MethodGet)), "the write scope grants reads")
+	assert.True(t, HasScope(scopes, RequiredScope("admin:reports", http.MethodGet)))
+	assert.False(t, HasScope(scopes, RequiredScope("admin:reports", http.MethodPost)))
+	assert.False(t, HasScope(scopes, RequiredScope("admin:cars", http.MethodGet)))
+}
@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+
+	"gorm.io/gorm"
+)
+
+func GetBranchByID(db *gorm.DB, branch_id int) (*entity.Branch, *httputil.HTTPError) {
+	branch := new(entity.Branch)
+
+	res := db.Where("branch_id = ?", branch_id).First(&branch)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("GetBranchByID: branch id [%d] not found", branch_id)
+		return nil, httputil.NewError(http.StatusNotFound, msg, res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetBranchByID: failed to get branch", res.Error)
+	}
+
+	return branch, nil
+}
+
+// SameBranch reports whether two optional branch ids point to the same branch.
+func SameBranch(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ResolveRentalBranches defaults the pickup branch to the car's current
+// location and the drop-off branch to the pickup branch, checks both exist and
+// sets the one-way fee of the drop-off branch when they differ.
+func ResolveRentalBranches(db *gorm.DB, r *dto.Rental, car *entity.Car) *httputil.HTTPError {
+	if r.PickupBranchID == nil {
+		r.PickupBranchID = car.BranchID
+	}
+	if car.BranchID != nil && !SameBranch(r.PickupBranchID, car.BranchID) {
+		msg := fmt.Sprintf("car is located at branch id [%d]", *car.BranchID)
+		return httputil.NewCodedError(http.StatusBadRequest, httputil.CodeCarUnavailable, "ResolveRentalBranches: car is not available at pickup branch", msg)
+	}
+	if r.DropoffBranchID == nil {
+		r.DropoffBranchID = r.PickupBranchID
+	}
+
+	if r.PickupBranchID != nil {
+		if _, err := GetBranchByID(db, *r.PickupBranchID); err != nil {
+			return err
+		}
+	}
+
+	r.OneWayFee = 0
+	if r.DropoffBranchID != nil {
+		dropoff, err := GetBranchByID(db, *r.DropoffBranchID)
+		if err != nil {
+			return err
+		}
+		if !SameBranch(r.PickupBranchID, r.DropoffBranchID) {
+			r.OneWayFee = dropoff.OneWayFee
+		}
+	}
+
+	return nil
+}
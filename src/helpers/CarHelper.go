@@ -1,12 +1,15 @@
 package helpers
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"p2-mini-project/src/dto"
 	"p2-mini-project/src/entity"
 	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,6 +25,84 @@ func GetCarByID(db *gorm.DB, car_id int) (*entity.Car, *httputil.HTTPError) {
 	return car, nil
 }
 
+// NormalizePlateNumber uppercases the plate and collapses whitespace so
+// "b 1234  xyz" and "B 1234 XYZ" hit the same unique index entry.
+func NormalizePlateNumber(plate string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(plate), " "))
+}
+
+// NormalizeFeatures lowercases feature tags and drops blanks and duplicates.
+func NormalizeFeatures(features []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, feature := range features {
+		feature = strings.ToLower(strings.TrimSpace(feature))
+		if feature == "" || seen[feature] {
+			continue
+		}
+		seen[feature] = true
+		normalized = append(normalized, feature)
+	}
+	return normalized
+}
+
+// EscapeLike escapes the LIKE wildcards in s, so it is matched literally by
+// a LIKE with ESCAPE '\'.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FilterCars applies the catalog search filters to a cars query.
+func FilterCars(db *gorm.DB, f *dto.CarFilter) *gorm.DB {
+	if f.Brand != "" {
+		db = db.Where("lower(brand) = lower(?)", f.Brand)
+	}
+	if f.Model != "" {
+		db = db.Where(`model ILIKE ? ESCAPE '\'`, "%"+EscapeLike(f.Model)+"%")
+	}
+	if f.Transmission != "" {
+		db = db.Where("transmission = ?", f.Transmission)
+	}
+	if f.FuelType != "" {
+		db = db.Where("fuel_type = ?", f.FuelType)
+	}
+	if f.Color != "" {
+		db = db.Where("lower(color) = lower(?)", f.Color)
+	}
+	if f.PlateNumber != "" {
+		db = db.Where("plate_number = ?", NormalizePlateNumber(f.PlateNumber))
+	}
+	if f.MinYear > 0 {
+		db = db.Where("year >= ?", f.MinYear)
+	}
+	if f.MaxYear > 0 {
+		db = db.Where("year <= ?", f.MaxYear)
+	}
+	if f.MinCapacity > 0 {
+		db = db.Where("capacity >= ?", f.MinCapacity)
+	}
+	if len(f.Features) > 0 {
+		features, _ := json.Marshal(NormalizeFeatures(f.Features))
+		db = db.Where("features @> ?", string(features))
+	}
+	if f.BranchID > 0 {
+		db = db.Where("branch_id = ?", f.BranchID)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	return db
+}
+
+// PreloadCarImages loads car images ordered for display, primary image first.
+func PreloadCarImages(db *gorm.DB) *gorm.DB {
+	return db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("is_primary desc, position, image_id")
+	})
+}
+
 func GetRentalByID(db *gorm.DB, rental_id int) (*dto.Rental, *httputil.HTTPError) {
 	rental := new(dto.Rental)
 
@@ -36,42 +117,83 @@ func GetRentalByID(db *gorm.DB, rental_id int) (*dto.Rental, *httputil.HTTPError
 	return rental, nil
 }
 
-func CalculateTotalPriceWithFormatStr(r *dto.Rental) float64 {
-	returnDate, _ := time.Parse("2006-01-02", r.ReturnDate)
-	rentalDate, _ := time.Parse("2006-01-02", r.RentalDate)
+// invalidTime explains the times accepted by pricing.Policy.ParseTime.
+const invalidTime = "must be a date, a date time or an RFC3339 timestamp"
 
-	return CalculateTotalPrice(r, rentalDate, returnDate)
-}
+// NormalizeRentalDates parses the requested rental period and rewrites it as
+// RFC3339 so the stored timestamps don't depend on the database timezone.
+func NormalizeRentalDates(r *dto.Rental, policy pricing.Policy) *httputil.HTTPError {
+	rentalDate, err := policy.ParseTime(r.RentalDate)
+	if err != nil {
+		return httputil.NewFieldError("NormalizeRentalDates: invalid rental_date", "rental_date", invalidTime, err)
+	}
+	returnDate, err := policy.ParseTime(r.ReturnDate)
+	if err != nil {
+		return httputil.NewFieldError("NormalizeRentalDates: invalid return_date", "return_date", invalidTime, err)
+	}
+	if !returnDate.After(rentalDate) {
+		return httputil.NewCodedError(http.StatusBadRequest, httputil.CodeInvalidRentalPeriod, "NormalizeRentalDates: invalid rental period", pricing.ErrInvalidPeriod.Error())
+	}
 
-func CalculateTotalPriceWithFormatRFC(r *dto.Rental) float64 {
-	returnDate, _ := time.Parse(time.RFC3339, r.ReturnDate)
-	rentalDate, _ := time.Parse(time.RFC3339, r.RentalDate)
+	r.RentalDate = rentalDate.Format(time.RFC3339)
+	r.ReturnDate = returnDate.Format(time.RFC3339)
 
-	return CalculateTotalPrice(r, rentalDate, returnDate)
+	return nil
 }
 
-func CalculateTotalPrice(r *dto.Rental, rentalDate time.Time, returnDate time.Time) float64 {
-
-	diffDay := int(returnDate.Sub(rentalDate).Hours() / 24)
+// CalculateBreakdown prices a rental without pricing rules and VAT, it is
+// used for rentals created before prices were stored on the rental.
+func CalculateBreakdown(db *gorm.DB, r *dto.Rental, policy pricing.Policy) (*pricing.Breakdown, *httputil.HTTPError) {
+	rentalDate, err := policy.ParseTime(r.RentalDate)
+	if err != nil {
+		return nil, httputil.NewFieldError("CalculateBreakdown: invalid rental_date", "rental_date", invalidTime, err)
+	}
+	returnDate, err := policy.ParseTime(r.ReturnDate)
+	if err != nil {
+		return nil, httputil.NewFieldError("CalculateBreakdown: invalid return_date", "return_date", invalidTime, err)
+	}
 
-	total_price := (r.Price * float64(diffDay))
+	couponPercent := 0.0
+	if r.CouponID != 0 {
+		if res := db.Model(&entity.Coupon{}).Select("discount_percent").Where("coupon_id = ?", r.CouponID).Scan(&couponPercent); res.Error != nil {
+			return nil, httputil.NewError(http.StatusInternalServerError, "CalculateBreakdown: failed to get coupon", res.Error)
+		}
+	}
 
-	switch r.CouponID {
-	case 1:
-		total_price = total_price - (total_price * 0.1)
-	case 2:
-		total_price = total_price - (total_price * 0.2)
-	case 3:
-		total_price = total_price - (total_price * 0.3)
-	default:
-		fmt.Println("can't detect coupon")
+	policy.VATRate = 0
+	breakdown, err := policy.Quote(pricing.Input{
+		DailyRate:     r.Price,
+		HourlyRate:    r.HourlyPrice,
+		Pickup:        rentalDate,
+		Return:        returnDate,
+		BookedAt:      rentalDate,
+		CouponPercent: couponPercent,
+		OneWayFee:     r.OneWayFee,
+	})
+	if err != nil {
+		return nil, httputil.NewCodedError(http.StatusBadRequest, httputil.CodeInvalidRentalPeriod, "CalculateBreakdown: invalid rental period", err.Error())
 	}
 
-	return total_price
+	return &breakdown, nil
+}
+
+// GetRentalBreakdown returns the price lines stored when the rental was
+// created. Rentals that only stored a total are billed as a single line and
+// older rentals are recalculated.
+func GetRentalBreakdown(db *gorm.DB, r *dto.Rental, policy pricing.Policy) (*pricing.Breakdown, *httputil.HTTPError) {
+	if len(r.PriceLines) > 0 {
+		breakdown := pricing.BreakdownOf(r.PriceLines)
+		return &breakdown, nil
+	}
+	if r.TotalPrice > 0 {
+		breakdown := pricing.BreakdownOf([]pricing.Line{{Code: pricing.LineRental, Description: "Car rental", Quantity: 1, UnitPrice: r.TotalPrice, Amount: r.TotalPrice}})
+		return &breakdown, nil
+	}
+	return CalculateBreakdown(db, r, policy)
 }
 
-func GetPrice(cs *gorm.DB, r *dto.Rental) (float64, *httputil.HTTPError) {
-	price := 0.0
+func GetPrice(cs *gorm.DB, r *dto.Rental) (money.Money, *httputil.HTTPError) {
+	price := money.Money(0)
 	res := cs.Model(&entity.Car{}).Select("rental_cost_per_day").Where("car_id = ?", r.CarID).First(&price)
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 		return -1, httputil.NewError(http.StatusNotFound, "GetPrice: car id not found", res.Error)
@@ -88,7 +210,7 @@ func CheckCarStatus(cs *gorm.DB, car_id int) *httputil.HTTPError {
 		return httputil.NewError(http.StatusInternalServerError, "CheckCarStatus: failed to check car status", res.Error)
 	}
 	if status == "rented" {
-		return httputil.NewError(http.StatusInternalServerError, "CheckCarStatus: car is rented", errors.New("can't rental car, status is rented"))
+		return httputil.NewCodedError(http.StatusConflict, httputil.CodeCarUnavailable, "CheckCarStatus: car is rented", "can't rental car, status is rented")
 	}
 
 	return nil
@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/testutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterCars_matchesLiterally(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+
+	// wildcards typed by the user are matched as characters
+	mock.ExpectQuery(`SELECT \* FROM "cars" WHERE lower\(brand\) = lower\(\$1\) AND model ILIKE \$2 ESCAPE '\\' AND lower\(color\) = lower\(\$3\)`).
+		WithArgs("Toy%", `%100\%\_a\\b%`, "_").
+		WillReturnRows(sqlmock.NewRows([]string{"car_id"}))
+
+	var cars []entity.Car
+	err := FilterCars(db, &dto.CarFilter{Brand: "Toy%", Model: `100%_a\b`, Color: "_"}).Find(&cars).Error
+
+	assert.NoError(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
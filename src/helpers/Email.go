@@ -1,61 +1,45 @@
 package helpers
 
 import (
-	"fmt"
-	"os"
-	"strconv"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/outbox"
 
-	"gopkg.in/gomail.v2"
+	"gorm.io/gorm"
 )
 
-func SendMail(email, subject, content string) {
-	senderName := os.Getenv("CONFIG_SENDER_NAME")
-	port, _ := strconv.Atoi((os.Getenv("CONFIG_SMTP_PORT")))
-	host := os.Getenv("CONFIG_SMTP_HOST")
-	username := os.Getenv("CONFIG_AUTH_EMAIL")
-	password := os.Getenv("CONFIG_AUTH_PASSWORD")
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", senderName)
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", content)
-
-	d := gomail.NewDialer(host, port, username, password)
-
-	if err := d.DialAndSend(m); err != nil {
-		panic(err)
+// QueueMail renders the template email in the user language and writes it to
+// the outbox of tx, it is sent by the outbox worker once tx commits.
+func QueueMail(tx *gorm.DB, user *entity.User, template string, data mailer.Data, attachments ...mailer.Attachment) *httputil.HTTPError {
+	if _, ok := data["Fullname"]; !ok {
+		data["Fullname"] = user.Fullname
 	}
-}
 
-func SendSuccessRegister(email string, url string) {
-	SendMail(
-		email,
-		"Register success",
-		fmt.Sprintf("Register success with url: <b>%s<b>", url),
-	)
-}
+	msg, err := mailer.Render(template, user.Language, data)
+	if err != nil {
+		return httputil.NewError(http.StatusInternalServerError, "QueueMail: failed to render email", err)
+	}
+	msg.To = user.Email
+	msg.Attachments = attachments
 
-func SendSuccessRental(email string, url string) {
-	SendMail(
-		email,
-		"Rental success",
-		fmt.Sprintf("invoice rental url: <b>%s<b>", url),
-	)
+	if err := outbox.EnqueueEmail(tx, msg); err != nil {
+		return httputil.NewError(http.StatusInternalServerError, "QueueMail: failed to queue email", err)
+	}
+	return nil
 }
 
-func SendSuccessTopUp(email string, url string) {
-	SendMail(
-		email,
-		"Topup success",
-		fmt.Sprintf("invoice top up url: <b>%s<b>", url),
-	)
+func SendRegisterEmail(tx *gorm.DB, user *entity.User, verifyURL string) *httputil.HTTPError {
+	return QueueMail(tx, user, mailer.TemplateRegister, mailer.Data{
+		"VerifyURL": verifyURL,
+		"ExpiresIn": VerificationTTL.String(),
+	})
 }
 
-func SendSuccessPayment(email string, total_price float64) {
-	SendMail(
-		email,
-		"Payment success",
-		fmt.Sprintf("success paid with amount of <b>Rp. %.2f<b>", total_price),
-	)
+func SendVerificationEmail(tx *gorm.DB, user *entity.User, verifyURL string) *httputil.HTTPError {
+	return QueueMail(tx, user, mailer.TemplateVerification, mailer.Data{
+		"VerifyURL": verifyURL,
+		"ExpiresIn": VerificationTTL.String(),
+	})
 }
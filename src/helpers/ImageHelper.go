@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+const (
+	ThumbnailSize  = 320
+	maxImagePixels = 40_000_000
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// DecodeImage sniffs the content type of an uploaded file, rejects anything
+// that isn't a supported image and decodes it. It returns the image, its
+// content type and the file extension to store it with.
+func DecodeImage(data []byte) (image.Image, string, string, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, "", "", fmt.Errorf("unsupported content type %s, only jpeg and png are allowed", contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", "", err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, "", "", errors.New("image dimension is too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return img, contentType, ext, nil
+}
+
+func EncodeImage(w io.Writer, img image.Image, contentType string) error {
+	if contentType == "image/png" {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 80})
+}
+
+// CreateThumbnail scales img down so its longest side is at most maxSide,
+// averaging the source pixels covered by each thumbnail pixel.
+func CreateThumbnail(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+
+	tw, th := maxSide, maxSide
+	if w > h {
+		th = max(h*maxSide/w, 1)
+	} else {
+		tw = max(w*maxSide/h, 1)
+	}
+
+	thumb := image.NewRGBA64(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+(x+1)*w/tw
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			thumb.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	return thumb
+}
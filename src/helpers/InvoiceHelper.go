@@ -2,19 +2,60 @@ package helpers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"p2-mini-project/src/entity"
+	"p2-mini-project/src/logging"
+	"p2-mini-project/src/metrics"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"p2-mini-project/src/tracing"
+	"time"
 )
 
-func CreateInvoiceRental(totalPrice *float64, user *entity.User, car *entity.Car) (*entity.Invoice, error) {
+// InvoiceItems splits price lines into Xendit invoice items and fees, items
+// and fees add up to the breakdown total. Discounts and VAT are sent as fees
+// because items can't have a negative price.
+func InvoiceItems(breakdown *pricing.Breakdown, car *entity.Car) ([]interface{}, []interface{}) {
+	items, fees := []interface{}{}, []interface{}{}
+
+	for _, line := range breakdown.Lines {
+		if line.Amount < 0 || line.Code == pricing.LineVAT {
+			fees = append(fees, map[string]interface{}{
+				"type":  line.Description,
+				"value": line.Amount,
+			})
+			continue
+		}
+
+		quantity, price := line.Quantity, line.UnitPrice
+		if price.Mul(quantity) != line.Amount {
+			quantity, price = 1, line.Amount
+		}
+		items = append(items, map[string]interface{}{
+			"name":     car.Name + " - " + line.Description,
+			"quantity": quantity,
+			"price":    price,
+		})
+	}
+
+	return items, fees
+}
+
+// InvoiceClient sends the Xendit invoice requests, config.SetupXendit sets
+// its timeout from XENDIT_TIMEOUT.
+var InvoiceClient = &http.Client{Timeout: 10 * time.Second, Transport: metrics.NewTransport(logging.NewTransport(tracing.NewTransport(nil, "xendit"), "xendit", nil), "xendit")}
+
+func CreateInvoiceRental(ctx context.Context, breakdown *pricing.Breakdown, user *entity.User, car *entity.Car) (*entity.Invoice, error) {
 	apiKey := os.Getenv("XENDIT_API_KEY")
 	apiUrl := "https://api.xendit.co/v2/invoices"
 
 	bodyRequest := map[string]interface{}{
 		"external_id":      "1",
-		"amount":           totalPrice,
+		"amount":           breakdown.Total,
 		"description":      "Dummy Invoice Mini Project",
 		"invoice_duration": 86400,
 		"customer": map[string]interface{}{
@@ -23,22 +64,15 @@ func CreateInvoiceRental(totalPrice *float64, user *entity.User, car *entity.Car
 			"email":   user.Email,
 		},
 		"currency": "IDR",
-		"items": []interface{}{
-			map[string]interface{}{
-				"name":     car.Name,
-				"quantity": 1,
-				"price":    car.RentalCostPerDay,
-			},
-		},
 	}
+	bodyRequest["items"], bodyRequest["fees"] = InvoiceItems(breakdown, car)
 
 	reqBody, err := json.Marshal(bodyRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{}
-	request, err := http.NewRequest("POST", apiUrl, bytes.NewBuffer(reqBody))
+	request, err := http.NewRequestWithContext(ctx, "POST", apiUrl, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
 	}
@@ -46,13 +80,17 @@ func CreateInvoiceRental(totalPrice *float64, user *entity.User, car *entity.Car
 	request.SetBasicAuth(apiKey, "")
 	request.Header.Set("Content-Type", "application/json")
 
-	response, err := client.Do(request)
+	response, err := InvoiceClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 
 	defer response.Body.Close()
 
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("xendit responded %s", response.Status)
+	}
+
 	var resInvoice entity.Invoice
 	if err := json.NewDecoder(response.Body).Decode(&resInvoice); err != nil {
 		return nil, err
@@ -61,7 +99,7 @@ func CreateInvoiceRental(totalPrice *float64, user *entity.User, car *entity.Car
 	return &resInvoice, nil
 }
 
-func CreateInvoiceTopUp(user *entity.User, amount float64) (*entity.Invoice, error) {
+func CreateInvoiceTopUp(ctx context.Context, user *entity.User, amount money.Money) (*entity.Invoice, error) {
 	apiKey := os.Getenv("XENDIT_API_KEY")
 	apiUrl := "https://api.xendit.co/v2/invoices"
 
@@ -83,8 +121,7 @@ func CreateInvoiceTopUp(user *entity.User, amount float64) (*entity.Invoice, err
 		return nil, err
 	}
 
-	client := &http.Client{}
-	request, err := http.NewRequest("POST", apiUrl, bytes.NewBuffer(reqBody))
+	request, err := http.NewRequestWithContext(ctx, "POST", apiUrl, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
 	}
@@ -92,13 +129,17 @@ func CreateInvoiceTopUp(user *entity.User, amount float64) (*entity.Invoice, err
 	request.SetBasicAuth(apiKey, "")
 	request.Header.Set("Content-Type", "application/json")
 
-	response, err := client.Do(request)
+	response, err := InvoiceClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 
 	defer response.Body.Close()
 
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("xendit responded %s", response.Status)
+	}
+
 	var resInvoice entity.Invoice
 	if err := json.NewDecoder(response.Body).Decode(&resInvoice); err != nil {
 		return nil, err
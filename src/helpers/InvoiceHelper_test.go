@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoiceItems_reconcileWithTotal(t *testing.T) {
+	breakdown := pricing.BreakdownOf([]pricing.Line{
+		{Code: pricing.LineRentalDay, Description: "Daily rental", Quantity: 2, UnitPrice: 300000, Amount: 600000},
+		{Code: pricing.RuleWeekend, Description: "Weekend (1 days)", Quantity: 1, UnitPrice: 60000, Amount: 60000},
+		{Code: pricing.LineCoupon, Description: "Coupon HEMAT10", Quantity: 1, UnitPrice: -66000, Amount: -66000},
+		{Code: pricing.LineInsurance, Description: "Insurance", Quantity: 2, UnitPrice: 50000, Amount: 100000},
+		{Code: pricing.LineVAT, Description: "VAT 11%", Quantity: 1, UnitPrice: 76340, Amount: 76340},
+	})
+
+	items, fees := InvoiceItems(&breakdown, &entity.Car{Name: "Avanza"})
+
+	assert.Len(t, items, 3)
+	assert.Equal(t, map[string]interface{}{"name": "Avanza - Daily rental", "quantity": 2, "price": money.Money(300000)}, items[0])
+	assert.Len(t, fees, 2)
+	assert.Equal(t, map[string]interface{}{"type": "Coupon HEMAT10", "value": money.Money(-66000)}, fees[0])
+
+	total := money.Money(0)
+	for _, item := range items {
+		item := item.(map[string]interface{})
+		total += item["price"].(money.Money).Mul(item["quantity"].(int))
+	}
+	for _, fee := range fees {
+		total += fee.(map[string]interface{})["value"].(money.Money)
+	}
+	assert.Equal(t, breakdown.Total, total)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCreateInvoiceTopUp_errorStatus(t *testing.T) {
+	defaultClient := InvoiceClient
+	t.Cleanup(func() { InvoiceClient = defaultClient })
+	InvoiceClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized", Body: io.NopCloser(strings.NewReader(`{"error_code": "INVALID_API_KEY"}`))}, nil
+	})}
+
+	invoice, err := CreateInvoiceTopUp(context.Background(), &entity.User{Fullname: "Budi"}, 100000)
+
+	assert.Nil(t, invoice)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/ratelimit"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reasons of rejected login attempts, successful attempts have no reason.
+const (
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginLocked             = "locked"
+	LoginRateLimited        = "rate_limited"
+)
+
+var (
+	dummyPasswordOnce sync.Once
+	dummyPassword     string
+)
+
+// DummyPassword is a hash to compare passwords of unknown emails with, so
+// they take as long to reject as wrong passwords.
+func DummyPassword() string {
+	dummyPasswordOnce.Do(func() {
+		dummyPassword = HashPassword("dummy password")
+	})
+	return dummyPassword
+}
+
+// NormalizeEmail returns the email that login attempts are recorded and
+// limited by.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// GetLockedUntil returns when email is unlocked, it counts the failed logins
+// since the last successful login within the lockout window. It is the zero
+// time for emails that aren't locked.
+func GetLockedUntil(db *gorm.DB, email string, lockout ratelimit.Lockout, now time.Time) (time.Time, *httputil.HTTPError) {
+	if lockout.Threshold <= 0 {
+		return time.Time{}, nil
+	}
+
+	var failures struct {
+		Count int
+		Last  *time.Time
+	}
+	since := now.Add(-lockout.Window)
+	res := db.Model(&entity.LoginAttempt{}).
+		Select("count(*) AS count, max(created_at) AS last").
+		Where("email = ? AND reason = ? AND created_at > ?", email, LoginInvalidCredentials, since).
+		Where("created_at > (SELECT coalesce(max(created_at), ?) FROM login_attempts WHERE email = ? AND success)", since, email).
+		Scan(&failures)
+	if res.Error != nil {
+		return time.Time{}, httputil.NewError(http.StatusInternalServerError, "GetLockedUntil: failed to count failed logins", res.Error)
+	}
+	if failures.Last == nil {
+		return time.Time{}, nil
+	}
+
+	return lockout.Until(failures.Count, *failures.Last), nil
+}
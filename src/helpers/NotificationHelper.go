@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/notification"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notify sends event to user with notifier inside tx, a failed notification
+// rolls back the transaction.
+func Notify(tx *gorm.DB, notifier *notification.Notifier, event string, user *entity.User, data mailer.Data, attachments ...mailer.Attachment) *httputil.HTTPError {
+	if err := notifier.Notify(tx, event, user, data, attachments...); err != nil {
+		return httputil.NewError(http.StatusInternalServerError, "Notify: failed to send "+event+" notification", err)
+	}
+	return nil
+}
+
+// GetNotificationPreferences returns the preference of every event and
+// channel, channels without a stored preference are enabled.
+func GetNotificationPreferences(db *gorm.DB, user_id int, channels []string) ([]dto.NotificationPreference, *httputil.HTTPError) {
+	stored := []entity.NotificationPreference{}
+	if res := db.Where("user_id = ?", user_id).Find(&stored); res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetNotificationPreferences: failed to get preferences", res.Error)
+	}
+
+	enabled := map[string]bool{}
+	for _, p := range stored {
+		enabled[p.Event+"/"+p.Channel] = p.Enabled
+	}
+
+	prefs := []dto.NotificationPreference{}
+	for _, event := range notification.Events {
+		for _, channel := range channels {
+			on, ok := enabled[event+"/"+channel]
+			prefs = append(prefs, dto.NotificationPreference{Event: event, Channel: channel, Enabled: on || !ok})
+		}
+	}
+	return prefs, nil
+}
+
+// SaveNotificationPreferences upserts prefs of the user, events and channels
+// are checked against notification.Events and channels.
+func SaveNotificationPreferences(tx *gorm.DB, user_id int, prefs []dto.NotificationPreference, channels []string) *httputil.HTTPError {
+	rows := []entity.NotificationPreference{}
+	for _, p := range prefs {
+		if !notification.IsEvent(p.Event) {
+			return httputil.NewFieldError("SaveNotificationPreferences: invalid event", "event", fmt.Sprintf("unknown event %q", p.Event), nil)
+		}
+		if !containsString(channels, p.Channel) {
+			return httputil.NewFieldError("SaveNotificationPreferences: invalid channel", "channel", fmt.Sprintf("unknown channel %q", p.Channel), nil)
+		}
+		rows = append(rows, entity.NotificationPreference{UserID: user_id, Event: p.Event, Channel: p.Channel, Enabled: p.Enabled})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	res := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&rows)
+	if res.Error != nil {
+		return httputil.NewError(http.StatusInternalServerError, "SaveNotificationPreferences: failed to save preferences", res.Error)
+	}
+	return nil
+}
+
+func GetNotificationByID(db *gorm.DB, user_id, notification_id int) (*entity.Notification, *httputil.HTTPError) {
+	n := new(entity.Notification)
+
+	res := db.Where("notification_id = ? AND user_id = ?", notification_id, user_id).First(n)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, httputil.NewError(http.StatusNotFound, "GetNotificationByID: notification id not found", res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetNotificationByID: failed to get notification", res.Error)
+	}
+	return n, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
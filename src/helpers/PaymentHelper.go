@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"errors"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/pricing"
+
+	"gorm.io/gorm"
+)
+
+// NewPaymentItems converts price lines into payment items of payment_id.
+func NewPaymentItems(payment_id int, lines []pricing.Line) []entity.PaymentItem {
+	items := make([]entity.PaymentItem, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, entity.PaymentItem{
+			PaymentID:   payment_id,
+			Code:        line.Code,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.Amount,
+		})
+	}
+	return items
+}
+
+// GetPaymentByRentalID returns nil without an error when the rental isn't
+// paid yet.
+func GetPaymentByRentalID(db *gorm.DB, rental_id int) (*entity.Payment, *httputil.HTTPError) {
+	payment := new(entity.Payment)
+
+	res := db.Where("rental_id = ?", rental_id).First(&payment)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetPaymentByRentalID: failed to get payment", res.Error)
+	}
+
+	return payment, nil
+}
@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/pricing"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// GetCoupon returns the coupon if it exists and hasn't expired at the given time.
+func GetCoupon(db *gorm.DB, coupon_id int, at time.Time) (*entity.Coupon, *httputil.HTTPError) {
+	coupon := new(entity.Coupon)
+
+	res := db.Where("coupon_id = ?", coupon_id).First(&coupon)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, httputil.NewError(http.StatusNotFound, "GetCoupon: coupon id not found", res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetCoupon: failed to get coupon", res.Error)
+	}
+	if coupon.ExpiresAt != nil && !at.Before(*coupon.ExpiresAt) {
+		msg := fmt.Sprintf("coupon %s expired at %s", coupon.CouponName, coupon.ExpiresAt.Format(time.RFC3339))
+		return nil, httputil.NewCodedError(http.StatusBadRequest, httputil.CodeCouponExpired, "GetCoupon: coupon expired", msg)
+	}
+
+	return coupon, nil
+}
+
+// NewPricingRule validates a pricing rule request and converts it into an
+// entity, dates are only kept for holiday and season rules.
+func NewPricingRule(req *dto.PricingRule) (*entity.PricingRule, *httputil.HTTPError) {
+	rule := &entity.PricingRule{
+		Name:        req.Name,
+		Type:        req.Type,
+		CategoryID:  req.CategoryID,
+		Percent:     req.Percent,
+		MinDays:     req.MinDays,
+		MinLeadDays: req.MinLeadDays,
+		Active:      req.Active == nil || *req.Active,
+	}
+
+	if req.Type != pricing.RuleHoliday && req.Type != pricing.RuleSeason {
+		return rule, nil
+	}
+
+	startDate, err := time.Parse(time.DateOnly, req.StartDate)
+	if err != nil {
+		return nil, httputil.NewFieldError("NewPricingRule: invalid start_date", "start_date", "must be a date formatted as 2006-01-02", err)
+	}
+	endDate, err := time.Parse(time.DateOnly, req.EndDate)
+	if err != nil {
+		return nil, httputil.NewFieldError("NewPricingRule: invalid end_date", "end_date", "must be a date formatted as 2006-01-02", err)
+	}
+	if endDate.Before(startDate) {
+		return nil, httputil.NewFieldError("NewPricingRule: invalid date range", "end_date", "must not be before start_date", nil)
+	}
+
+	start, end := datatypes.Date(startDate), datatypes.Date(endDate)
+	rule.StartDate, rule.EndDate = &start, &end
+
+	return rule, nil
+}
+
+// GetPricingRules returns the active rules that apply to every category or to
+// the given one.
+func GetPricingRules(db *gorm.DB, category_id int) ([]pricing.Rule, *httputil.HTTPError) {
+	rules := []entity.PricingRule{}
+
+	res := db.Where("active AND (category_id IS NULL OR category_id = ?)", category_id).Order("pricing_rule_id").Find(&rules)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetPricingRules: failed to get pricing rules", res.Error)
+	}
+
+	pricingRules := []pricing.Rule{}
+	for _, rule := range rules {
+		pricingRule := pricing.Rule{
+			Name:        rule.Name,
+			Type:        rule.Type,
+			Percent:     rule.Percent,
+			MinDays:     rule.MinDays,
+			MinLeadDays: rule.MinLeadDays,
+		}
+		if rule.StartDate != nil && rule.EndDate != nil {
+			pricingRule.StartDate, pricingRule.EndDate = time.Time(*rule.StartDate), time.Time(*rule.EndDate)
+		}
+		pricingRules = append(pricingRules, pricingRule)
+	}
+
+	return pricingRules, nil
+}
+
+// QuoteRental prices a rental of car with the pricing rules of its category.
+func QuoteRental(db *gorm.DB, r *dto.Rental, car *entity.Car, policy pricing.Policy, bookedAt time.Time) (*pricing.Breakdown, *httputil.HTTPError) {
+	rentalDate, err := policy.ParseTime(r.RentalDate)
+	if err != nil {
+		return nil, httputil.NewFieldError("QuoteRental: invalid rental_date", "rental_date", invalidTime, err)
+	}
+	returnDate, err := policy.ParseTime(r.ReturnDate)
+	if err != nil {
+		return nil, httputil.NewFieldError("QuoteRental: invalid return_date", "return_date", invalidTime, err)
+	}
+
+	rules, httpErr := GetPricingRules(db, car.CategoryID)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	input := pricing.Input{
+		DailyRate:  car.RentalCostPerDay,
+		HourlyRate: pricing.HourlyRate(car.RentalCostPerDay, car.RentalCostPerHour),
+		Pickup:     rentalDate,
+		Return:     returnDate,
+		BookedAt:   bookedAt,
+		Rules:      rules,
+		Insurance:  r.Insurance,
+		OneWayFee:  r.OneWayFee,
+	}
+	if r.CouponID != 0 {
+		coupon, httpErr := GetCoupon(db, r.CouponID, bookedAt)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		input.CouponName, input.CouponPercent = coupon.CouponName, coupon.DiscountPercent
+	}
+
+	breakdown, err := policy.Quote(input)
+	if err != nil {
+		return nil, httputil.NewCodedError(http.StatusBadRequest, httputil.CodeInvalidRentalPeriod, "QuoteRental: invalid rental period", err.Error())
+	}
+
+	return &breakdown, nil
+}
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/pricing"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidQuote = errors.New("invalid quote token")
+	ErrQuoteExpired = errors.New("quote token expired")
+)
+
+func signQuotePayload(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignQuote returns a token carrying the quote, the token is the base64 JSON
+// payload and its HMAC-SHA256 signature separated by a dot.
+func SignQuote(quote *dto.Quote, secret []byte) (string, error) {
+	unsigned := *quote
+	unsigned.Token = ""
+
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+
+	return payload + "." + signQuotePayload(payload, secret), nil
+}
+
+// VerifyQuote checks the signature and expiry of a quote token and returns
+// the quote it carries.
+func VerifyQuote(token string, secret []byte, now time.Time) (*dto.Quote, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signQuotePayload(payload, secret))) {
+		return nil, ErrInvalidQuote
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+	quote := new(dto.Quote)
+	if err := json.Unmarshal(data, quote); err != nil {
+		return nil, ErrInvalidQuote
+	}
+	if !now.Before(quote.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+
+	quote.Token = token
+	return quote, nil
+}
+
+// MatchQuote checks that a quote was issued to the user for the same rental
+// that is being created.
+func MatchQuote(quote *dto.Quote, r *dto.Rental, policy pricing.Policy) *httputil.HTTPError {
+	mismatch := func(field string) *httputil.HTTPError {
+		return httputil.NewCodedError(http.StatusBadRequest, httputil.CodeQuoteMismatch, "MatchQuote: quote does not match rental request", field+" differs from the quote")
+	}
+
+	if quote.UserID != r.UserID {
+		return httputil.NewCodedError(http.StatusBadRequest, httputil.CodeQuoteMismatch, "MatchQuote: quote does not match rental request", "quote was issued to another user")
+	}
+	if quote.CarID != r.CarID {
+		return mismatch("car_id")
+	}
+	if quote.CouponID != r.CouponID {
+		return mismatch("coupon_id")
+	}
+	if quote.Insurance != r.Insurance {
+		return mismatch("insurance")
+	}
+	if !SameBranch(quote.PickupBranchID, r.PickupBranchID) {
+		return mismatch("pickup_branch_id")
+	}
+	if !SameBranch(quote.DropoffBranchID, r.DropoffBranchID) {
+		return mismatch("dropoff_branch_id")
+	}
+	if !sameTime(policy, quote.RentalDate, r.RentalDate) {
+		return mismatch("rental_date")
+	}
+	if !sameTime(policy, quote.ReturnDate, r.ReturnDate) {
+		return mismatch("return_date")
+	}
+
+	return nil
+}
+
+func sameTime(policy pricing.Policy, a, b string) bool {
+	ta, errA := policy.ParseTime(a)
+	tb, errB := policy.ParseTime(b)
+	return errA == nil && errB == nil && ta.Equal(tb)
+}
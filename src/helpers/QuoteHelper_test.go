@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerifyQuote(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2024, 4, 18, 9, 0, 0, 0, time.UTC)
+	quote := &dto.Quote{
+		UserID:     1,
+		CarID:      2,
+		RentalDate: "2024-04-20T09:00:00+07:00",
+		ReturnDate: "2024-04-21T09:00:00+07:00",
+		Breakdown:  pricing.Breakdown{Days: 1, Total: 300000},
+		ExpiresAt:  now.Add(15 * time.Minute),
+	}
+
+	token, err := SignQuote(quote, secret)
+	assert.Nil(t, err)
+
+	verified, err := VerifyQuote(token, secret, now)
+	assert.Nil(t, err)
+	assert.Equal(t, money.Money(300000), verified.Breakdown.Total)
+
+	_, err = VerifyQuote(token, secret, now.Add(15*time.Minute))
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	_, err = VerifyQuote(payload+"x."+signature, secret, now)
+	assert.ErrorIs(t, err, ErrInvalidQuote)
+
+	_, err = VerifyQuote(token, []byte("another secret"), now)
+	assert.ErrorIs(t, err, ErrInvalidQuote)
+}
+
+func TestMatchQuote(t *testing.T) {
+	policy := pricing.Policy{Location: time.FixedZone("WIB", 7*60*60)}
+	quote := &dto.Quote{UserID: 1, CarID: 2, RentalDate: "2024-04-20T09:00:00+07:00", ReturnDate: "2024-04-21T09:00:00+07:00"}
+
+	rental := &dto.Rental{UserID: 1, CarID: 2, RentalDate: "2024-04-20T02:00:00Z", ReturnDate: "2024-04-21 09:00"}
+	assert.Nil(t, MatchQuote(quote, rental, policy))
+
+	rental.CarID = 3
+	assert.NotNil(t, MatchQuote(quote, rental, policy))
+
+	rental.CarID, rental.UserID = 2, 4
+	assert.NotNil(t, MatchQuote(quote, rental, policy))
+}
@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/receipt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetPaymentByID loads a payment with its rental and items.
+func GetPaymentByID(db *gorm.DB, payment_id int) (*entity.Payment, *httputil.HTTPError) {
+	payment := new(entity.Payment)
+
+	res := db.Preload("Rental").Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("payment_item_id")
+	}).Where("payment_id = ?", payment_id).First(&payment)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, httputil.NewError(http.StatusNotFound, "GetPaymentByID: payment id not found", res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetPaymentByID: failed to get payment", res.Error)
+	}
+
+	return payment, nil
+}
+
+// RenderReceipt renders the PDF receipt of a payment loaded by GetPaymentByID.
+func RenderReceipt(db *gorm.DB, generator *receipt.Generator, payment *entity.Payment) ([]byte, *httputil.HTTPError) {
+	user, err := GetUserByID(db, payment.Rental.UserID)
+	if err != nil {
+		return nil, err
+	}
+	car, err := GetCarByID(db, payment.Rental.CarID)
+	if err != nil {
+		return nil, err
+	}
+	method := new(entity.PaymentMethod)
+	if res := db.Where("payment_method_id = ?", payment.PaymentMethodID).First(&method); res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "RenderReceipt: failed to get payment method", res.Error)
+	}
+
+	r := &receipt.Receipt{
+		Number:          payment.ReceiptNumber,
+		PaidAt:          time.Time(payment.PaymentDate),
+		PaymentMethod:   method.PaymentName,
+		CustomerName:    user.Fullname,
+		CustomerEmail:   user.Email,
+		CustomerAddress: user.Address,
+		RentalID:        payment.RentalID,
+		CarName:         car.Name,
+		PlateNumber:     car.PlateNumber,
+		RentalDate:      payment.Rental.RentalDate,
+		ReturnDate:      payment.Rental.ReturnDate,
+		Total:           payment.TotalPrice,
+	}
+	if r.Number == "" {
+		r.Number = receipt.Number(payment.ID, r.PaidAt)
+	}
+	for _, item := range payment.Items {
+		r.Items = append(r.Items, receipt.Item{Description: item.Description, Quantity: item.Quantity, UnitPrice: item.UnitPrice, Amount: item.Amount})
+	}
+	if len(r.Items) == 0 {
+		r.Items = []receipt.Item{{Description: "Car rental", Quantity: 1, UnitPrice: payment.TotalPrice, Amount: payment.TotalPrice}}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := generator.Render(buf, r); err != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "RenderReceipt: failed to render receipt", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,190 @@
+package helpers
+
+import (
+	"math"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/pricing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultReportDays  = 30
+	defaultReportLimit = 10
+)
+
+// ReportPeriod is the [From, To) range of a report, both at midnight in the
+// pricing location.
+type ReportPeriod struct {
+	From time.Time
+	To   time.Time
+}
+
+func (p ReportPeriod) Hours() float64 {
+	return p.To.Sub(p.From).Hours()
+}
+
+// GetReportPeriod reads the from and to dates of filter, both inclusive. It
+// defaults to the last 30 days up to today.
+func GetReportPeriod(filter *dto.ReportFilter, policy pricing.Policy, now time.Time) (*ReportPeriod, *httputil.HTTPError) {
+	today := policy.Local(now)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	if filter.To != "" {
+		t, err := policy.ParseTime(filter.To)
+		if err != nil {
+			return nil, httputil.NewFieldError("GetReportPeriod: invalid to", "to", invalidTime, err)
+		}
+		to = t
+	}
+	to = to.AddDate(0, 0, 1)
+
+	from := to.AddDate(0, 0, -defaultReportDays)
+	if filter.From != "" {
+		t, err := policy.ParseTime(filter.From)
+		if err != nil {
+			return nil, httputil.NewFieldError("GetReportPeriod: invalid from", "from", invalidTime, err)
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return nil, httputil.NewFieldError("GetReportPeriod: invalid period", "from", "must not be after to", nil)
+	}
+	return &ReportPeriod{From: from, To: to}, nil
+}
+
+// Revenue is recognized on the payment date of settled payments.
+const settledPayments = "p.payment_status = 'settlement' AND p.payment_date >= ? AND p.payment_date < ?"
+
+// Rental reports count the paid rentals starting in the period.
+const paidRentals = "r.rental_date >= ? AND r.rental_date < ? AND EXISTS (SELECT 1 FROM payments p WHERE p.rental_id = r.rental_id AND p.payment_status = 'settlement')"
+
+func GetRevenueByPeriod(db *gorm.DB, period *ReportPeriod, groupBy string) ([]dto.RevenuePeriod, *httputil.HTTPError) {
+	if groupBy == "" {
+		groupBy = "day"
+	}
+
+	revenue := []dto.RevenuePeriod{}
+	res := db.Raw(`SELECT to_char(date_trunc(?, p.payment_date), 'YYYY-MM-DD') AS period, count(*) AS payments, sum(p.total_price)::bigint AS revenue
+		FROM payments p
+		WHERE `+settledPayments+`
+		GROUP BY 1 ORDER BY 1`, groupBy, period.From, period.To).Scan(&revenue)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetRevenueByPeriod: failed to query revenue", res.Error)
+	}
+	return revenue, nil
+}
+
+func GetRevenueByCategory(db *gorm.DB, period *ReportPeriod) ([]dto.CategoryRevenue, *httputil.HTTPError) {
+	revenue := []dto.CategoryRevenue{}
+	res := db.Raw(`SELECT cat.category_id, cat.type AS category, count(*) AS rentals, sum(p.total_price)::bigint AS revenue
+		FROM payments p
+		JOIN rentals r ON r.rental_id = p.rental_id
+		JOIN cars c ON c.car_id = r.car_id
+		JOIN categories cat ON cat.category_id = c.category_id
+		WHERE `+settledPayments+`
+		GROUP BY cat.category_id, cat.type ORDER BY revenue DESC`, period.From, period.To).Scan(&revenue)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetRevenueByCategory: failed to query revenue", res.Error)
+	}
+	return revenue, nil
+}
+
+func GetRevenueByCar(db *gorm.DB, period *ReportPeriod) ([]dto.CarRevenue, *httputil.HTTPError) {
+	revenue := []dto.CarRevenue{}
+	res := db.Raw(`SELECT c.car_id, c.name, coalesce(c.plate_number, '') AS plate_number, count(*) AS rentals, sum(p.total_price)::bigint AS revenue
+		FROM payments p
+		JOIN rentals r ON r.rental_id = p.rental_id
+		JOIN cars c ON c.car_id = r.car_id
+		WHERE `+settledPayments+`
+		GROUP BY c.car_id, c.name, c.plate_number ORDER BY revenue DESC`, period.From, period.To).Scan(&revenue)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetRevenueByCar: failed to query revenue", res.Error)
+	}
+	return revenue, nil
+}
+
+// GetUtilization returns the share of the period every car was rented for.
+// Rentals are clipped to the period, unreturned rentals run until now or
+// their return date.
+func GetUtilization(db *gorm.DB, period *ReportPeriod, now time.Time) (*dto.Utilization, *httputil.HTTPError) {
+	hours := period.Hours()
+
+	cars := []dto.CarUtilization{}
+	res := db.Raw(`WITH usage AS (
+			SELECT c.car_id, c.name, coalesce(sum(extract(epoch FROM
+				least(coalesce(r.returned_at, greatest(r.return_date, @now)), @to) - greatest(r.rental_date, @from)
+			)) / 3600, 0) AS rented_hours
+			FROM cars c
+			LEFT JOIN rentals r ON r.car_id = c.car_id
+				AND r.rental_date < @to AND coalesce(r.returned_at, greatest(r.return_date, @now)) > @from
+				AND EXISTS (SELECT 1 FROM payments p WHERE p.rental_id = r.rental_id AND p.payment_status = 'settlement')
+			GROUP BY c.car_id, c.name
+		)
+		SELECT car_id, name, round(rented_hours::numeric, 2) AS rented_hours, round((100 * rented_hours / @hours)::numeric, 2) AS utilization_percent
+		FROM usage ORDER BY utilization_percent DESC, car_id`,
+		map[string]interface{}{"from": period.From, "to": period.To, "now": now, "hours": hours}).Scan(&cars)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetUtilization: failed to query utilization", res.Error)
+	}
+
+	utilization := &dto.Utilization{FleetSize: len(cars), PeriodHours: hours, Cars: cars}
+	for _, car := range cars {
+		utilization.RentedHours += car.RentedHours
+	}
+	utilization.RentedHours = math.Round(utilization.RentedHours*100) / 100
+	if len(cars) > 0 {
+		utilization.UtilizationPercent = math.Round(10000*utilization.RentedHours/(hours*float64(len(cars)))) / 100
+	}
+	return utilization, nil
+}
+
+func GetRentalLength(db *gorm.DB, period *ReportPeriod) (*dto.RentalLength, *httputil.HTTPError) {
+	length := new(dto.RentalLength)
+	res := db.Raw(`SELECT count(*) AS rentals,
+			round(coalesce(avg(extract(epoch FROM coalesce(r.returned_at, r.return_date) - r.rental_date)) / 3600, 0)::numeric, 2) AS average_hours,
+			round(coalesce(avg(extract(epoch FROM coalesce(r.returned_at, r.return_date) - r.rental_date)) / 86400, 0)::numeric, 2) AS average_days
+		FROM rentals r
+		WHERE `+paidRentals, period.From, period.To).Scan(length)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetRentalLength: failed to query rental length", res.Error)
+	}
+	return length, nil
+}
+
+// GetCouponUsage returns how often every coupon was used and the discount it
+// cost, read from the coupon line of the rental price.
+func GetCouponUsage(db *gorm.DB, period *ReportPeriod) ([]dto.CouponUsage, *httputil.HTTPError) {
+	usage := []dto.CouponUsage{}
+	res := db.Raw(`SELECT co.coupon_id, co.coupon_name, count(DISTINCT r.rental_id) AS rentals, coalesce(sum(-(l->>'amount')::bigint), 0)::bigint AS discount
+		FROM rentals r
+		JOIN coupons co ON co.coupon_id = r.coupon_id
+		LEFT JOIN LATERAL jsonb_array_elements(r.price_lines) l ON l->>'code' = 'coupon'
+		WHERE `+paidRentals+`
+		GROUP BY co.coupon_id, co.coupon_name ORDER BY discount DESC`, period.From, period.To).Scan(&usage)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetCouponUsage: failed to query coupon usage", res.Error)
+	}
+	return usage, nil
+}
+
+func GetTopCustomers(db *gorm.DB, period *ReportPeriod, limit int) ([]dto.TopCustomer, *httputil.HTTPError) {
+	if limit == 0 {
+		limit = defaultReportLimit
+	}
+
+	customers := []dto.TopCustomer{}
+	res := db.Raw(`SELECT u.user_id, u.fullname, u.email, count(*) AS rentals, sum(p.total_price)::bigint AS revenue
+		FROM payments p
+		JOIN rentals r ON r.rental_id = p.rental_id
+		JOIN users u ON u.user_id = r.user_id
+		WHERE `+settledPayments+`
+		GROUP BY u.user_id, u.fullname, u.email ORDER BY revenue DESC, u.user_id LIMIT ?`, period.From, period.To, limit).Scan(&customers)
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetTopCustomers: failed to query customers", res.Error)
+	}
+	return customers, nil
+}
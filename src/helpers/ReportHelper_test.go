@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/pricing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReportPeriod(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	policy := pricing.Policy{Location: jakarta}
+	now := time.Date(2024, 4, 18, 20, 0, 0, 0, time.UTC)
+
+	period, err := GetReportPeriod(&dto.ReportFilter{}, policy, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 4, 20, 0, 0, 0, 0, jakarta), period.To)
+	assert.Equal(t, time.Date(2024, 3, 21, 0, 0, 0, 0, jakarta), period.From)
+
+	period, err = GetReportPeriod(&dto.ReportFilter{From: "2024-04-01", To: "2024-04-01"}, policy, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, jakarta), period.From)
+	assert.Equal(t, time.Date(2024, 4, 2, 0, 0, 0, 0, jakarta), period.To)
+	assert.Equal(t, 24.0, period.Hours())
+
+	_, err = GetReportPeriod(&dto.ReportFilter{From: "2024-04-02", To: "2024-04-01"}, policy, now)
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"p2-mini-project/src/entity"
 	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/money"
 
 	"gorm.io/gorm"
 )
@@ -18,8 +19,8 @@ func GetUserByID(db *gorm.DB, user_id int) (*entity.User, *httputil.HTTPError) {
 	return user, nil
 }
 
-func GetUserDeposit(cs *gorm.DB, user_id int) (float64, *httputil.HTTPError) {
-	deposit := 0.0
+func GetUserDeposit(cs *gorm.DB, user_id int) (money.Money, *httputil.HTTPError) {
+	deposit := money.Money(0)
 	if res := cs.Table("users").Select("deposit").Where("user_id = ?", user_id).Scan(&deposit); res.Error != nil {
 		return -1, httputil.NewError(http.StatusInternalServerError, "GetUserDeposit: fail to get deposit user", res.Error)
 	}
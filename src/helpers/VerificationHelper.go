@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// VerificationTTL is how long an email verification link stays valid.
+const VerificationTTL = 24 * time.Hour
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// VerificationSecret signs verification tokens, config.SetupVerification sets
+// it from VERIFICATION_SECRET and tokens are refused while it is empty.
+var VerificationSecret []byte
+
+type verificationClaims struct {
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// The token is deliberately not a JWT so it can't be used as an access token.
+func signVerificationPayload(payload string) string {
+	mac := hmac.New(sha256.New, VerificationSecret)
+	mac.Write([]byte("verify_email." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CreateVerificationToken returns a token proving the owner of email can read
+// it, it expires after VerificationTTL.
+func CreateVerificationToken(user_id int, email string, now time.Time) (string, error) {
+	if len(VerificationSecret) == 0 {
+		return "", errors.New("verification secret is not set")
+	}
+	data, err := json.Marshal(verificationClaims{UserID: user_id, Email: email, ExpiresAt: now.Add(VerificationTTL).Unix()})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+
+	return payload + "." + signVerificationPayload(payload), nil
+}
+
+// ParseVerificationToken returns the user id and email the token was issued
+// for.
+func ParseVerificationToken(token string, now time.Time) (int, string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || len(VerificationSecret) == 0 || !hmac.Equal([]byte(signature), []byte(signVerificationPayload(payload))) {
+		return 0, "", ErrInvalidVerificationToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	claims := verificationClaims{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return 0, "", ErrInvalidVerificationToken
+	}
+
+	return claims.UserID, claims.Email, nil
+}
@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationToken(t *testing.T) {
+	VerificationSecret = []byte("secret")
+	defer func() { VerificationSecret = nil }()
+	now := time.Date(2024, 4, 18, 9, 0, 0, 0, time.UTC)
+
+	token, err := CreateVerificationToken(7, "budi@mail.com", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(token, "."), "must not look like a JWT")
+
+	user_id, email, err := ParseVerificationToken(token, now.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 7, user_id)
+	assert.Equal(t, "budi@mail.com", email)
+
+	_, _, err = ParseVerificationToken(token, now.Add(VerificationTTL))
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	_, _, err = ParseVerificationToken(token+"x", now)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
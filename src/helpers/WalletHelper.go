@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WalletTopUp   = "topup"
+	WalletPayment = "payment"
+)
+
+// CreditDeposit adds amount to the user deposit and records it in the wallet
+// ledger, it should be called inside a transaction.
+func CreditDeposit(tx *gorm.DB, user_id int, amount money.Money, walletType, reference string) *httputil.HTTPError {
+	_, err := updateDeposit(tx, user_id, amount, walletType, reference)
+	return err
+}
+
+// TopUpDeposit credits a top up and returns its wallet transaction, the
+// invoice of the top up is stored on it.
+func TopUpDeposit(tx *gorm.DB, user_id int, amount money.Money) (*entity.WalletTransaction, *httputil.HTTPError) {
+	return updateDeposit(tx, user_id, amount, WalletTopUp, "")
+}
+
+// DebitDeposit subtracts amount from the user deposit, the balance is checked
+// in the same statement so concurrent payments can't overdraw it.
+func DebitDeposit(tx *gorm.DB, user_id int, amount money.Money, walletType, reference string) *httputil.HTTPError {
+	_, err := updateDeposit(tx, user_id, -amount, walletType, reference)
+	return err
+}
+
+// SettleLateFees charges the outstanding late fees of a user from the deposit,
+// oldest first, and stops at the first fee the deposit can't cover. It returns
+// the late fees still due. Returns only record the fee so they never fail on
+// the deposit, fees are settled after the return and after every top up.
+func SettleLateFees(db *gorm.DB, user_id int) (money.Money, *httputil.HTTPError) {
+	rentals := []entity.Rental{}
+	if res := db.Select("rental_id", "late_fee_due").Where("user_id = ? AND late_fee_due > 0", user_id).Order("returned_at, rental_id").Find(&rentals); res.Error != nil {
+		return 0, httputil.NewError(http.StatusInternalServerError, "SettleLateFees: failed to get late fees", res.Error)
+	}
+
+	var due money.Money
+	for i, rental := range rentals {
+		txErr := db.Transaction(func(tx *gorm.DB) error {
+			// a concurrent settlement already charged the fee when no row is updated
+			res := tx.Model(&entity.Rental{}).Where("rental_id = ? AND late_fee_due = ?", rental.ID, rental.LateFeeDue).Update("late_fee_due", 0)
+			if res.Error != nil {
+				return httputil.NewError(http.StatusInternalServerError, "SettleLateFees: failed to update rental", res.Error)
+			}
+			if res.RowsAffected == 0 {
+				return nil
+			}
+			if err := DebitDeposit(tx, user_id, rental.LateFeeDue, WalletPayment, fmt.Sprintf("late_fee:%d", rental.ID)); err != nil {
+				return err
+			}
+			return nil
+		})
+
+		var httpErr *httputil.HTTPError
+		if errors.As(txErr, &httpErr) && httpErr.Code == httputil.CodeInsufficientDeposit {
+			for _, rental := range rentals[i:] {
+				due += rental.LateFeeDue
+			}
+			return due, nil
+		}
+		if txErr != nil {
+			return 0, httputil.NewError(http.StatusInternalServerError, "SettleLateFees: failed to charge late fee", txErr)
+		}
+	}
+
+	return 0, nil
+}
+
+func updateDeposit(tx *gorm.DB, user_id int, amount money.Money, walletType, reference string) (*entity.WalletTransaction, *httputil.HTTPError) {
+	user := new(entity.User)
+
+	res := tx.Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "deposit"}}}).
+		Where("user_id = ? AND deposit + ? >= 0", user_id, amount).
+		Update("deposit", gorm.Expr("deposit + ?", amount))
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "updateDeposit: failed to update deposit", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		msg := fmt.Sprintf("deposit is not enough for %s", -amount)
+		return nil, httputil.NewCodedError(http.StatusBadRequest, httputil.CodeInsufficientDeposit, "updateDeposit: your deposit is not enough", msg)
+	}
+
+	transaction := entity.WalletTransaction{
+		UserID:       user_id,
+		Type:         walletType,
+		Amount:       amount,
+		BalanceAfter: user.Deposit,
+		Reference:    reference,
+	}
+	if res := tx.Create(&transaction); res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "updateDeposit: failed to record wallet transaction", res.Error)
+	}
+
+	return &transaction, nil
+}
@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/testutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettleLateFees(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+
+	mock.ExpectQuery(`SELECT "rental_id","late_fee_due" FROM "rentals" WHERE user_id = \$1 AND late_fee_due > 0 ORDER BY returned_at, rental_id`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id", "late_fee_due"}).AddRow(3, 100000).AddRow(4, 250000))
+
+	// the first fee is covered by the deposit
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "rentals" SET "late_fee_due"=\$1 WHERE rental_id = \$2 AND late_fee_due = \$3`).
+		WithArgs(0, 3, 100000).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE "users" SET "deposit"=deposit \+ \$1 WHERE user_id = \$2 AND deposit \+ \$3 >= 0 RETURNING "deposit"`).
+		WithArgs(-100000, 1, -100000).
+		WillReturnRows(sqlmock.NewRows([]string{"deposit"}).AddRow(50000))
+	mock.ExpectQuery(`INSERT INTO "wallet_transactions"`).
+		WithArgs(1, WalletPayment, -100000, 50000, "late_fee:3", sqlmock.AnyArg(), "").
+		WillReturnRows(sqlmock.NewRows([]string{"wallet_transaction_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// the second isn't and stays due
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "rentals" SET "late_fee_due"=\$1 WHERE rental_id = \$2 AND late_fee_due = \$3`).
+		WithArgs(0, 4, 250000).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`UPDATE "users" SET "deposit"=deposit \+ \$1`).
+		WithArgs(-250000, 1, -250000).
+		WillReturnRows(sqlmock.NewRows([]string{"deposit"}))
+	mock.ExpectRollback()
+
+	due, err := SettleLateFees(db, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, money.Money(250000), due)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"errors"
+	"net/http"
+	"p2-mini-project/src/dto"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/pricing"
+	"p2-mini-project/src/webhook"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// PublishWebhook sends event to the webhook endpoints subscribed to it once
+// tx commits.
+func PublishWebhook(tx *gorm.DB, event string, data interface{}) *httputil.HTTPError {
+	if err := webhook.Publish(tx, event, data); err != nil {
+		return httputil.NewError(http.StatusInternalServerError, "PublishWebhook: failed to publish "+event, err)
+	}
+	return nil
+}
+
+// PublishCarUpdated sends car.updated with the whole car, it is published
+// whenever a car changes, including its status on payment and return.
+func PublishCarUpdated(tx *gorm.DB, car_id int) *httputil.HTTPError {
+	car, err := GetCarByID(tx, car_id)
+	if err != nil {
+		return err
+	}
+	return PublishWebhook(tx, webhook.EventCarUpdated, car)
+}
+
+// RentalWebhook is the rental.created payload, the request-only fields of
+// dto.Rental such as the quote token are left out.
+type RentalWebhook struct {
+	ID              int                               `json:"rental_id"`
+	UserID          int                               `json:"user_id"`
+	CarID           int                               `json:"car_id"`
+	CouponID        int                               `json:"coupon_id,omitempty"`
+	PickupBranchID  *int                              `json:"pickup_branch_id"`
+	DropoffBranchID *int                              `json:"dropoff_branch_id"`
+	Insurance       bool                              `json:"insurance"`
+	TotalPrice      money.Money                       `json:"total_price"`
+	PriceLines      datatypes.JSONSlice[pricing.Line] `json:"price_lines"`
+	RentalDate      time.Time                         `json:"rental_date"`
+	ReturnDate      time.Time                         `json:"return_date"`
+}
+
+func NewRentalWebhook(rental *dto.Rental, rentalDate, returnDate time.Time) RentalWebhook {
+	return RentalWebhook{
+		ID:              rental.ID,
+		UserID:          rental.UserID,
+		CarID:           rental.CarID,
+		CouponID:        rental.CouponID,
+		PickupBranchID:  rental.PickupBranchID,
+		DropoffBranchID: rental.DropoffBranchID,
+		Insurance:       rental.Insurance,
+		TotalPrice:      rental.TotalPrice,
+		PriceLines:      rental.PriceLines,
+		RentalDate:      rentalDate,
+		ReturnDate:      returnDate,
+	}
+}
+
+func GetWebhookByID(db *gorm.DB, webhook_id int) (*entity.WebhookEndpoint, *httputil.HTTPError) {
+	endpoint := new(entity.WebhookEndpoint)
+
+	res := db.Where("webhook_endpoint_id = ?", webhook_id).First(endpoint)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, httputil.NewError(http.StatusNotFound, "GetWebhookByID: webhook id not found", res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetWebhookByID: failed to get webhook", res.Error)
+	}
+	return endpoint, nil
+}
+
+func GetWebhookDelivery(db *gorm.DB, webhook_id, delivery_id int) (*entity.WebhookDelivery, *httputil.HTTPError) {
+	delivery := new(entity.WebhookDelivery)
+
+	res := db.Preload("AttemptLog").Where("webhook_delivery_id = ? AND webhook_endpoint_id = ?", delivery_id, webhook_id).First(delivery)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, httputil.NewError(http.StatusNotFound, "GetWebhookDelivery: delivery id not found", res.Error)
+	}
+	if res.Error != nil {
+		return nil, httputil.NewError(http.StatusInternalServerError, "GetWebhookDelivery: failed to get delivery", res.Error)
+	}
+	return delivery, nil
+}
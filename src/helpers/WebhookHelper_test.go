@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"encoding/json"
+	"p2-mini-project/src/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRentalWebhook(t *testing.T) {
+	rentalDate := time.Date(2024, 4, 18, 2, 0, 0, 0, time.UTC)
+	rental := &dto.Rental{ID: 7, UserID: 1, CarID: 3, TotalPrice: 300000, RentalDate: "2024-04-18 09:00", QuoteToken: "secret-quote"}
+
+	data, err := json.Marshal(NewRentalWebhook(rental, rentalDate, rentalDate.Add(48*time.Hour)))
+	assert.Nil(t, err)
+
+	payload := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(data, &payload))
+	assert.NotContains(t, payload, "quote_token")
+	assert.Equal(t, float64(7), payload["rental_id"])
+	assert.Equal(t, "2024-04-18T02:00:00Z", payload["rental_date"])
+}
@@ -0,0 +1,127 @@
+package httputil
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Error codes are part of the API, clients may rely on them so existing
+// codes must not be renamed.
+const (
+	CodeBadRequest          = "BAD_REQUEST"
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeNotFound            = "NOT_FOUND"
+	CodeConflict            = "CONFLICT"
+	CodePayloadTooLarge     = "PAYLOAD_TOO_LARGE"
+	CodeUnprocessableEntity = "UNPROCESSABLE_ENTITY"
+	CodeTooManyRequests     = "TOO_MANY_REQUESTS"
+	CodeInternal            = "INTERNAL_ERROR"
+
+	CodeInvalidCredentials       = "INVALID_CREDENTIALS"
+	CodeAccountLocked            = "ACCOUNT_LOCKED"
+	CodeTokenExpired             = "TOKEN_EXPIRED"
+	CodeAPIKeyInvalid            = "API_KEY_INVALID"
+	CodeAPIKeyExpired            = "API_KEY_EXPIRED"
+	CodeAPIKeyRevoked            = "API_KEY_REVOKED"
+	CodeInsufficientScope        = "INSUFFICIENT_SCOPE"
+	CodeInvalidVerificationToken = "INVALID_VERIFICATION_TOKEN"
+	CodeEmailAlreadyVerified     = "EMAIL_ALREADY_VERIFIED"
+	CodeInsufficientDeposit      = "INSUFFICIENT_DEPOSIT"
+	CodeCarUnavailable           = "CAR_UNAVAILABLE"
+	CodeCarAlreadyRegistered     = "CAR_ALREADY_REGISTERED"
+	CodeCarAlreadyReturned       = "CAR_ALREADY_RETURNED"
+	CodeCouponExpired            = "COUPON_EXPIRED"
+	CodeInvalidRentalPeriod      = "INVALID_RENTAL_PERIOD"
+	CodeQuoteInvalid             = "QUOTE_INVALID"
+	CodeQuoteMismatch            = "QUOTE_MISMATCH"
+	CodeRentalAlreadyPaid        = "RENTAL_ALREADY_PAID"
+	CodeNotRentalOwner           = "NOT_RENTAL_OWNER"
+	CodeBranchInUse              = "BRANCH_IN_USE"
+	CodeWebhookDisabled          = "WEBHOOK_DISABLED"
+	CodeTooManyImages            = "TOO_MANY_IMAGES"
+	CodeImageTooLarge            = "IMAGE_TOO_LARGE"
+	CodeInvalidImage             = "INVALID_IMAGE"
+	CodeIdempotencyKeyInvalid    = "IDEMPOTENCY_KEY_INVALID"
+	CodeIdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyKeyInUse      = "IDEMPOTENCY_KEY_IN_USE"
+)
+
+var titles = map[string]string{
+	CodeBadRequest:          "Bad request",
+	CodeValidationFailed:    "Validation failed",
+	CodeUnauthorized:        "Unauthorized",
+	CodeForbidden:           "Forbidden",
+	CodeNotFound:            "Not found",
+	CodeConflict:            "Conflict",
+	CodePayloadTooLarge:     "Payload too large",
+	CodeUnprocessableEntity: "Unprocessable entity",
+	CodeTooManyRequests:     "Too many requests",
+	CodeInternal:            "Internal server error",
+
+	CodeInvalidCredentials:       "Invalid credentials",
+	CodeAccountLocked:            "Account locked",
+	CodeTokenExpired:             "Token expired",
+	CodeAPIKeyInvalid:            "Invalid API key",
+	CodeAPIKeyExpired:            "API key expired",
+	CodeAPIKeyRevoked:            "API key revoked",
+	CodeInsufficientScope:        "Insufficient scope",
+	CodeInvalidVerificationToken: "Invalid verification token",
+	CodeEmailAlreadyVerified:     "Email already verified",
+	CodeInsufficientDeposit:      "Insufficient deposit",
+	CodeCarUnavailable:           "Car unavailable",
+	CodeCarAlreadyRegistered:     "Car already registered",
+	CodeCarAlreadyReturned:       "Car already returned",
+	CodeCouponExpired:            "Coupon expired",
+	CodeInvalidRentalPeriod:      "Invalid rental period",
+	CodeQuoteInvalid:             "Invalid quote",
+	CodeQuoteMismatch:            "Quote does not match",
+	CodeRentalAlreadyPaid:        "Rental already paid",
+	CodeNotRentalOwner:           "Not the owner of the rental",
+	CodeBranchInUse:              "Branch in use",
+	CodeWebhookDisabled:          "Webhook disabled",
+	CodeTooManyImages:            "Too many images",
+	CodeImageTooLarge:            "Image too large",
+	CodeInvalidImage:             "Invalid image",
+	CodeIdempotencyKeyInvalid:    "Invalid Idempotency-Key",
+	CodeIdempotencyKeyReused:     "Idempotency-Key already used",
+	CodeIdempotencyKeyInUse:      "Idempotency-Key in use",
+}
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeUnprocessableEntity,
+	http.StatusTooManyRequests:       CodeTooManyRequests,
+	http.StatusInternalServerError:   CodeInternal,
+}
+
+// StatusCode returns the generic code of status.
+func StatusCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Title returns the short summary of code.
+func Title(code string) string {
+	if title, ok := titles[code]; ok {
+		return title
+	}
+	return code
+}
+
+// TypeURI returns the problem type of code, a URI reference relative to the
+// API host such as /problems/insufficient-deposit.
+func TypeURI(code string) string {
+	return "/problems/" + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
@@ -1,21 +1,138 @@
 package httputil
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
+// HTTPError is an error responded to the client as a problem. Err is the
+// cause, it is logged but never returned since it may carry internal details
+// such as database errors.
 type HTTPError struct {
-	Code    int    `json:"code" swaggerignore:"true"`
-	Message string `json:"message"`
-	Detail  string `json:"detail"`
+	Status int
+	// Code is the catalog code of the error, see codes.go.
+	Code    string
+	Message string
+	// Detail is returned to the client, it defaults to Message.
+	Detail string
+	Fields []FieldError
+	Err    error
 }
 
 func (h *HTTPError) Error() string {
-	return fmt.Sprintf("Error %d: %s", h.Code, h.Message)
+	return fmt.Sprintf("Error %d: %s", h.Status, h.Message)
 }
 
+func (h *HTTPError) Unwrap() error {
+	return h.Err
+}
+
+// NewError returns an error with the generic code of status. Validation and
+// decoding errors of the binding layer are reported per field, bodies over
+// the size limit as too large whatever status is.
 func NewError(status int, message string, err error) *HTTPError {
+	h := &HTTPError{
+		Status:  status,
+		Code:    StatusCode(status),
+		Message: message,
+		Err:     err,
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		h.Status = http.StatusRequestEntityTooLarge
+		h.Code = CodePayloadTooLarge
+		h.Detail = fmt.Sprintf("request body is larger than %d bytes", maxBytesErr.Limit)
+		return h
+	}
+	if fields := FieldErrors(err); len(fields) > 0 {
+		h.Code = CodeValidationFailed
+		h.Fields = fields
+	}
+	return h
+}
+
+// NewCodedError returns an error with a catalog code, detail is written for
+// the client and returned as is.
+func NewCodedError(status int, code, message, detail string) *HTTPError {
 	return &HTTPError{
-		Code:    status,
+		Status:  status,
+		Code:    code,
 		Message: message,
-		Detail:  err.Error(),
+		Detail:  detail,
+	}
+}
+
+// WithCode sets the catalog code of h.
+func (h *HTTPError) WithCode(code string) *HTTPError {
+	h.Code = code
+	return h
+}
+
+// NewFieldError returns a validation error of a single field, err is the
+// cause such as a parse error.
+func NewFieldError(message, field, reason string, err error) *HTTPError {
+	return &HTTPError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeValidationFailed,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Rule: "format", Message: reason}},
+		Err:     err,
+	}
+}
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type      string       `json:"type" example:"/problems/insufficient-deposit"`
+	Title     string       `json:"title" example:"Insufficient deposit"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"your deposit is Rp100.000 while total payment is Rp350.000"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/cars/pay/1"`
+	Code      string       `json:"code" example:"INSUFFICIENT_DEPOSIT"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Problem returns the problem of h for the request to instance. Server
+// errors only report their code.
+func (h *HTTPError) Problem(instance, requestID string) Problem {
+	p := Problem{
+		Type:      TypeURI(h.Code),
+		Title:     Title(h.Code),
+		Status:    h.Status,
+		Instance:  instance,
+		Code:      h.Code,
+		RequestID: requestID,
+		Errors:    h.Fields,
+	}
+	if h.Status < 500 {
+		p.Detail = h.Detail
+		if p.Detail == "" {
+			p.Detail = publicMessage(h.Message)
+		}
+	}
+	return p
+}
+
+// publicMessage drops the "FuncName: " prefix of message, it only helps to
+// find the source of the error in the logs.
+func publicMessage(message string) string {
+	prefix, rest, found := strings.Cut(message, ": ")
+	if !found || strings.ContainsAny(prefix, " -") {
+		return message
+	}
+	return rest
+}
+
+// RetryAfter returns the Retry-After header value of wait, in whole seconds
+// rounded up.
+func RetryAfter(wait time.Duration) string {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
 	}
+	return strconv.Itoa(seconds)
 }
@@ -0,0 +1,51 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	err := NewError(http.StatusNotFound, "GetCarByID: car id not found", errors.New("record not found"))
+	assert.Equal(t, CodeNotFound, err.Code)
+
+	problem := err.Problem("/api/v1/cars/9", "req-1")
+	assert.Equal(t, "/problems/not-found", problem.Type)
+	assert.Equal(t, "Not found", problem.Title)
+	assert.Equal(t, "car id not found", problem.Detail)
+	assert.Equal(t, "req-1", problem.RequestID)
+
+	assert.Equal(t, CodeInternal, NewError(http.StatusBadGateway, "failed", errors.New("eof")).Code)
+	assert.Equal(t, "Idempotency-Key already used", publicMessage("Idempotency-Key already used"))
+}
+
+func TestFieldErrors(t *testing.T) {
+	type preference struct {
+		Channel string `json:"channel" binding:"required,oneof=email webhook"`
+	}
+	type request struct {
+		Email       string       `json:"email" binding:"required,email"`
+		Password    string       `json:"password" binding:"required,min=8"`
+		Status      string       `form:"status" binding:"omitempty,oneof=settlement refunded"`
+		Preferences []preference `json:"preferences" binding:"dive"`
+	}
+
+	err := binding.Validator.ValidateStruct(&request{
+		Email:       "budi",
+		Password:    "secret",
+		Status:      "paid",
+		Preferences: []preference{{Channel: "sms"}},
+	})
+	assert.Equal(t, []FieldError{
+		{Field: "email", Rule: "email", Message: "must be a valid email"},
+		{Field: "password", Rule: "min", Message: "must have at least 8 characters"},
+		{Field: "status", Rule: "oneof", Message: "must be one of settlement, refunded"},
+		{Field: "preferences[0].channel", Rule: "oneof", Message: "must be one of email, webhook"},
+	}, FieldErrors(err))
+
+	assert.Nil(t, FieldErrors(errors.New("record not found")))
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger logs GORM messages and queries to a slog logger. Failed queries
+// are logged as errors, queries slower than SlowThreshold as warnings and
+// every other query at debug level.
+type GormLogger struct {
+	logger        *slog.Logger
+	level         logger.LogLevel
+	SlowThreshold time.Duration
+}
+
+func NewGormLogger(l *slog.Logger, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{logger: l, level: logger.Info, SlowThreshold: slowThreshold}
+}
+
+func (g *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *g
+	copied.level = level
+	return &copied
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= logger.Info {
+		g.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= logger.Warn {
+		g.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= logger.Error {
+		g.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= logger.Error:
+		level, msg = slog.LevelError, "query failed"
+	case g.SlowThreshold > 0 && elapsed > g.SlowThreshold && g.level >= logger.Warn:
+		level, msg = slog.LevelWarn, "slow query"
+	case g.level < logger.Info:
+		return
+	}
+	if !g.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		Milliseconds("duration_ms", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	g.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Transport logs every request sent through it as an outbound call to
+// service, requests carry the request ID of their context in the
+// X-Request-ID header. It logs to slog.Default when Logger is nil.
+type Transport struct {
+	Base    http.RoundTripper
+	Service string
+	Logger  *slog.Logger
+}
+
+func NewTransport(base http.RoundTripper, service string, logger *slog.Logger) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Service: service, Logger: logger}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if id := RequestID(ctx); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(ctx)
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+
+	attrs := []slog.Attr{
+		slog.String("service", t.Service),
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+		Milliseconds("duration_ms", time.Since(start)),
+	}
+	switch {
+	case err != nil:
+		t.logger().LogAttrs(ctx, slog.LevelError, "outbound call failed", append(attrs, slog.String("error", err.Error()))...)
+	case res.StatusCode >= 500:
+		t.logger().LogAttrs(ctx, slog.LevelError, "outbound call", append(attrs, slog.Int("status", res.StatusCode))...)
+	case res.StatusCode >= 400:
+		t.logger().LogAttrs(ctx, slog.LevelWarn, "outbound call", append(attrs, slog.Int("status", res.StatusCode))...)
+	default:
+		t.logger().LogAttrs(ctx, slog.LevelInfo, "outbound call", append(attrs, slog.Int("status", res.StatusCode))...)
+	}
+	return res, err
+}
+
+func (t *Transport) logger() *slog.Logger {
+	if t.Logger == nil {
+		return slog.Default()
+	}
+	return t.Logger
+}
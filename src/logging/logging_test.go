@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	out := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestNew_addsRequestID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger, err := New(buf, slog.LevelInfo, FormatJSON)
+	assert.Nil(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "hello")
+	logger.With("user_id", 7).InfoContext(ctx, "with attrs")
+	logger.Info("no context")
+	logger.DebugContext(ctx, "too verbose")
+
+	logs := records(t, buf)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, "req-1", logs[0]["request_id"])
+	assert.Equal(t, "req-1", logs[1]["request_id"])
+	assert.Equal(t, float64(7), logs[1]["user_id"])
+	assert.Nil(t, logs[2]["request_id"])
+
+	_, err = New(buf, slog.LevelInfo, "xml")
+	assert.NotNil(t, err)
+}
+
+func TestNew_addsTraceID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger, _ := New(buf, slog.LevelInfo, FormatJSON)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.InfoContext(ctx, "hello")
+	logger.Info("no span")
+
+	logs := records(t, buf)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logs[0]["trace_id"])
+	assert.Nil(t, logs[1]["trace_id"])
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	assert.Nil(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.NotNil(t, err)
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger, _ := New(buf, slog.LevelInfo, FormatJSON)
+	gormLogger := NewGormLogger(logger, 100*time.Millisecond)
+	ctx := WithRequestID(context.Background(), "req-1")
+	sql := func() (string, int64) { return `SELECT * FROM "cars"`, 2 }
+
+	gormLogger.Trace(ctx, time.Now(), sql, nil)
+	gormLogger.Trace(ctx, time.Now(), sql, gorm.ErrRecordNotFound)
+	assert.Empty(t, buf.String(), "fast queries are logged at debug level")
+
+	gormLogger.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+	gormLogger.Trace(ctx, time.Now(), sql, errors.New("connection refused"))
+
+	logs := records(t, buf)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "WARN", logs[0]["level"])
+	assert.Equal(t, "slow query", logs[0]["msg"])
+	assert.Equal(t, `SELECT * FROM "cars"`, logs[0]["sql"])
+	assert.Equal(t, "req-1", logs[0]["request_id"])
+	assert.Equal(t, "ERROR", logs[1]["level"])
+	assert.Equal(t, "connection refused", logs[1]["error"])
+}
+
+func TestTransport(t *testing.T) {
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get(RequestIDHeader)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	logger, _ := New(buf, slog.LevelInfo, FormatJSON)
+	client := &http.Client{Transport: NewTransport(nil, "xendit", logger)}
+
+	req, _ := http.NewRequestWithContext(WithRequestID(context.Background(), "req-1"), http.MethodPost, server.URL+"/v2/invoices", nil)
+	res, err := client.Do(req)
+	assert.Nil(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, "req-1", requestID)
+	logs := records(t, buf)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "WARN", logs[0]["level"])
+	assert.Equal(t, "xendit", logs[0]["service"])
+	assert.Equal(t, "/v2/invoices", logs[0]["path"])
+	assert.Equal(t, float64(http.StatusBadRequest), logs[0]["status"])
+	assert.Equal(t, "req-1", logs[0]["request_id"])
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"p2-mini-project/src/mailer"
+	"time"
+)
+
+// Mailer logs every email sent through the wrapped mailer, recipients are
+// logged but subjects and bodies are not. It logs to slog.Default when Logger
+// is nil.
+type Mailer struct {
+	mailer.Mailer
+	Logger *slog.Logger
+}
+
+func NewMailer(m mailer.Mailer, logger *slog.Logger) *Mailer {
+	return &Mailer{Mailer: m, Logger: logger}
+}
+
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	start := time.Now()
+	err := m.Mailer.Send(ctx, msg)
+
+	attrs := []slog.Attr{
+		slog.String("service", "smtp"),
+		slog.String("to", msg.To),
+		slog.Int("attachments", len(msg.Attachments)),
+		Milliseconds("duration_ms", time.Since(start)),
+	}
+	if err != nil {
+		m.logger().LogAttrs(ctx, slog.LevelError, "email failed", append(attrs, slog.String("error", err.Error()))...)
+		return err
+	}
+	m.logger().LogAttrs(ctx, slog.LevelInfo, "email sent", attrs...)
+	return nil
+}
+
+func (m *Mailer) logger() *slog.Logger {
+	if m.Logger == nil {
+		return slog.Default()
+	}
+	return m.Logger
+}
@@ -0,0 +1,88 @@
+// Package mailer sends emails.
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"gopkg.in/gomail.v2"
+)
+
+type Attachment struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+type Message struct {
+	To          string       `json:"to"`
+	Subject     string       `json:"subject"`
+	HTML        string       `json:"html"`
+	Text        string       `json:"text,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type SMTPMailer struct {
+	dialer *gomail.Dialer
+	from   string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{dialer: gomail.NewDialer(host, port, username, password), from: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	gm := gomail.NewMessage()
+	gm.SetHeader("From", m.from)
+	gm.SetHeader("To", msg.To)
+	gm.SetHeader("Subject", msg.Subject)
+	if msg.Text != "" {
+		gm.SetBody("text/plain", msg.Text)
+		gm.AddAlternative("text/html", msg.HTML)
+	} else {
+		gm.SetBody("text/html", msg.HTML)
+	}
+	for _, attachment := range msg.Attachments {
+		data := attachment.Data
+		gm.Attach(attachment.Name, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}))
+	}
+
+	return m.dialer.DialAndSend(gm)
+}
+
+// MemoryMailer keeps sent messages in memory, it is used in development and
+// tests. Sending fails with Err when it is set.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	LanguageEnglish    = "en"
+	LanguageIndonesian = "id"
+	DefaultLanguage    = LanguageEnglish
+)
+
+const (
+	TemplateRegister       = "register"
+	TemplateVerification   = "verification"
+	TemplateRental         = "rental"
+	TemplateTopUp          = "topup"
+	TemplatePayment        = "payment"
+	TemplateReturn         = "return"
+	TemplatePickupReminder = "pickup_reminder"
+	TemplateReturnReminder = "return_reminder"
+	TemplateOverdue        = "overdue"
+	TemplateOverdueAdmin   = "overdue_admin"
+	TemplateCancellation   = "cancellation"
+)
+
+var templateNames = []string{
+	TemplateRegister, TemplateVerification, TemplateRental, TemplateTopUp, TemplatePayment,
+	TemplateReturn, TemplatePickupReminder, TemplateReturnReminder, TemplateOverdue, TemplateOverdueAdmin,
+	TemplateCancellation,
+}
+
+// Every email has templates/<language>/<name>.txt defining the "subject" and
+// the plaintext "text" templates, and templates/<language>/<name>.html
+// defining the "content" of the HTML layout.
+//
+//go:embed templates
+var templateFS embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = mustParseTemplates()
+
+// Data is passed to templates, times are printed in their own location.
+type Data map[string]interface{}
+
+// Render renders the name email in language, unknown languages fall back to
+// DefaultLanguage.
+func Render(name, language string, data Data) (Message, error) {
+	t, ok := templates[language+"/"+name]
+	if !ok {
+		language = DefaultLanguage
+		if t, ok = templates[language+"/"+name]; !ok {
+			return Message{}, fmt.Errorf("mailer: unknown template %q", name)
+		}
+	}
+
+	values := Data{"Lang": language}
+	for k, v := range data {
+		values[k] = v
+	}
+
+	subject, text := new(bytes.Buffer), new(bytes.Buffer)
+	if err := t.text.ExecuteTemplate(subject, "subject", values); err != nil {
+		return Message{}, err
+	}
+	if err := t.text.ExecuteTemplate(text, "text", values); err != nil {
+		return Message{}, err
+	}
+	values["Subject"] = strings.TrimSpace(subject.String())
+
+	html := new(bytes.Buffer)
+	if err := t.html.ExecuteTemplate(html, "layout", values); err != nil {
+		return Message{}, err
+	}
+
+	return Message{Subject: values["Subject"].(string), Text: text.String(), HTML: html.String()}, nil
+}
+
+// IsLanguage reports whether emails can be rendered in language.
+func IsLanguage(language string) bool {
+	return language == LanguageEnglish || language == LanguageIndonesian
+}
+
+func mustParseTemplates() map[string]emailTemplate {
+	parsed := map[string]emailTemplate{}
+	layout := htmltemplate.Must(htmltemplate.New("layout").ParseFS(templateFS, "templates/layout.html"))
+
+	for _, language := range []string{LanguageEnglish, LanguageIndonesian} {
+		funcs := map[string]interface{}{"date": dateFormatter(language)}
+		for _, name := range templateNames {
+			path := "templates/" + language + "/" + name
+			parsed[language+"/"+name] = emailTemplate{
+				text: texttemplate.Must(texttemplate.New(name).Funcs(funcs).ParseFS(templateFS, path+".txt")),
+				html: htmltemplate.Must(htmltemplate.Must(layout.Clone()).Funcs(funcs).ParseFS(templateFS, path+".html")),
+			}
+		}
+	}
+
+	return parsed
+}
+
+var indonesianMonths = strings.NewReplacer(
+	"January", "Januari", "February", "Februari", "March", "Maret", "May", "Mei",
+	"June", "Juni", "July", "Juli", "August", "Agustus", "October", "Oktober", "December", "Desember",
+)
+
+func dateFormatter(language string) func(time.Time) string {
+	return func(t time.Time) string {
+		formatted := t.Format("2 January 2006 15:04 MST")
+		if language == LanguageIndonesian {
+			return indonesianMonths.Replace(formatted)
+		}
+		return formatted
+	}
+}
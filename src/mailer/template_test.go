@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"p2-mini-project/src/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_allTemplates(t *testing.T) {
+	at := time.Date(2024, 8, 17, 9, 30, 0, 0, time.FixedZone("WIB", 7*60*60))
+	data := Data{
+		"Fullname": "Budi <Santoso>", "VerifyURL": "https://example.com/verify?token=a&b", "ExpiresIn": "24h",
+		"RentalID": 7, "CarName": "Avanza", "RentalDate": at, "ReturnDate": at, "ReturnedAt": at,
+		"Total": money.Money(350000), "Amount": money.Money(100000), "LateFee": money.Money(0), "Refund": money.Money(0),
+		"InvoiceURL": "https://invoice", "ReceiptNumber": "RCP-20240817-000001", "OverdueHours": 5, "Customer": "Budi", "CustomerEmail": "budi@mail.com",
+	}
+
+	for _, language := range []string{LanguageEnglish, LanguageIndonesian} {
+		for _, name := range templateNames {
+			msg, err := Render(name, language, data)
+			assert.Nil(t, err, name)
+			assert.NotEmpty(t, msg.Subject, name)
+			assert.NotContains(t, msg.Text, "<no value>", name)
+			assert.NotContains(t, msg.HTML, "<no value>", name)
+		}
+	}
+}
+
+func TestRender_escapingAndLanguage(t *testing.T) {
+	at := time.Date(2024, 8, 17, 9, 30, 0, 0, time.FixedZone("WIB", 7*60*60))
+	data := Data{"Fullname": "Budi <b>", "RentalID": 7, "CarName": "Avanza", "RentalDate": at}
+
+	msg, err := Render(TemplatePickupReminder, LanguageIndonesian, data)
+	assert.Nil(t, err)
+	assert.Equal(t, "Pengingat: ambil Avanza pada 17 Agustus 2024 09:30 WIB", msg.Subject)
+	assert.Contains(t, msg.HTML, `<html lang="id">`)
+	assert.Contains(t, msg.HTML, "Halo Budi &lt;b&gt;,")
+	assert.Contains(t, msg.Text, "Halo Budi <b>,")
+
+	msg, err = Render(TemplatePickupReminder, "fr", data)
+	assert.Nil(t, err)
+	assert.Equal(t, "Reminder: pick up Avanza on 17 August 2024 09:30 WIB", msg.Subject)
+
+	_, err = Render("unknown", LanguageEnglish, data)
+	assert.NotNil(t, err)
+}
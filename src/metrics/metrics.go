@@ -0,0 +1,138 @@
+// Package metrics exposes Prometheus metrics of HTTP requests, outbound
+// calls, the database pool and rental activity.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const namespace = "rental"
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	OutboundDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbound_request_duration_seconds",
+		Help:      "Duration of calls to the payment gateway, mail server and webhooks.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service"})
+
+	OutboundFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_request_failures_total",
+		Help:      "Failed calls to the payment gateway, mail server and webhooks.",
+	}, []string{"service"})
+
+	RentalsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rentals_created_total",
+		Help:      "Rentals created.",
+	})
+
+	PaymentsSettled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_settled_total",
+		Help:      "Rental payments settled.",
+	})
+
+	PaymentAmount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_amount_rupiah_total",
+		Help:      "Rupiah paid for rentals.",
+	})
+
+	TopUps = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "topups_total",
+		Help:      "Deposit top ups.",
+	})
+
+	TopUpAmount = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "topup_amount_rupiah_total",
+		Help:      "Rupiah topped up to deposits.",
+	})
+)
+
+// Register registers the metrics of this package, the pool stats of db and
+// the active rentals gauge with registerer.
+func Register(registerer prometheus.Registerer, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	cs := []prometheus.Collector{
+		HTTPRequestDuration, OutboundDuration, OutboundFailures,
+		RentalsCreated, PaymentsSettled, PaymentAmount, TopUps, TopUpAmount,
+		collectors.NewDBStatsCollector(sqlDB, "postgres"),
+		activeRentals(db),
+	}
+	for _, c := range cs {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeRentals counts the rentals picked up but not returned yet on every
+// scrape. A failed count is reported as NaN.
+func activeRentals(db *gorm.DB) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_rentals",
+		Help:      "Rentals picked up and not returned yet.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var count int64
+		res := db.WithContext(ctx).Table("rentals").
+			Where("returned_at IS NULL AND rental_date <= now()").
+			Count(&count)
+		if res.Error != nil {
+			slog.ErrorContext(ctx, "metrics: failed to count active rentals", "error", res.Error)
+			return math.NaN()
+		}
+		return float64(count)
+	})
+}
+
+// Middleware observes the duration of every request, requests matching no
+// route are grouped under the "unmatched" route.
+func Middleware(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	HTTPRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+}
+
+// ObserveOutbound records a call to service that took since start and failed
+// when err is not nil.
+func ObserveOutbound(service string, start time.Time, err error) {
+	OutboundDuration.WithLabelValues(service).Observe(time.Since(start).Seconds())
+	if err != nil {
+		OutboundFailures.WithLabelValues(service).Inc()
+	}
+}
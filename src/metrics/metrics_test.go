@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/testutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware)
+	r.GET("/cars/:category_id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cars/9", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cars/10", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	assert.Equal(t, uint64(2), sampleCount(t, "GET", "/cars/:category_id", "404"))
+	assert.Equal(t, uint64(1), sampleCount(t, "GET", "unmatched", "404"))
+}
+
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	metric := &io_prometheus_client.Metric{}
+	if err := HTTPRequestDuration.WithLabelValues(labels...).(prometheus.Metric).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestTransport(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, "test-gateway")}
+	failures := OutboundFailures.WithLabelValues("test-gateway")
+
+	res, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, 0.0, promtestutil.ToFloat64(failures))
+
+	status = http.StatusBadGateway
+	res, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(failures))
+}
+
+func TestMailer(t *testing.T) {
+	memory := mailer.NewMemoryMailer()
+	m := NewMailer(memory)
+	failures := OutboundFailures.WithLabelValues("smtp")
+	before := promtestutil.ToFloat64(failures)
+
+	assert.Nil(t, m.Send(context.Background(), mailer.Message{To: "budi@mail.com"}))
+	memory.Err = errors.New("connection refused")
+	assert.NotNil(t, m.Send(context.Background(), mailer.Message{To: "budi@mail.com"}))
+
+	assert.Equal(t, before+1, promtestutil.ToFloat64(failures))
+	assert.Len(t, memory.Messages(), 1)
+}
+
+func TestRegister(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, Register(registry, db))
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "rentals" WHERE returned_at IS NULL AND rental_date <= now\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+
+	names := map[string]float64{}
+	for _, family := range families {
+		if family.GetMetric()[0].GetGauge() != nil {
+			names[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+		} else {
+			names[family.GetName()] = 0
+		}
+	}
+	assert.Equal(t, 3.0, names["rental_active_rentals"])
+	assert.Contains(t, names, "go_sql_open_connections")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
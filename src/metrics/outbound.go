@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/mailer"
+	"time"
+)
+
+// Transport observes every request sent through it as a call to Service,
+// server errors count as failures.
+type Transport struct {
+	Base    http.RoundTripper
+	Service string
+}
+
+func NewTransport(base http.RoundTripper, service string) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Service: service}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+
+	failure := err
+	if err == nil && res.StatusCode >= 500 {
+		failure = fmt.Errorf("status %d", res.StatusCode)
+	}
+	ObserveOutbound(t.Service, start, failure)
+	return res, err
+}
+
+// Mailer observes every email sent through the wrapped mailer as a call to
+// the mail service.
+type Mailer struct {
+	mailer.Mailer
+}
+
+func NewMailer(m mailer.Mailer) *Mailer {
+	return &Mailer{Mailer: m}
+}
+
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	start := time.Now()
+	err := m.Mailer.Send(ctx, msg)
+	ObserveOutbound("smtp", start, err)
+	return err
+}
@@ -3,17 +3,40 @@ package middleware
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/helpers"
 	"p2-mini-project/src/httputil"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware(roles string) gin.HandlerFunc {
+// APIKeyHeader is the header API keys are sent in instead of a token.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyUsedInterval throttles the writes of when an API key was last used.
+const apiKeyUsedInterval = time.Minute
+
+// AuthMiddleware authenticates requests with a token or, for routes with a
+// resource, an API key with the scope of the resource, see
+// helpers.RequiredScope. Routes without a resource only take tokens.
+func AuthMiddleware(db *gorm.DB, roles string, resource string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			if resource == "" {
+				c.Error(httputil.NewCodedError(http.StatusForbidden, httputil.CodeInsufficientScope, "unauthorized", "API keys can't access this api"))
+				c.Abort()
+				return
+			}
+			apiKeyAuth(c, db, roles, resource, key)
+			return
+		}
+
 		tokenString := c.GetHeader("authorization")
 
 		if tokenString == "" {
@@ -39,7 +62,7 @@ func AuthMiddleware(roles string) gin.HandlerFunc {
 		}
 
 		if float64(time.Now().Unix()) > parsedToken.Claims.(jwt.MapClaims)["exp"].(float64) {
-			c.Error(httputil.NewError(http.StatusUnauthorized, "unauthorized", errors.New("token expired")))
+			c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeTokenExpired, "unauthorized", "token expired"))
 			c.Abort()
 			return
 		}
@@ -56,3 +79,55 @@ func AuthMiddleware(roles string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// apiKeyAuth authenticates the request as the user of key, user_id is set
+// like for tokens so handlers don't tell them apart.
+func apiKeyAuth(c *gin.Context, db *gorm.DB, roles string, resource string, key string) {
+	ctx := c.Request.Context()
+	db = db.WithContext(ctx)
+
+	apiKey := entity.APIKey{}
+	if res := db.Joins("User").Where("key_hash = ?", helpers.HashAPIKey(key)).Take(&apiKey); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeAPIKeyInvalid, "unauthorized", "invalid API key"))
+		} else {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "AuthMiddleware: failed to get API key", res.Error))
+		}
+		c.Abort()
+		return
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeAPIKeyRevoked, "unauthorized", "API key revoked"))
+		c.Abort()
+		return
+	}
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		c.Error(httputil.NewCodedError(http.StatusUnauthorized, httputil.CodeAPIKeyExpired, "unauthorized", "API key expired"))
+		c.Abort()
+		return
+	}
+
+	if apiKey.User.Role != "admin" && roles == "admin" {
+		c.Error(httputil.NewError(http.StatusUnauthorized, "unauthorized", errors.New("need admin role to access this api")))
+		c.Abort()
+		return
+	}
+	if scope := helpers.RequiredScope(resource, c.Request.Method); !helpers.HasScope(apiKey.Scopes, scope) {
+		c.Error(httputil.NewCodedError(http.StatusForbidden, httputil.CodeInsufficientScope, "unauthorized", "API key needs the "+scope+" scope"))
+		c.Abort()
+		return
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyUsedInterval {
+		res := db.Model(&entity.APIKey{ID: apiKey.ID}).UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": c.ClientIP()})
+		if res.Error != nil {
+			slog.WarnContext(ctx, "AuthMiddleware: failed to update API key last use", "api_key_id", apiKey.ID, "error", res.Error)
+		}
+	}
+
+	c.Set("user_id", float64(apiKey.UserID))
+
+	c.Next()
+}
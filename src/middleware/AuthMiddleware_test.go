@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/helpers"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/testutil"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func apiKeyRouter(db *gorm.DB, roles string, resource string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware, AuthMiddleware(db, roles, resource))
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetFloat64("user_id")})
+	}
+	r.GET("/cars", handler)
+	r.POST("/cars", handler)
+	return r
+}
+
+func expectAPIKey(mock sqlmock.Sqlmock, role string, scopes string, expiresAt, revokedAt, lastUsedAt interface{}) {
+	mock.ExpectQuery(`SELECT .* FROM "api_keys" LEFT JOIN "users" "User" ON "api_keys"."user_id" = "User"."user_id" WHERE key_hash = \$1`).
+		WithArgs(helpers.HashAPIKey("rk_secret"), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"api_key_id", "user_id", "scopes", "expires_at", "revoked_at", "last_used_at", "User__user_id", "User__role"}).
+			AddRow(3, 7, scopes, expiresAt, revokedAt, lastUsedAt, 7, role))
+}
+
+func sendAPIKey(r *gin.Engine, method string) (*httptest.ResponseRecorder, httputil.Problem) {
+	req := httptest.NewRequest(method, "/cars", nil)
+	req.Header.Set(APIKeyHeader, "rk_secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := httputil.Problem{}
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	return w, problem
+}
+
+func TestAuthMiddleware_apiKey(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	r := apiKeyRouter(db, "user", "cars")
+
+	expectAPIKey(mock, "user", `["cars:write"]`, nil, nil, nil)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys" SET "last_used_at"=\$1,"last_used_ip"=\$2 WHERE "api_key_id" = \$3`).
+		WithArgs(sqlmock.AnyArg(), "192.0.2.1", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	w, _ := sendAPIKey(r, http.MethodGet)
+	assert.Equal(t, http.StatusOK, w.Code, "the write scope grants reads")
+	assert.JSONEq(t, `{"user_id": 7}`, w.Body.String())
+
+	// last use was just recorded
+	expectAPIKey(mock, "user", `["cars:write"]`, nil, nil, time.Now())
+	w, _ = sendAPIKey(r, http.MethodPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthMiddleware_apiKeyRejected(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		expect func()
+		roles  string
+		method string
+		status int
+		code   string
+	}{
+		{"unknown", func() {
+			mock.ExpectQuery(`SELECT .* FROM "api_keys"`).WillReturnRows(sqlmock.NewRows([]string{"api_key_id"}))
+		}, "user", http.MethodGet, http.StatusUnauthorized, httputil.CodeAPIKeyInvalid},
+		{"revoked", func() { expectAPIKey(mock, "user", `["cars:read"]`, nil, past, nil) }, "user", http.MethodGet, http.StatusUnauthorized, httputil.CodeAPIKeyRevoked},
+		{"expired", func() { expectAPIKey(mock, "user", `["cars:read"]`, past, nil, nil) }, "user", http.MethodGet, http.StatusUnauthorized, httputil.CodeAPIKeyExpired},
+		{"read scope writing", func() { expectAPIKey(mock, "user", `["cars:read"]`, nil, nil, nil) }, "user", http.MethodPost, http.StatusForbidden, httputil.CodeInsufficientScope},
+		{"other scope", func() { expectAPIKey(mock, "user", `["branches:read"]`, nil, nil, nil) }, "user", http.MethodGet, http.StatusForbidden, httputil.CodeInsufficientScope},
+		{"user on admin api", func() { expectAPIKey(mock, "user", `["cars:read"]`, nil, nil, nil) }, "admin", http.MethodGet, http.StatusUnauthorized, httputil.CodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expect()
+			w, problem := sendAPIKey(apiKeyRouter(db, tt.roles, "cars"), tt.method)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.code, problem.Code)
+		})
+	}
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthMiddleware_apiKeyNotAccepted(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+
+	w, problem := sendAPIKey(apiKeyRouter(db, "admin", ""), http.MethodGet)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, httputil.CodeInsufficientScope, problem.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,27 +1,50 @@
 package middleware
 
 import (
+	"errors"
+	"log/slog"
+	"net/http"
 	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/logging"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ProblemContentType is the content type of error responses, see RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// ErrorMiddleware responds with the last error of the request as a problem
+// and logs it, server errors are logged as errors and client errors as
+// warnings. The cause of the error is only logged, the request ID lets
+// clients point at the log of a failed request.
 func ErrorMiddleware(c *gin.Context) {
 	c.Next()
 
 	err := c.Errors.Last()
 	if err != nil {
-		switch e := err.Err.(type) {
-		case *httputil.HTTPError:
-			c.JSON(e.Code, gin.H{
-				"message": e.Message,
-				"detail":  e.Detail,
-			})
-		default:
-			c.JSON(500, gin.H{
-				"error": err.Error(),
-			})
+		var e *httputil.HTTPError
+		if !errors.As(err.Err, &e) {
+			e = httputil.NewError(http.StatusInternalServerError, "unhandled error", err.Err)
 		}
-		c.Abort()
+		abortWithProblem(c, e)
 	}
 }
+
+// abortWithProblem logs e and responds with it as a problem.
+func abortWithProblem(c *gin.Context, e *httputil.HTTPError, attrs ...any) {
+	ctx := c.Request.Context()
+
+	level := slog.LevelWarn
+	if e.Status >= 500 {
+		level = slog.LevelError
+	}
+	attrs = append([]any{"status", e.Status, "code", e.Code}, attrs...)
+	if e.Err != nil {
+		attrs = append(attrs, "error", e.Err.Error())
+	}
+	slog.Log(ctx, level, e.Message, attrs...)
+
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(e.Status, e.Problem(c.Request.URL.Path, logging.RequestID(ctx)))
+	c.Abort()
+}
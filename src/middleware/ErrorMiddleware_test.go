@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware)
+	r.POST("/cars/pay/:rental_id", func(c *gin.Context) {
+		c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeInsufficientDeposit, "PayRentalCar: your deposit is not enough", "your deposit is Rp100.000 while total payment is Rp350.000"))
+	})
+	r.POST("/users/topup", func(c *gin.Context) {
+		var body struct {
+			Amount int `json:"amount" binding:"required,min=10000"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Error(httputil.NewError(http.StatusBadRequest, "TopUp: invalid body request", err))
+		}
+	})
+	r.GET("/cars", func(c *gin.Context) {
+		c.Error(httputil.NewError(http.StatusInternalServerError, "GetAllCars: fail to get all cars", errors.New(`pq: relation "cars" does not exist`)))
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		c.Error(errors.New("unexpected"))
+	})
+
+	serve := func(method, path, body string) (*httptest.ResponseRecorder, httputil.Problem) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		problem := httputil.Problem{}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		return w, problem
+	}
+
+	w, problem := serve(http.MethodPost, "/cars/pay/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, httputil.Problem{
+		Type:     "/problems/insufficient-deposit",
+		Title:    "Insufficient deposit",
+		Status:   http.StatusBadRequest,
+		Detail:   "your deposit is Rp100.000 while total payment is Rp350.000",
+		Instance: "/cars/pay/1",
+		Code:     httputil.CodeInsufficientDeposit,
+	}, problem)
+
+	w, problem = serve(http.MethodPost, "/users/topup", `{"amount": 500}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, httputil.CodeValidationFailed, problem.Code)
+	assert.Equal(t, "invalid body request", problem.Detail)
+	assert.Equal(t, []httputil.FieldError{{Field: "amount", Rule: "min", Message: "must be at least 10000"}}, problem.Errors)
+
+	w, problem = serve(http.MethodPost, "/users/topup", `{"amount": "a lot"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, []httputil.FieldError{{Field: "amount", Rule: "type", Message: "must be a number"}}, problem.Errors)
+
+	// internal details are logged but not returned
+	w, problem = serve(http.MethodGet, "/cars", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, httputil.CodeInternal, problem.Code)
+	assert.Empty(t, problem.Detail)
+	assert.NotContains(t, w.Body.String(), "pq:")
+
+	w, problem = serve(http.MethodGet, "/panic", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, httputil.CodeInternal, problem.Code)
+	assert.NotContains(t, w.Body.String(), "unexpected")
+}
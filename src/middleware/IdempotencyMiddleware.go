@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/httputil"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+)
+
+// responseRecorder keeps a copy of the response body written by the handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes requests sent with an Idempotency-Key header
+// safe to retry. The first request with a key runs the handler and stores its
+// successful response, repeats of the same request within ttl get the stored
+// response and a different request with the same key is rejected with 422.
+// Failed requests release the key so they can be retried, a key left
+// processing by a crash is released once lease has passed. It has to run
+// after AuthMiddleware, keys are scoped by user.
+func IdempotencyMiddleware(db *gorm.DB, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.Error(httputil.NewCodedError(http.StatusBadRequest, httputil.CodeIdempotencyKeyInvalid, "invalid Idempotency-Key", "Idempotency-Key is longer than 255 characters"))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(httputil.NewError(http.StatusBadRequest, "invalid body request", err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		user_id := int(c.GetFloat64("user_id"))
+		hash := requestHash(c.Request, body)
+
+		token, err := claimToken()
+		if err != nil {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "failed to check Idempotency-Key", err))
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		record, err := claimIdempotencyKey(db, user_id, key, hash, token, now.Add(-ttl), now.Add(-lease))
+		if err != nil {
+			c.Error(httputil.NewError(http.StatusInternalServerError, "failed to check Idempotency-Key", err))
+			c.Abort()
+			return
+		}
+		if record != nil {
+			switch {
+			case record.RequestHash != hash:
+				c.Error(httputil.NewCodedError(http.StatusUnprocessableEntity, httputil.CodeIdempotencyKeyReused, "Idempotency-Key already used", "Idempotency-Key was used for a different request"))
+				c.Abort()
+			case record.Status != idempotencyCompleted:
+				c.Error(httputil.NewCodedError(http.StatusConflict, httputil.CodeIdempotencyKeyInUse, "Idempotency-Key in use", "a request with this Idempotency-Key is still processing"))
+				c.Abort()
+			default:
+				c.Header(IdempotencyReplayedHeader, "true")
+				c.Data(record.ResponseCode, record.ContentType, record.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// the key is released unless the response is stored, this runs on
+		// panics too. Once the lease has passed the key may be claimed again
+		// by a retry, only this claim is stored or released.
+		own := db.Where("user_id = ? AND key = ? AND request_hash = ? AND token = ?", user_id, key, hash, token)
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if res := own.Session(&gorm.Session{}).Delete(&entity.IdempotencyKey{}); res.Error != nil {
+				slog.ErrorContext(c.Request.Context(), "idempotency: failed to release key", "key", key, "user_id", user_id, "error", res.Error)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if len(c.Errors) == 0 && status >= 200 && status < 300 {
+			res := own.Session(&gorm.Session{}).Model(&entity.IdempotencyKey{}).Updates(map[string]interface{}{
+				"status":        idempotencyCompleted,
+				"response_code": status,
+				"content_type":  recorder.Header().Get("Content-Type"),
+				"response_body": recorder.body.Bytes(),
+			})
+			if res.Error != nil {
+				slog.ErrorContext(c.Request.Context(), "idempotency: failed to store response", "key", key, "user_id", user_id, "error", res.Error)
+				return
+			}
+			stored = true
+		}
+	}
+}
+
+// claimIdempotencyKey stores key as processing under token and returns nil,
+// or returns the stored key when it was already used after expiredBefore. A
+// key still processing since before leaseExpiredBefore is claimed again.
+func claimIdempotencyKey(db *gorm.DB, user_id int, key, hash, token string, expiredBefore, leaseExpiredBefore time.Time) (*entity.IdempotencyKey, error) {
+	record := &entity.IdempotencyKey{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND key = ?", user_id, key).
+			Where("created_at < ? OR (status = ? AND created_at < ?)", expiredBefore, idempotencyProcessing, leaseExpiredBefore).
+			Delete(&entity.IdempotencyKey{})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.IdempotencyKey{UserID: user_id, Key: key, RequestHash: hash, Token: token, Status: idempotencyProcessing})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			record = nil
+			return nil
+		}
+
+		return tx.Where("user_id = ? AND key = ?", user_id, key).First(record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// claimToken returns a random token for a claim of a key.
+func claimToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// requestHash identifies a request by its method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
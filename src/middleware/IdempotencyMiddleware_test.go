@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/testutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func idempotentRouter(db *gorm.DB, calls *int) *gin.Engine {
+	return idempotentRouterWith(db, func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusOK, gin.H{"message": "success top up"})
+	})
+}
+
+func idempotentRouterWith(db *gorm.DB, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RecoveryMiddleware, ErrorMiddleware)
+	r.POST("/users/topup", func(c *gin.Context) {
+		c.Set("user_id", float64(1))
+	}, IdempotencyMiddleware(db, time.Hour, time.Minute), handler)
+	return r
+}
+
+func topUp(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// claimArg matches the claim token of a key, the first match stores the
+// token and later ones must be equal to it.
+type claimArg struct {
+	token *string
+}
+
+func (a claimArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok || len(s) != 32 {
+		return false
+	}
+	if *a.token == "" {
+		*a.token = s
+	}
+	return s == *a.token
+}
+
+func expectClaim(mock sqlmock.Sqlmock, claimed bool) *string {
+	token := new(string)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE \(user_id = \$1 AND key = \$2\) AND \(created_at < \$3 OR \(status = \$4 AND created_at < \$5\)\)`).
+		WithArgs(1, "key-1", sqlmock.AnyArg(), idempotencyProcessing, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"idempotency_key_id"})
+	if claimed {
+		rows.AddRow(1)
+	}
+	mock.ExpectQuery(`INSERT INTO "idempotency_keys" .* ON CONFLICT DO NOTHING`).
+		WithArgs(1, "key-1", sqlmock.AnyArg(), claimArg{token}, idempotencyProcessing, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(rows)
+	return token
+}
+
+func expectStore(mock sqlmock.Sqlmock, hash string, token *string) *sqlmock.ExpectedExec {
+	mock.ExpectBegin()
+	return mock.ExpectExec(`UPDATE "idempotency_keys" SET .* WHERE user_id = \$5 AND key = \$6 AND request_hash = \$7 AND token = \$8`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, "key-1", hash, claimArg{token})
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	calls := 0
+	r := idempotentRouter(db, &calls)
+	body := `{"amount":100000}`
+
+	// first request runs the handler and stores the response
+	hash := requestHash(httptest.NewRequest(http.MethodPost, "/users/topup", nil), []byte(body))
+	token := expectClaim(mock, true)
+	mock.ExpectCommit()
+	expectStore(mock, hash, token).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	w := topUp(r, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, calls)
+
+	// a retry gets the stored response
+	expectClaim(mock, false)
+	mock.ExpectQuery(`SELECT \* FROM "idempotency_keys" WHERE user_id = \$1 AND key = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"idempotency_key_id", "user_id", "key", "request_hash", "status", "response_code", "content_type", "response_body"}).
+			AddRow(1, 1, "key-1", hash, idempotencyCompleted, http.StatusOK, "application/json; charset=utf-8", []byte(`{"message":"success top up"}`)))
+	mock.ExpectCommit()
+
+	w = topUp(r, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get(IdempotencyReplayedHeader))
+	assert.JSONEq(t, `{"message":"success top up"}`, w.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// the same key with another body is rejected
+	expectClaim(mock, false)
+	mock.ExpectQuery(`SELECT \* FROM "idempotency_keys" WHERE user_id = \$1 AND key = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"idempotency_key_id", "user_id", "key", "request_hash", "status"}).
+			AddRow(1, 1, "key-1", hash, idempotencyCompleted))
+	mock.ExpectCommit()
+
+	w = topUp(r, `{"amount":200000}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, calls)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func expectRelease(mock sqlmock.Sqlmock, hash string, token *string, released bool) {
+	mock.ExpectBegin()
+	rows := int64(0)
+	if released {
+		rows = 1
+	}
+	mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE user_id = \$1 AND key = \$2 AND request_hash = \$3 AND token = \$4`).
+		WithArgs(1, "key-1", hash, claimArg{token}).
+		WillReturnResult(sqlmock.NewResult(0, rows))
+	mock.ExpectCommit()
+}
+
+func TestIdempotencyMiddleware_releasesKey(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	fail := true
+	r := idempotentRouterWith(db, func(c *gin.Context) {
+		if fail {
+			panic("handler crashed")
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success top up"})
+	})
+
+	// a panicking handler releases the key
+	hash := requestHash(httptest.NewRequest(http.MethodPost, "/users/topup", nil), []byte(`{"amount":100000}`))
+	token := expectClaim(mock, true)
+	mock.ExpectCommit()
+	expectRelease(mock, hash, token, true)
+
+	w := topUp(r, `{"amount":100000}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// so does a response that can't be stored, the retry runs the handler
+	fail = false
+	token = expectClaim(mock, true)
+	mock.ExpectCommit()
+	expectStore(mock, hash, token).WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
+	expectRelease(mock, hash, token, true)
+
+	w = topUp(r, `{"amount":100000}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyMiddleware_keepsReclaimedKey(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	body := `{"amount":100000}`
+	var r *gin.Engine
+	retried := false
+	r = idempotentRouterWith(db, func(c *gin.Context) {
+		if !retried {
+			// the lease passes while the request is slow, a retry claims
+			// the key again and succeeds before the request fails
+			retried = true
+			topUp(r, body)
+			c.JSON(http.StatusBadGateway, gin.H{"message": "payment gateway timeout"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success top up"})
+	})
+
+	hash := requestHash(httptest.NewRequest(http.MethodPost, "/users/topup", nil), []byte(body))
+	stale := expectClaim(mock, true)
+	mock.ExpectCommit()
+	retry := expectClaim(mock, true)
+	mock.ExpectCommit()
+	expectStore(mock, hash, retry).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// the failed request only releases its own claim, the stored response
+	// of the retry is kept
+	expectRelease(mock, hash, stale, false)
+
+	w := topUp(r, body)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.NotEqual(t, *stale, *retry)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"p2-mini-project/src/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware reuses the X-Request-ID header of the request or
+// generates a new ID, returns it in the response header and stores it in the
+// request context so every log of the request carries it.
+func RequestIDMiddleware(c *gin.Context) {
+	id := c.GetHeader(logging.RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+
+	c.Set("request_id", id)
+	c.Header(logging.RequestIDHeader, id)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+	c.Next()
+}
+
+// LoggerMiddleware logs every request once it is handled, server errors are
+// logged as errors and client errors as warnings.
+func LoggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			logging.Milliseconds("duration_ms", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if _, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Int("user_id", int(c.GetFloat64("user_id"))))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
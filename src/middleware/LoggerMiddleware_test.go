@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/logging"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger, _ := logging.New(buf, slog.LevelInfo, logging.FormatJSON)
+	defaultLogger := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware, LoggerMiddleware(logger), ErrorMiddleware)
+	r.GET("/cars/:category_id", func(c *gin.Context) {
+		c.Error(httputil.NewError(http.StatusNotFound, "GetAllCarsByCategory: category not found", errors.New("record not found")))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/cars/9", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "req-1", w.Header().Get(logging.RequestIDHeader))
+	body := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "req-1", body["request_id"])
+
+	logs := []map[string]interface{}{}
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		record := map[string]interface{}{}
+		assert.Nil(t, decoder.Decode(&record))
+		logs = append(logs, record)
+	}
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "GetAllCarsByCategory: category not found", logs[0]["msg"])
+	assert.Equal(t, "record not found", logs[0]["error"])
+	assert.Equal(t, "request", logs[1]["msg"])
+	assert.Equal(t, "/cars/:category_id", logs[1]["route"])
+	assert.Equal(t, float64(http.StatusNotFound), logs[1]["status"])
+	for _, record := range logs {
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, "req-1", record["request_id"])
+	}
+
+	// invalid request IDs are replaced
+	req = httptest.NewRequest(http.MethodGet, "/cars/9", nil)
+	req.Header.Set(logging.RequestIDHeader, "bad id")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Len(t, w.Header().Get(logging.RequestIDHeader), 32)
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware limits the requests of every client IP with limiter,
+// onLimited, when set, runs for every rejected request before it is answered
+// with 429. Requests are let through when the limiter store fails.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, onLimited gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, wait, err := limiter.Allow(c.Request.Context(), c.ClientIP())
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "RateLimitMiddleware: failed to check rate limit", "error", err)
+		} else if !ok {
+			if onLimited != nil {
+				onLimited(c)
+			}
+			c.Header("Retry-After", httputil.RetryAfter(wait))
+			c.Error(httputil.NewCodedError(http.StatusTooManyRequests, httputil.CodeTooManyRequests, "RateLimitMiddleware: too many requests", "too many requests, try again later"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
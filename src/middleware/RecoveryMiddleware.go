@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"p2-mini-project/src/httputil"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware recovers from panics of the next handlers, the panic is
+// logged with its stack and the client gets an internal error problem. A
+// panic caused by a client that hung up is only logged.
+func RecoveryMiddleware(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		err, ok := recovered.(error)
+		if !ok {
+			err = fmt.Errorf("%v", recovered)
+		}
+		if brokenPipe(err) {
+			slog.WarnContext(c.Request.Context(), "RecoveryMiddleware: client disconnected", "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		e := httputil.NewError(http.StatusInternalServerError, "RecoveryMiddleware: panic recovered", err)
+		abortWithProblem(c, e, "stack", string(debug.Stack()))
+	}()
+
+	c.Next()
+}
+
+func brokenPipe(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if errors.As(opErr, &syscallErr) {
+		return errors.Is(syscallErr, syscall.EPIPE) || errors.Is(syscallErr, syscall.ECONNRESET)
+	}
+	message := strings.ToLower(opErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
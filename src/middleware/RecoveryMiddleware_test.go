@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"p2-mini-project/src/logging"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware, RecoveryMiddleware, ErrorMiddleware)
+	r.GET("/cars/:car_id", func(c *gin.Context) {
+		var car map[string]string
+		car["name"] = "avanza"
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/cars/1", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	problem := httputil.Problem{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, httputil.CodeInternal, problem.Code)
+	assert.Equal(t, "req-1", problem.RequestID)
+	assert.NotContains(t, w.Body.String(), "nil map")
+}
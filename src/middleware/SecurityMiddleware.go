@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"p2-mini-project/src/httputil"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecureHeadersMiddleware sets headers that keep browsers from sniffing,
+// framing or leaking API responses. The content security policy is not set
+// for the swagger UI, which runs inline scripts. HSTS is only sent when
+// hstsMaxAge is set, the API must then only be served over HTTPS.
+func SecureHeadersMiddleware(contentSecurityPolicy string, hstsMaxAge time.Duration) gin.HandlerFunc {
+	hsts := ""
+	if hstsMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds())) + "; includeSubDomains"
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if contentSecurityPolicy != "" && !strings.HasPrefix(c.Request.URL.Path, "/swagger/") {
+			header.Set("Content-Security-Policy", contentSecurityPolicy)
+		}
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		c.Next()
+	}
+}
+
+// BodyLimitMiddleware limits request bodies to maxBytes, reading past it
+// fails with http.MaxBytesError. Bodies declaring a larger Content-Length are
+// rejected right away. The routes in ownLimit, e.g. image uploads, are limited
+// by their handlers.
+func BodyLimitMiddleware(maxBytes int64, ownLimit ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil || slices.Contains(ownLimit, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.Error(httputil.NewError(http.StatusRequestEntityTooLarge, "BodyLimitMiddleware: body too large", &http.MaxBytesError{Limit: maxBytes}))
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/httputil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureHeadersMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(SecureHeadersMiddleware("default-src 'none'", 365*24*time.Hour))
+	r.GET("/api/v1/cars", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/swagger/index.html", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/cars", nil))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'none'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware, BodyLimitMiddleware(16, "/admin/cars/:car_id/images"))
+	bind := func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Error(httputil.NewError(http.StatusBadRequest, "TopUp: invalid body request", err))
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r.POST("/users/topup", bind)
+	r.POST("/admin/cars/:car_id/images", bind)
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, httputil.Problem) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		problem := httputil.Problem{}
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		return w, problem
+	}
+
+	w, _ := serve(httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(`{"amount": 1}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, problem := serve(httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(`{"amount": 100000000}`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, httputil.CodePayloadTooLarge, problem.Code)
+
+	// chunked bodies without a Content-Length fail while reading
+	req := httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(`{"amount": 100000000}`))
+	req.ContentLength = -1
+	w, problem = serve(req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "request body is larger than 16 bytes", problem.Detail)
+
+	// only the upload route is limited by its handler, a multipart content
+	// type doesn't lift the limit elsewhere
+	w, _ = serve(httptest.NewRequest(http.MethodPost, "/admin/cars/1/images", strings.NewReader(`{"amount": 100000000}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/users/topup", strings.NewReader(`{"amount": 100000000}`))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	w, _ = serve(req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
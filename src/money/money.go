@@ -0,0 +1,78 @@
+// Package money represents rupiah amounts as a whole number of rupiah so
+// discounts and balances never accumulate floating point errors.
+package money
+
+import (
+	"math"
+	"strconv"
+)
+
+// Money is an amount of rupiah, it is stored as a bigint column and encoded
+// as a JSON integer.
+type Money int64
+
+// FromFloat converts a float amount rounding half away from zero, it is only
+// meant for values coming from outside such as existing float columns.
+func FromFloat(amount float64) Money {
+	return Money(math.Round(amount))
+}
+
+func (m Money) Int64() int64 {
+	return int64(m)
+}
+
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+// Div divides m by n rounding half away from zero.
+func (m Money) Div(n int64) Money {
+	return Money(divRound(int64(m), n))
+}
+
+// Percent returns percent of m rounded half away from zero. The percentage is
+// applied as basis points, so 12.5% is exact.
+func (m Money) Percent(percent float64) Money {
+	basisPoints := int64(math.Round(percent * 100))
+	return Money(divRound(int64(m)*basisPoints, 10000))
+}
+
+// String formats m the Indonesian way, e.g. "Rp 1.234.567".
+func (m Money) String() string {
+	digits := strconv.FormatInt(int64(m), 10)
+	sign := ""
+	if m < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	formatted := []byte{}
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			formatted = append(formatted, '.')
+		}
+		formatted = append(formatted, digits[i])
+	}
+
+	return sign + "Rp " + string(formatted)
+}
+
+func divRound(a, b int64) int64 {
+	q, r := a/b, a%b
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= abs(b) {
+		if (a < 0) != (b < 0) {
+			return q - 1
+		}
+		return q + 1
+	}
+	return q
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
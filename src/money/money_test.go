@@ -0,0 +1,41 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercent(t *testing.T) {
+	assert.Equal(t, Money(30000), Money(100000).Percent(30))
+	assert.Equal(t, Money(-30000), Money(-100000).Percent(30))
+	assert.Equal(t, Money(12500), Money(100000).Percent(12.5))
+	// percentages are applied in basis points, 33.333% is 33.33%
+	assert.Equal(t, Money(33330), Money(99999).Percent(33.333))
+	// 11% of 12345 is 1357.95
+	assert.Equal(t, Money(1358), Money(12345).Percent(11))
+	// 10% of 5 is 0.5, rounded away from zero
+	assert.Equal(t, Money(1), Money(5).Percent(10))
+	assert.Equal(t, Money(-1), Money(5).Percent(-10))
+}
+
+func TestDiv(t *testing.T) {
+	assert.Equal(t, Money(16667), Money(100000).Div(6))
+	assert.Equal(t, Money(50000), Money(300000).Div(6))
+	assert.Equal(t, Money(-16667), Money(-100000).Div(6))
+	assert.Equal(t, Money(2), Money(3).Div(2))
+}
+
+func TestFromFloat(t *testing.T) {
+	assert.Equal(t, Money(100000), FromFloat(99999.99999))
+	assert.Equal(t, Money(70000), FromFloat(100000*0.7))
+	assert.Equal(t, Money(-3), FromFloat(-2.5))
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "Rp 0", Money(0).String())
+	assert.Equal(t, "Rp 999", Money(999).String())
+	assert.Equal(t, "Rp 1.000", Money(1000).String())
+	assert.Equal(t, "Rp 1.234.567", Money(1234567).String())
+	assert.Equal(t, "-Rp 250.000", Money(-250000).String())
+}
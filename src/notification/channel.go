@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/outbox"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ChannelEmail   = "email"
+	ChannelInApp   = "in_app"
+	ChannelWebhook = "webhook"
+)
+
+const KindWebhook = "webhook"
+
+// EmailChannel sends the notification email through the outbox.
+type EmailChannel struct{}
+
+func (EmailChannel) Name() string { return ChannelEmail }
+
+func (EmailChannel) Send(tx *gorm.DB, n Notification) error {
+	return outbox.EnqueueEmail(tx, n.Message)
+}
+
+// InAppChannel stores the notification in the user inbox.
+type InAppChannel struct{}
+
+func (InAppChannel) Name() string { return ChannelInApp }
+
+func (InAppChannel) Send(tx *gorm.DB, n Notification) error {
+	return tx.Create(&entity.Notification{
+		UserID: n.User.ID,
+		Event:  n.Event,
+		Title:  n.Message.Subject,
+		Body:   n.Message.Text,
+	}).Error
+}
+
+// WebhookChannel posts the notification to the webhook URL of the user, users
+// without one are skipped.
+type WebhookChannel struct{}
+
+func (WebhookChannel) Name() string { return ChannelWebhook }
+
+// Webhook is the payload posted to user webhooks.
+type Webhook struct {
+	URL       string                 `json:"-"`
+	Event     string                 `json:"event"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body"`
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// webhookMessage keeps the URL next to the payload in the outbox.
+type webhookMessage struct {
+	URL     string  `json:"url"`
+	Webhook Webhook `json:"webhook"`
+}
+
+func (WebhookChannel) Send(tx *gorm.DB, n Notification) error {
+	if n.User.WebhookURL == "" {
+		return nil
+	}
+	return outbox.Enqueue(tx, KindWebhook, webhookMessage{
+		URL: n.User.WebhookURL,
+		Webhook: Webhook{
+			Event:     n.Event,
+			Title:     n.Message.Subject,
+			Body:      n.Message.Text,
+			Data:      n.Data,
+			CreatedAt: time.Now(),
+		},
+	})
+}
+
+// WebhookHandler posts webhook messages with client, any response other than
+// 2xx is retried by the outbox.
+func WebhookHandler(client *http.Client) outbox.Handler {
+	return func(ctx context.Context, payload []byte) error {
+		msg := webhookMessage{}
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		body, err := json.Marshal(msg.Webhook)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded %s", res.Status)
+		}
+		return nil
+	}
+}
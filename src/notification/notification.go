@@ -0,0 +1,124 @@
+// Package notification sends notifications about rental events to users on
+// every channel they haven't turned off. Channels are pluggable, a new one
+// such as SMS only has to implement Channel and be passed to NewNotifier.
+package notification
+
+import (
+	"fmt"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/mailer"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EventRentalCreated    = "rental_created"
+	EventPaymentSucceeded = "payment_succeeded"
+	EventTopUpSucceeded   = "topup_succeeded"
+	EventPickupReminder   = "pickup_reminder"
+	EventReturnReminder   = "return_reminder"
+	EventOverdue          = "overdue"
+	EventOverdueAlert     = "overdue_alert"
+	EventRentalReturned   = "rental_returned"
+)
+
+// eventTemplates is the mailer template rendering each event, in-app
+// notifications use its subject and plaintext body.
+var eventTemplates = map[string]string{
+	EventRentalCreated:    mailer.TemplateRental,
+	EventPaymentSucceeded: mailer.TemplatePayment,
+	EventTopUpSucceeded:   mailer.TemplateTopUp,
+	EventPickupReminder:   mailer.TemplatePickupReminder,
+	EventReturnReminder:   mailer.TemplateReturnReminder,
+	EventOverdue:          mailer.TemplateOverdue,
+	EventOverdueAlert:     mailer.TemplateOverdueAdmin,
+	EventRentalReturned:   mailer.TemplateReturn,
+}
+
+// Events lists every event type users can set preferences for.
+var Events = []string{
+	EventRentalCreated, EventPaymentSucceeded, EventTopUpSucceeded, EventPickupReminder,
+	EventReturnReminder, EventOverdue, EventOverdueAlert, EventRentalReturned,
+}
+
+func IsEvent(event string) bool {
+	_, ok := eventTemplates[event]
+	return ok
+}
+
+// Notification is an event rendered for one user.
+type Notification struct {
+	Event string
+	User  *entity.User
+	// Message is rendered in the user language, To is the user email.
+	Message mailer.Message
+	Data    mailer.Data
+}
+
+// Channel delivers notifications. Send is called inside the transaction of
+// the event, channels talking to the outside world should write to the
+// outbox instead of blocking it.
+type Channel interface {
+	Name() string
+	Send(tx *gorm.DB, n Notification) error
+}
+
+type Notifier struct {
+	channels []Channel
+}
+
+func NewNotifier(channels ...Channel) *Notifier {
+	return &Notifier{channels: channels}
+}
+
+// Channels returns the names of the channels, in the order they are sent.
+func (n *Notifier) Channels() []string {
+	names := make([]string, 0, len(n.channels))
+	for _, ch := range n.channels {
+		names = append(names, ch.Name())
+	}
+	return names
+}
+
+// Notify sends event to user on every channel enabled in the user
+// preferences, channels without a preference are enabled.
+func (n *Notifier) Notify(tx *gorm.DB, event string, user *entity.User, data mailer.Data, attachments ...mailer.Attachment) error {
+	template, ok := eventTemplates[event]
+	if !ok {
+		return fmt.Errorf("notification: unknown event %q", event)
+	}
+
+	disabled := []string{}
+	if res := tx.Model(&entity.NotificationPreference{}).Where("user_id = ? AND event = ? AND enabled = ?", user.ID, event, false).Pluck("channel", &disabled); res.Error != nil {
+		return res.Error
+	}
+
+	if _, ok := data["Fullname"]; !ok {
+		data["Fullname"] = user.Fullname
+	}
+	msg, err := mailer.Render(template, user.Language, data)
+	if err != nil {
+		return err
+	}
+	msg.To = user.Email
+	msg.Attachments = attachments
+
+	for _, ch := range n.channels {
+		if contains(disabled, ch.Name()) {
+			continue
+		}
+		if err := ch.Send(tx, Notification{Event: event, User: user, Message: msg, Data: data}); err != nil {
+			return fmt.Errorf("notification: %s: %w", ch.Name(), err)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
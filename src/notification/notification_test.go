@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/money"
+	"p2-mini-project/src/testutil"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type recordChannel struct {
+	name string
+	sent []Notification
+}
+
+func (r *recordChannel) Name() string { return r.name }
+
+func (r *recordChannel) Send(tx *gorm.DB, n Notification) error {
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func TestNotify_preferences(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	email, inApp := &recordChannel{name: ChannelEmail}, &recordChannel{name: ChannelInApp}
+	n := NewNotifier(email, inApp)
+
+	mock.ExpectQuery(`SELECT "channel" FROM "notification_preferences" WHERE user_id = \$1 AND event = \$2 AND enabled = \$3`).
+		WithArgs(1, EventRentalReturned, false).
+		WillReturnRows(sqlmock.NewRows([]string{"channel"}).AddRow(ChannelEmail))
+
+	user := &entity.User{ID: 1, Fullname: "Budi", Email: "budi@mail.com", Language: mailer.LanguageIndonesian}
+	err := n.Notify(db, EventRentalReturned, user, mailer.Data{"RentalID": 7, "CarName": "Avanza", "ReturnedAt": time.Date(2024, 4, 20, 12, 0, 0, 0, time.UTC), "LateFee": money.Money(0)})
+
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Empty(t, email.sent)
+	assert.Len(t, inApp.sent, 1)
+	assert.Equal(t, "budi@mail.com", inApp.sent[0].Message.To)
+	assert.Contains(t, inApp.sent[0].Message.Subject, "Avanza")
+	assert.Contains(t, inApp.sent[0].Message.Text, "Halo Budi")
+}
+
+func TestNotify_unknownEvent(t *testing.T) {
+	db, _ := testutil.DbMock(t)
+	n := NewNotifier(&recordChannel{name: ChannelEmail})
+
+	assert.NotNil(t, n.Notify(db, "unknown", &entity.User{ID: 1}, mailer.Data{}))
+	assert.Equal(t, []string{ChannelEmail}, n.Channels())
+}
+
+func TestWebhookHandler(t *testing.T) {
+	received := Webhook{}
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	payload, _ := json.Marshal(webhookMessage{URL: server.URL, Webhook: Webhook{Event: EventOverdue, Title: "Rental #7 is overdue"}})
+	handler := WebhookHandler(server.Client())
+
+	assert.Nil(t, handler(context.Background(), payload))
+	assert.Equal(t, EventOverdue, received.Event)
+	assert.Equal(t, "Rental #7 is overdue", received.Title)
+
+	status = http.StatusInternalServerError
+	assert.NotNil(t, handler(context.Background(), payload))
+}
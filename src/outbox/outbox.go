@@ -0,0 +1,57 @@
+// Package outbox delivers side effects such as emails after the transaction
+// that caused them commits. Messages are written with Enqueue in the same
+// transaction as the business change and delivered by a Worker, failed
+// deliveries are retried with exponential backoff and dead-lettered after
+// MaxAttempts.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/mailer"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+const KindEmail = "email"
+
+// Handler delivers the payload of a message, an error schedules a retry.
+type Handler func(ctx context.Context, payload []byte) error
+
+// Enqueue stores a message of kind to be delivered once tx commits.
+func Enqueue(tx *gorm.DB, kind string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&entity.OutboxMessage{
+		Kind:          kind,
+		Payload:       data,
+		Status:        StatusPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+func EnqueueEmail(tx *gorm.DB, msg mailer.Message) error {
+	return Enqueue(tx, KindEmail, msg)
+}
+
+// EmailHandler sends email messages with m.
+func EmailHandler(m mailer.Mailer) Handler {
+	return func(ctx context.Context, payload []byte) error {
+		msg := mailer.Message{}
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		return m.Send(ctx, msg)
+	}
+}
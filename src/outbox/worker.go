@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"p2-mini-project/src/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Backoff doubles the delay after every failed attempt starting from Base,
+// the delay never exceeds Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the delay before the next attempt after attempt failed
+// attempts.
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(b.Base) * math.Pow(2, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(delay)
+}
+
+type Worker struct {
+	db           *gorm.DB
+	handlers     map[string]Handler
+	Backoff      Backoff
+	MaxAttempts  int
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+func NewWorker(db *gorm.DB, backoff Backoff, maxAttempts int, pollInterval time.Duration, batchSize int) *Worker {
+	return &Worker{
+		db:           db,
+		handlers:     map[string]Handler{},
+		Backoff:      backoff,
+		MaxAttempts:  maxAttempts,
+		PollInterval: pollInterval,
+		BatchSize:    batchSize,
+	}
+}
+
+func (w *Worker) Handle(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+// Run delivers due messages every PollInterval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := w.ProcessBatch(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "outbox: failed to process messages", "error", err)
+			}
+			if err != nil || n < w.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch delivers up to BatchSize due messages and returns how many were
+// processed. Rows are locked with SKIP LOCKED so several workers can run.
+func (w *Worker) ProcessBatch(ctx context.Context) (int, error) {
+	processed := 0
+
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		messages := []entity.OutboxMessage{}
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+			Order("outbox_message_id").Limit(w.BatchSize).Find(&messages)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		for i := range messages {
+			if err := w.deliver(ctx, tx, &messages[i]); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	})
+
+	return processed, err
+}
+
+func (w *Worker) deliver(ctx context.Context, tx *gorm.DB, msg *entity.OutboxMessage) error {
+	err := errors.New("no handler for kind " + msg.Kind)
+	if handler, ok := w.handlers[msg.Kind]; ok {
+		err = handler(ctx, msg.Payload)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": msg.Attempts + 1}
+	switch {
+	case err == nil:
+		updates["status"], updates["sent_at"], updates["last_error"] = StatusSent, now, ""
+	case msg.Attempts+1 >= w.MaxAttempts:
+		updates["status"], updates["last_error"] = StatusDead, err.Error()
+		slog.ErrorContext(ctx, "outbox: message dead", "outbox_message_id", msg.ID, "kind", msg.Kind, "attempts", msg.Attempts+1, "error", err)
+	default:
+		updates["next_attempt_at"], updates["last_error"] = now.Add(w.Backoff.Delay(msg.Attempts+1)), err.Error()
+	}
+
+	if res := tx.Model(msg).Updates(updates); res.Error != nil {
+		return fmt.Errorf("update message %d: %w", msg.ID, res.Error)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/testutil"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func messageRows(attempts int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"outbox_message_id", "kind", "payload", "status", "attempts"}).
+		AddRow(1, KindEmail, []byte(`{"to":"budi@mail.com","subject":"Payment success","html":"paid"}`), StatusPending, attempts)
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Base: 30 * time.Second, Max: 10 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, b.Delay(1))
+	assert.Equal(t, 60*time.Second, b.Delay(2))
+	assert.Equal(t, 4*time.Minute, b.Delay(4))
+	assert.Equal(t, 10*time.Minute, b.Delay(6))
+	assert.Equal(t, 10*time.Minute, b.Delay(100))
+}
+
+func TestProcessBatch_sent(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	m := mailer.NewMemoryMailer()
+	w := NewWorker(db, Backoff{Base: time.Second}, 3, time.Second, 10)
+	w.Handle(KindEmail, EmailHandler(m))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "outbox_messages" WHERE status = \$1 AND next_attempt_at <= \$2 ORDER BY outbox_message_id LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+		WithArgs(StatusPending, sqlmock.AnyArg(), 10).
+		WillReturnRows(messageRows(0))
+	mock.ExpectExec(`UPDATE "outbox_messages" SET "attempts"=\$1,"last_error"=\$2,"sent_at"=\$3,"status"=\$4 WHERE "outbox_message_id" = \$5`).
+		WithArgs(1, "", sqlmock.AnyArg(), StatusSent, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := w.ProcessBatch(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []mailer.Message{{To: "budi@mail.com", Subject: "Payment success", HTML: "paid"}}, m.Messages())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessBatch_retryThenDead(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	m := mailer.NewMemoryMailer()
+	m.Err = errors.New("smtp: connection refused")
+	w := NewWorker(db, Backoff{Base: time.Second}, 3, time.Second, 10)
+	w.Handle(KindEmail, EmailHandler(m))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "outbox_messages"`).WillReturnRows(messageRows(0))
+	mock.ExpectExec(`UPDATE "outbox_messages" SET "attempts"=\$1,"last_error"=\$2,"next_attempt_at"=\$3 WHERE "outbox_message_id" = \$4`).
+		WithArgs(1, "smtp: connection refused", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "outbox_messages"`).WillReturnRows(messageRows(2))
+	mock.ExpectExec(`UPDATE "outbox_messages" SET "attempts"=\$1,"last_error"=\$2,"status"=\$3 WHERE "outbox_message_id" = \$4`).
+		WithArgs(3, "smtp: connection refused", StatusDead, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err := w.ProcessBatch(context.Background())
+	assert.Nil(t, err)
+	_, err = w.ProcessBatch(context.Background())
+	assert.Nil(t, err)
+
+	assert.Empty(t, m.Messages())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+// Package pricing turns a rental period into a price.
+//
+// A rental is billed as follows:
+//   - every full 24 hours is billed at the daily rate,
+//   - the remainder is rounded up to whole hours and billed at the hourly
+//     rate, capped at one daily rate,
+//   - a remainder shorter than or equal to the grace period is not billed,
+//     unless the whole rental is shorter than that, then one hour is billed,
+//   - amounts are whole rupiah, percentages and divisions are rounded half
+//     away from zero.
+package pricing
+
+import (
+	"errors"
+	"math"
+	"p2-mini-project/src/money"
+	"time"
+)
+
+// DefaultHourlyDivisor derives the hourly rate of cars without one, so the
+// daily cap is reached after six hours.
+const DefaultHourlyDivisor = 6
+
+var ErrInvalidPeriod = errors.New("return time must be after pickup time")
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+type Policy struct {
+	GracePeriod time.Duration
+	// Location is used for times sent without an UTC offset.
+	Location *time.Location
+	// VATRate is a percentage added on top of the rental total.
+	VATRate  float64
+	QuoteTTL time.Duration
+	// QuoteSecret signs quote tokens.
+	QuoteSecret []byte
+	// InsurancePerDay is charged for every billed day of insured rentals.
+	InsurancePerDay money.Money
+	// LateFeePercent is applied to the regular price of an overdue period.
+	LateFeePercent float64
+}
+
+type Charge struct {
+	Days   int         `json:"days"`
+	Hours  int         `json:"hours"`
+	Amount money.Money `json:"amount"`
+}
+
+// HourlyRate returns hourly, or the daily rate split by DefaultHourlyDivisor
+// when the car has no hourly rate.
+func HourlyRate(daily, hourly money.Money) money.Money {
+	if hourly > 0 {
+		return hourly
+	}
+	return daily.Div(DefaultHourlyDivisor)
+}
+
+// ParseTime accepts RFC3339 timestamps as well as dates and date times
+// without an offset, which are read in the policy location.
+func (p Policy) ParseTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, p.location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Local returns t in the policy location.
+func (p Policy) Local(t time.Time) time.Time {
+	return t.In(p.location())
+}
+
+// Charge bills the period between pickup and ret.
+func (p Policy) Charge(daily, hourly money.Money, pickup, ret time.Time) (Charge, error) {
+	duration := ret.Sub(pickup)
+	if duration <= 0 {
+		return Charge{}, ErrInvalidPeriod
+	}
+
+	days := int(duration / (24 * time.Hour))
+	remainder := duration - time.Duration(days)*24*time.Hour
+
+	hours := 0
+	switch {
+	case days == 0 && remainder <= p.GracePeriod:
+		hours = 1
+	case remainder > p.GracePeriod:
+		hours = int(math.Ceil(remainder.Hours()))
+	}
+
+	hourlyAmount := HourlyRate(daily, hourly).Mul(hours)
+	if hours > 0 && hourlyAmount >= daily {
+		days, hours, hourlyAmount = days+1, 0, 0
+	}
+
+	return Charge{
+		Days:   days,
+		Hours:  hours,
+		Amount: daily.Mul(days) + hourlyAmount,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package pricing
+
+import (
+	"p2-mini-project/src/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharge(t *testing.T) {
+	policy := Policy{GracePeriod: 30 * time.Minute}
+	pickup := time.Date(2024, 4, 18, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		hourly   money.Money
+		expected Charge
+	}{
+		{"exact days", 48 * time.Hour, 0, Charge{Days: 2, Amount: 600000}},
+		{"same day rental is billed hourly", 3 * time.Hour, 0, Charge{Hours: 3, Amount: 150000}},
+		{"partial hour rounds up", 2*time.Hour + 10*time.Minute, 0, Charge{Hours: 3, Amount: 150000}},
+		{"remainder within grace period is free", 24*time.Hour + 30*time.Minute, 0, Charge{Days: 1, Amount: 300000}},
+		{"remainder after grace period is billed", 24*time.Hour + 31*time.Minute, 0, Charge{Days: 1, Hours: 1, Amount: 350000}},
+		{"short rental bills one hour", 10 * time.Minute, 0, Charge{Hours: 1, Amount: 50000}},
+		{"hourly charge is capped at daily rate", 24*time.Hour + 7*time.Hour, 0, Charge{Days: 2, Amount: 600000}},
+		{"explicit hourly rate", 5 * time.Hour, 40000, Charge{Hours: 5, Amount: 200000}},
+		{"hourly rate reaching daily cap", 8 * time.Hour, 40000, Charge{Days: 1, Amount: 300000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charge, err := policy.Charge(300000, tt.hourly, pickup, pickup.Add(tt.duration))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, charge)
+		})
+	}
+}
+
+func TestCharge_invalidPeriod(t *testing.T) {
+	pickup := time.Date(2024, 4, 18, 10, 0, 0, 0, time.UTC)
+
+	_, err := Policy{}.Charge(300000, 0, pickup, pickup)
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+
+	_, err = Policy{}.Charge(300000, 0, pickup, pickup.Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+}
+
+func TestHourlyRate(t *testing.T) {
+	assert.Equal(t, money.Money(50000), HourlyRate(300000, 0))
+	assert.Equal(t, money.Money(16667), HourlyRate(100000, 0))
+	assert.Equal(t, money.Money(20000), HourlyRate(100000, 20000))
+}
+
+func TestParseTime(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	policy := Policy{Location: jakarta}
+
+	tests := map[string]time.Time{
+		"2024-04-18":                time.Date(2024, 4, 18, 0, 0, 0, 0, jakarta),
+		"2024-04-18 09:30":          time.Date(2024, 4, 18, 9, 30, 0, 0, jakarta),
+		"2024-04-18T09:30:15":       time.Date(2024, 4, 18, 9, 30, 15, 0, jakarta),
+		"2024-04-18T09:30:00Z":      time.Date(2024, 4, 18, 9, 30, 0, 0, time.UTC),
+		"2024-04-18T09:30:00+08:00": time.Date(2024, 4, 18, 1, 30, 0, 0, time.UTC),
+	}
+
+	for value, expected := range tests {
+		parsed, err := policy.ParseTime(value)
+		assert.Nil(t, err, value)
+		assert.True(t, expected.Equal(parsed), value)
+	}
+
+	_, err := policy.ParseTime("18-04-2024")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,224 @@
+package pricing
+
+import (
+	"fmt"
+	"p2-mini-project/src/money"
+	"strings"
+	"time"
+)
+
+const (
+	RuleWeekend    = "weekend"
+	RuleHoliday    = "holiday"
+	RuleSeason     = "season"
+	RuleLongRental = "long_rental"
+	RuleEarlyBird  = "early_bird"
+)
+
+const (
+	LineRentalDay  = "rental_day"
+	LineRentalHour = "rental_hour"
+	LineCoupon     = "coupon"
+	LineInsurance  = "insurance"
+	LineOneWayFee  = "one_way_fee"
+	LineLateFee    = "late_fee"
+	LineVAT        = "vat"
+	// LineRental is the single line of rentals priced before lines were
+	// stored.
+	LineRental = "rental"
+)
+
+// Rule adjusts the price by Percent, positive values are surcharges and
+// negative values are discounts.
+//
+// Weekend, holiday and season rules are applied to the base price of every
+// billed day they match, holiday and season rules match days between
+// StartDate and EndDate inclusive. Long rental and early bird rules are
+// applied once to the adjusted rental price, only the rule with the highest
+// MinDays or MinLeadDays that the rental qualifies for is used.
+type Rule struct {
+	Name        string
+	Type        string
+	Percent     float64
+	StartDate   time.Time
+	EndDate     time.Time
+	MinDays     int
+	MinLeadDays int
+}
+
+type Input struct {
+	DailyRate     money.Money
+	HourlyRate    money.Money
+	Pickup        time.Time
+	Return        time.Time
+	BookedAt      time.Time
+	Rules         []Rule
+	CouponName    string
+	CouponPercent float64
+	Insurance     bool
+	OneWayFee     money.Money
+}
+
+type Line struct {
+	Code        string      `json:"code"`
+	Description string      `json:"description"`
+	Quantity    int         `json:"quantity"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Amount      money.Money `json:"amount"`
+}
+
+type Breakdown struct {
+	Days  int         `json:"days"`
+	Hours int         `json:"hours"`
+	Lines []Line      `json:"lines"`
+	Total money.Money `json:"total"`
+}
+
+// Quote prices a rental: the base charge, day based rules, long rental and
+// early bird discounts, the coupon, insurance, the one-way fee and finally
+// VAT. Every percentage is rounded on its own line and the total is the sum
+// of the lines.
+func (p Policy) Quote(in Input) (Breakdown, error) {
+	charge, err := p.Charge(in.DailyRate, in.HourlyRate, in.Pickup, in.Return)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	hourlyRate := HourlyRate(in.DailyRate, in.HourlyRate)
+
+	b := Breakdown{Days: charge.Days, Hours: charge.Hours}
+	if charge.Days > 0 {
+		b.add(Line{Code: LineRentalDay, Description: "Daily rental", Quantity: charge.Days, UnitPrice: in.DailyRate, Amount: in.DailyRate.Mul(charge.Days)})
+	}
+	if charge.Hours > 0 {
+		b.add(Line{Code: LineRentalHour, Description: "Hourly rental", Quantity: charge.Hours, UnitPrice: hourlyRate, Amount: hourlyRate.Mul(charge.Hours)})
+	}
+
+	for _, rule := range in.Rules {
+		if rule.Type != RuleWeekend && rule.Type != RuleHoliday && rule.Type != RuleSeason {
+			continue
+		}
+
+		days, amount := 0, money.Money(0)
+		for i := 0; i <= charge.Days; i++ {
+			base := in.DailyRate
+			if i == charge.Days {
+				base = hourlyRate.Mul(charge.Hours)
+			}
+			if base == 0 || !rule.matchesDay(in.Pickup.Add(time.Duration(i)*24*time.Hour).In(p.location())) {
+				continue
+			}
+			days++
+			amount += base.Percent(rule.Percent)
+		}
+		if days > 0 {
+			b.add(Line{Code: rule.Type, Description: fmt.Sprintf("%s (%d days)", rule.Name, days), Quantity: 1, UnitPrice: amount, Amount: amount})
+		}
+	}
+
+	subtotal := b.Total
+	if rule, ok := bestRule(in.Rules, RuleLongRental, charge.Days, func(r Rule) int { return r.MinDays }); ok {
+		amount := subtotal.Percent(rule.Percent)
+		b.add(Line{Code: rule.Type, Description: rule.Name, Quantity: 1, UnitPrice: amount, Amount: amount})
+	}
+	leadDays := int(in.Pickup.Sub(in.BookedAt) / (24 * time.Hour))
+	if rule, ok := bestRule(in.Rules, RuleEarlyBird, leadDays, func(r Rule) int { return r.MinLeadDays }); ok {
+		amount := subtotal.Percent(rule.Percent)
+		b.add(Line{Code: rule.Type, Description: rule.Name, Quantity: 1, UnitPrice: amount, Amount: amount})
+	}
+
+	if in.CouponPercent > 0 {
+		amount := -b.Total.Percent(in.CouponPercent)
+		b.add(Line{Code: LineCoupon, Description: strings.TrimSpace("Coupon " + in.CouponName), Quantity: 1, UnitPrice: amount, Amount: amount})
+	}
+
+	if in.Insurance && p.InsurancePerDay > 0 {
+		days := charge.Days
+		if charge.Hours > 0 {
+			days++
+		}
+		b.add(Line{Code: LineInsurance, Description: "Insurance", Quantity: days, UnitPrice: p.InsurancePerDay, Amount: p.InsurancePerDay.Mul(days)})
+	}
+
+	if in.OneWayFee > 0 {
+		b.add(Line{Code: LineOneWayFee, Description: "One-way fee", Quantity: 1, UnitPrice: in.OneWayFee, Amount: in.OneWayFee})
+	}
+
+	p.addVAT(&b)
+
+	return b, nil
+}
+
+// LateFee bills a car returned after due with LateFeePercent of the regular
+// price of the overdue period plus VAT. Returns within the grace period are
+// not billed and return an empty breakdown.
+func (p Policy) LateFee(dailyRate, hourlyRate money.Money, due, returned time.Time) Breakdown {
+	if returned.Sub(due) <= p.GracePeriod {
+		return Breakdown{}
+	}
+
+	charge, err := p.Charge(dailyRate, hourlyRate, due, returned)
+	if err != nil {
+		return Breakdown{}
+	}
+
+	b := Breakdown{Days: charge.Days, Hours: charge.Hours}
+	amount := charge.Amount.Percent(p.LateFeePercent)
+	b.add(Line{Code: LineLateFee, Description: fmt.Sprintf("Late return (%d days %d hours)", charge.Days, charge.Hours), Quantity: 1, UnitPrice: amount, Amount: amount})
+	p.addVAT(&b)
+
+	return b
+}
+
+func (p Policy) addVAT(b *Breakdown) {
+	if p.VATRate > 0 {
+		amount := b.Total.Percent(p.VATRate)
+		b.add(Line{Code: LineVAT, Description: fmt.Sprintf("VAT %g%%", p.VATRate), Quantity: 1, UnitPrice: amount, Amount: amount})
+	}
+}
+
+// BreakdownOf sums stored lines back into a breakdown.
+func BreakdownOf(lines []Line) Breakdown {
+	b := Breakdown{}
+	for _, line := range lines {
+		b.add(line)
+	}
+	return b
+}
+
+func (b *Breakdown) add(line Line) {
+	b.Lines = append(b.Lines, line)
+	b.Total += line.Amount
+}
+
+func (r Rule) matchesDay(day time.Time) bool {
+	switch r.Type {
+	case RuleWeekend:
+		return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+	case RuleHoliday, RuleSeason:
+		date := day.Format(time.DateOnly)
+		return date >= r.StartDate.Format(time.DateOnly) && date <= r.EndDate.Format(time.DateOnly)
+	}
+	return false
+}
+
+func (p Policy) location() *time.Location {
+	if p.Location == nil {
+		return time.UTC
+	}
+	return p.Location
+}
+
+// bestRule returns the rule of ruleType with the highest threshold not
+// above value.
+func bestRule(rules []Rule, ruleType string, value int, threshold func(Rule) int) (Rule, bool) {
+	best, found := Rule{}, false
+	for _, rule := range rules {
+		if rule.Type != ruleType || threshold(rule) > value {
+			continue
+		}
+		if !found || threshold(rule) > threshold(best) {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
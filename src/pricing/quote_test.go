@@ -0,0 +1,150 @@
+package pricing
+
+import (
+	"p2-mini-project/src/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote_baseAndFees(t *testing.T) {
+	policy := Policy{GracePeriod: 30 * time.Minute}
+	// wednesday
+	pickup := time.Date(2024, 4, 17, 10, 0, 0, 0, time.UTC)
+
+	b, err := policy.Quote(Input{
+		DailyRate:     300000,
+		Pickup:        pickup,
+		Return:        pickup.Add(26 * time.Hour),
+		BookedAt:      pickup,
+		CouponName:    "HEMAT10",
+		CouponPercent: 10,
+		OneWayFee:     75000,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Line{
+		{Code: LineRentalDay, Description: "Daily rental", Quantity: 1, UnitPrice: 300000, Amount: 300000},
+		{Code: LineRentalHour, Description: "Hourly rental", Quantity: 2, UnitPrice: 50000, Amount: 100000},
+		{Code: LineCoupon, Description: "Coupon HEMAT10", Quantity: 1, UnitPrice: -40000, Amount: -40000},
+		{Code: LineOneWayFee, Description: "One-way fee", Quantity: 1, UnitPrice: 75000, Amount: 75000},
+	}, b.Lines)
+	assert.Equal(t, money.Money(435000), b.Total)
+}
+
+func TestQuote_dayRules(t *testing.T) {
+	policy := Policy{}
+	// friday to monday
+	pickup := time.Date(2024, 4, 19, 9, 0, 0, 0, time.UTC)
+
+	b, err := policy.Quote(Input{
+		DailyRate: 200000,
+		Pickup:    pickup,
+		Return:    pickup.Add(72 * time.Hour),
+		BookedAt:  pickup,
+		Rules: []Rule{
+			{Name: "Weekend", Type: RuleWeekend, Percent: 20},
+			{Name: "Holiday", Type: RuleHoliday, Percent: 50, StartDate: time.Date(2024, 4, 19, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 4, 19, 0, 0, 0, 0, time.UTC)},
+			{Name: "Low season", Type: RuleSeason, Percent: -10, StartDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Line{
+		{Code: LineRentalDay, Description: "Daily rental", Quantity: 3, UnitPrice: 200000, Amount: 600000},
+		{Code: RuleWeekend, Description: "Weekend (2 days)", Quantity: 1, UnitPrice: 80000, Amount: 80000},
+		{Code: RuleHoliday, Description: "Holiday (1 days)", Quantity: 1, UnitPrice: 100000, Amount: 100000},
+	}, b.Lines)
+	assert.Equal(t, money.Money(780000), b.Total)
+}
+
+func TestQuote_longRentalAndEarlyBird(t *testing.T) {
+	policy := Policy{}
+	// monday
+	pickup := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	b, err := policy.Quote(Input{
+		DailyRate: 100000,
+		Pickup:    pickup,
+		Return:    pickup.Add(10 * 24 * time.Hour),
+		BookedAt:  pickup.Add(-15 * 24 * time.Hour),
+		Rules: []Rule{
+			{Name: "Weekly", Type: RuleLongRental, Percent: -10, MinDays: 7},
+			{Name: "Monthly", Type: RuleLongRental, Percent: -25, MinDays: 30},
+			{Name: "Early bird 7", Type: RuleEarlyBird, Percent: -5, MinLeadDays: 7},
+			{Name: "Early bird 14", Type: RuleEarlyBird, Percent: -8, MinLeadDays: 14},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Line{
+		{Code: LineRentalDay, Description: "Daily rental", Quantity: 10, UnitPrice: 100000, Amount: 1000000},
+		{Code: RuleLongRental, Description: "Weekly", Quantity: 1, UnitPrice: -100000, Amount: -100000},
+		{Code: RuleEarlyBird, Description: "Early bird 14", Quantity: 1, UnitPrice: -80000, Amount: -80000},
+	}, b.Lines)
+	assert.Equal(t, money.Money(820000), b.Total)
+}
+
+func TestQuote_vat(t *testing.T) {
+	policy := Policy{VATRate: 11}
+	pickup := time.Date(2024, 4, 17, 10, 0, 0, 0, time.UTC)
+
+	b, err := policy.Quote(Input{
+		DailyRate: 250000,
+		Pickup:    pickup,
+		Return:    pickup.Add(24 * time.Hour),
+		BookedAt:  pickup,
+		OneWayFee: 50000,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, Line{Code: LineVAT, Description: "VAT 11%", Quantity: 1, UnitPrice: 33000, Amount: 33000}, b.Lines[len(b.Lines)-1])
+	assert.Equal(t, money.Money(333000), b.Total)
+}
+
+func TestQuote_insurance(t *testing.T) {
+	policy := Policy{GracePeriod: 30 * time.Minute, InsurancePerDay: 40000}
+	pickup := time.Date(2024, 4, 17, 10, 0, 0, 0, time.UTC)
+
+	b, err := policy.Quote(Input{
+		DailyRate:     300000,
+		Pickup:        pickup,
+		Return:        pickup.Add(26 * time.Hour),
+		BookedAt:      pickup,
+		CouponName:    "HEMAT10",
+		CouponPercent: 10,
+		Insurance:     true,
+	})
+
+	assert.Nil(t, err)
+	// the coupon doesn't apply to insurance
+	assert.Equal(t, Line{Code: LineInsurance, Description: "Insurance", Quantity: 2, UnitPrice: 40000, Amount: 80000}, b.Lines[len(b.Lines)-1])
+	assert.Equal(t, money.Money(440000), b.Total)
+}
+
+func TestLateFee(t *testing.T) {
+	policy := Policy{GracePeriod: 30 * time.Minute, VATRate: 11, LateFeePercent: 150}
+	due := time.Date(2024, 4, 17, 10, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, policy.LateFee(300000, 0, due, due.Add(-time.Hour)).Lines)
+	assert.Empty(t, policy.LateFee(300000, 0, due, due.Add(30*time.Minute)).Lines)
+
+	b := policy.LateFee(300000, 0, due, due.Add(2*time.Hour+10*time.Minute))
+	assert.Equal(t, []Line{
+		{Code: LineLateFee, Description: "Late return (0 days 3 hours)", Quantity: 1, UnitPrice: 225000, Amount: 225000},
+		{Code: LineVAT, Description: "VAT 11%", Quantity: 1, UnitPrice: 24750, Amount: 24750},
+	}, b.Lines)
+	assert.Equal(t, money.Money(249750), b.Total)
+}
+
+func TestBreakdownOf(t *testing.T) {
+	lines := []Line{
+		{Code: LineRentalDay, Quantity: 2, UnitPrice: 100000, Amount: 200000},
+		{Code: LineCoupon, Quantity: 1, UnitPrice: -20000, Amount: -20000},
+	}
+
+	b := BreakdownOf(lines)
+	assert.Equal(t, lines, b.Lines)
+	assert.Equal(t, money.Money(180000), b.Total)
+}
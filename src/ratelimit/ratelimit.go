@@ -0,0 +1,132 @@
+// Package ratelimit limits requests with token buckets kept in a Store and
+// locks accounts out after repeated failed logins.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Rate allows Limit events per Period in bursts of up to Limit events.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Store keeps the token buckets by key. MemoryStore keeps them in process, a
+// store shared by every instance of the API enforces the limits across them.
+type Store interface {
+	// Take takes a token from the bucket of key, the bucket refills at rate.
+	// When the bucket is empty it returns false and the wait for the next
+	// token.
+	Take(ctx context.Context, key string, rate Rate, now time.Time) (bool, time.Duration, error)
+}
+
+// Limiter limits the events of a key at a rate, limiters sharing a store are
+// told apart by their name.
+type Limiter struct {
+	store Store
+	name  string
+	rate  Rate
+}
+
+func NewLimiter(store Store, name string, rate Rate) *Limiter {
+	return &Limiter{store: store, name: name, rate: rate}
+}
+
+// Allow reports whether an event of key is allowed now and otherwise the
+// wait until it is. A limiter without a limit allows everything.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	if l == nil || l.rate.Limit <= 0 || l.rate.Period <= 0 {
+		return true, 0, nil
+	}
+	return l.store.Take(ctx, l.name+":"+key, l.rate, time.Now())
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket is refilled, it can be dropped from then on.
+	full time.Time
+}
+
+// MemoryStore keeps token buckets in memory, full buckets are dropped every
+// sweep interval.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, rate Rate, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.swept) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	perToken := rate.Period / time.Duration(rate.Limit)
+	limit := float64(rate.Limit)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(limit, b.tokens+float64(elapsed)/float64(perToken))
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(perToken)), nil
+	}
+	b.tokens--
+	b.full = now.Add(time.Duration((limit - b.tokens) * float64(perToken)))
+	return true, 0, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.swept = now
+}
+
+// Lockout locks an account out for Base once it has Threshold failed logins
+// in a row, every further failure doubles the lockout up to Max. Failures
+// older than Window are forgotten.
+type Lockout struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration
+}
+
+// Until returns when an account with failures in a row, the last one at
+// last, is unlocked. It is the zero time for accounts that aren't locked.
+func (l Lockout) Until(failures int, last time.Time) time.Time {
+	if l.Threshold <= 0 || failures < l.Threshold {
+		return time.Time{}
+	}
+
+	duration := l.Base
+	for i := l.Threshold; i < failures && duration < l.Max; i++ {
+		duration *= 2
+	}
+	if duration > l.Max {
+		duration = l.Max
+	}
+	return last.Add(duration)
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	rate := Rate{Limit: 3, Period: time.Minute}
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := store.Take(ctx, "login-ip:10.0.0.1", rate, now)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	ok, wait, _ := store.Take(ctx, "login-ip:10.0.0.1", rate, now)
+	assert.False(t, ok)
+	assert.Equal(t, 20*time.Second, wait)
+
+	// other keys have their own bucket
+	ok, _, _ = store.Take(ctx, "login-ip:10.0.0.2", rate, now)
+	assert.True(t, ok)
+
+	// one token is back after a third of the period
+	ok, _, _ = store.Take(ctx, "login-ip:10.0.0.1", rate, now.Add(20*time.Second))
+	assert.True(t, ok)
+	ok, wait, _ = store.Take(ctx, "login-ip:10.0.0.1", rate, now.Add(30*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, wait)
+
+	// full buckets are dropped
+	store.Take(ctx, "login-ip:10.0.0.3", rate, now.Add(2*time.Minute))
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	store := NewMemoryStore()
+	ip := NewLimiter(store, "login-ip", Rate{Limit: 1, Period: time.Minute})
+	account := NewLimiter(store, "login-account", Rate{Limit: 1, Period: time.Minute})
+
+	ok, _, _ := ip.Allow(context.Background(), "budi@mail.com")
+	assert.True(t, ok)
+	ok, _, _ = account.Allow(context.Background(), "budi@mail.com")
+	assert.True(t, ok, "limiters sharing a store have their own buckets")
+	ok, _, _ = ip.Allow(context.Background(), "budi@mail.com")
+	assert.False(t, ok)
+
+	unlimited := NewLimiter(store, "unlimited", Rate{})
+	for i := 0; i < 5; i++ {
+		ok, _, _ = unlimited.Allow(context.Background(), "budi@mail.com")
+		assert.True(t, ok)
+	}
+}
+
+func TestLockout_Until(t *testing.T) {
+	lockout := Lockout{Threshold: 5, Base: time.Minute, Max: 10 * time.Minute}
+	last := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.True(t, lockout.Until(4, last).IsZero())
+	assert.Equal(t, last.Add(time.Minute), lockout.Until(5, last))
+	assert.Equal(t, last.Add(2*time.Minute), lockout.Until(6, last))
+	assert.Equal(t, last.Add(8*time.Minute), lockout.Until(8, last))
+	assert.Equal(t, last.Add(10*time.Minute), lockout.Until(9, last))
+	assert.Equal(t, last.Add(10*time.Minute), lockout.Until(100, last))
+}
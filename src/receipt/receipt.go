@@ -0,0 +1,140 @@
+// Package receipt renders payment receipts as PDF documents.
+package receipt
+
+import (
+	"fmt"
+	"io"
+	"p2-mini-project/src/money"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+type Company struct {
+	Name    string
+	Address string
+	Phone   string
+	Email   string
+}
+
+type Item struct {
+	Description string
+	Quantity    int
+	UnitPrice   money.Money
+	Amount      money.Money
+}
+
+type Receipt struct {
+	Number        string
+	PaidAt        time.Time
+	PaymentMethod string
+
+	CustomerName    string
+	CustomerEmail   string
+	CustomerAddress string
+
+	RentalID    int
+	CarName     string
+	PlateNumber string
+	RentalDate  time.Time
+	ReturnDate  time.Time
+
+	Items []Item
+	Total money.Money
+}
+
+// Generator renders receipts issued by Company, times are printed in
+// Location.
+type Generator struct {
+	Company  Company
+	Location *time.Location
+}
+
+// Number formats the receipt number of a payment, e.g. "RCP-20240418-000042".
+func Number(payment_id int, paidAt time.Time) string {
+	return fmt.Sprintf("RCP-%s-%06d", paidAt.Format("20060102"), payment_id)
+}
+
+func (g *Generator) Render(w io.Writer, r *Receipt) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Receipt "+r.Number, true)
+	pdf.SetAuthor(g.Company.Name, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(110, 8, tr(g.Company.Name), "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(60, 8, "RECEIPT", "", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range []string{g.Company.Address, g.Company.Phone, g.Company.Email} {
+		if line != "" {
+			pdf.CellFormat(170, 5, tr(line), "", 1, "L", false, 0, "")
+		}
+	}
+	pdf.Ln(6)
+
+	field := func(label, value string) {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(35, 6, label, "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(135, 6, tr(value), "", 1, "L", false, 0, "")
+	}
+	field("Receipt number", r.Number)
+	field("Paid at", g.format(r.PaidAt, "02 Jan 2006"))
+	field("Payment method", r.PaymentMethod)
+	pdf.Ln(4)
+
+	field("Billed to", r.CustomerName)
+	field("Email", r.CustomerEmail)
+	field("Address", r.CustomerAddress)
+	pdf.Ln(4)
+
+	field("Rental", fmt.Sprintf("#%d", r.RentalID))
+	car := r.CarName
+	if r.PlateNumber != "" {
+		car += " (" + r.PlateNumber + ")"
+	}
+	field("Car", car)
+	field("Rental period", g.format(r.RentalDate, "02 Jan 2006 15:04")+" - "+g.format(r.ReturnDate, "02 Jan 2006 15:04 MST"))
+	pdf.Ln(6)
+
+	widths := []float64{85, 15, 35, 35}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, header := range []string{"Description", "Qty", "Unit price", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, header, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, item := range r.Items {
+		pdf.CellFormat(widths[0], 7, tr(item.Description), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 7, fmt.Sprint(item.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 7, item.UnitPrice.String(), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, item.Amount.String(), "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2], 8, "Total", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[3], 8, r.Total.String(), "T", 1, "R", false, 0, "")
+
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.CellFormat(170, 5, tr("Thank you for renting with "+g.Company.Name+"."), "", 1, "C", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+func (g *Generator) format(t time.Time, layout string) string {
+	if g.Location != nil {
+		t = t.In(g.Location)
+	}
+	return t.Format(layout)
+}
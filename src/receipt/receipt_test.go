@@ -0,0 +1,35 @@
+package receipt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumber(t *testing.T) {
+	assert.Equal(t, "RCP-20240418-000042", Number(42, time.Date(2024, 4, 18, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestRender(t *testing.T) {
+	g := &Generator{Company: Company{Name: "Rental Car", Address: "Jl. Sudirman 1, Jakarta"}, Location: time.UTC}
+	pickup := time.Date(2024, 4, 18, 9, 0, 0, 0, time.UTC)
+
+	buf := new(bytes.Buffer)
+	err := g.Render(buf, &Receipt{
+		Number:        "RCP-20240418-000042",
+		PaidAt:        pickup,
+		PaymentMethod: "Deposit",
+		CustomerName:  "Budi",
+		RentalID:      7,
+		CarName:       "Avanza",
+		RentalDate:    pickup,
+		ReturnDate:    pickup.Add(48 * time.Hour),
+		Items:         []Item{{Description: "Daily rental", Quantity: 2, UnitPrice: 300000, Amount: 600000}},
+		Total:         600000,
+	})
+
+	assert.Nil(t, err)
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+}
@@ -0,0 +1,221 @@
+// Package reminder scans rentals on a schedule and notifies users before
+// their pickup and return, and escalates overdue returns to the user and the
+// admins. Every notice is recorded in rental_notices in the same transaction
+// as the notification so it is sent once even with several schedulers.
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/notification"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Schedule configures when notices are sent.
+type Schedule struct {
+	PickupBefore time.Duration
+	ReturnBefore time.Duration
+	// OverdueAfter are the escalation levels after the return date, in
+	// increasing order. Only the highest level reached is sent.
+	OverdueAfter []time.Duration
+	// AdminLevel is the first level, starting at 1, also sent to admins.
+	AdminLevel int
+}
+
+type Scheduler struct {
+	db       *gorm.DB
+	notifier *notification.Notifier
+	location *time.Location
+	Interval time.Duration
+	Schedule Schedule
+}
+
+func NewScheduler(db *gorm.DB, notifier *notification.Notifier, location *time.Location, interval time.Duration, schedule Schedule) *Scheduler {
+	return &Scheduler{db: db, notifier: notifier, location: location, Interval: interval, Schedule: schedule}
+}
+
+// dueRental is a rental with the fields notices are rendered with.
+type dueRental struct {
+	RentalID   int
+	UserID     int
+	CarName    string
+	RentalDate time.Time
+	ReturnDate time.Time
+}
+
+// Run sends due notices every Interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "reminder: failed to send notices", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends every notice due at now, a failed notice doesn't stop the
+// others and is retried on the next run.
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) error {
+	return errors.Join(
+		s.sendPickupReminders(ctx, now),
+		s.sendReturnReminders(ctx, now),
+		s.sendOverdueAlerts(ctx, now),
+	)
+}
+
+func (s *Scheduler) sendPickupReminders(ctx context.Context, now time.Time) error {
+	rentals, err := s.dueRentals(ctx, notification.EventPickupReminder, false, "rentals.rental_date > ? AND rentals.rental_date <= ?", now, now.Add(s.Schedule.PickupBefore))
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, r := range rentals {
+		errs = append(errs, s.send(ctx, r, notification.EventPickupReminder, func(tx *gorm.DB, user *entity.User) error {
+			return s.notifier.Notify(tx, notification.EventPickupReminder, user, s.data(r))
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) sendReturnReminders(ctx context.Context, now time.Time) error {
+	rentals, err := s.dueRentals(ctx, notification.EventReturnReminder, true, "rentals.return_date > ? AND rentals.return_date <= ?", now, now.Add(s.Schedule.ReturnBefore))
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, r := range rentals {
+		errs = append(errs, s.send(ctx, r, notification.EventReturnReminder, func(tx *gorm.DB, user *entity.User) error {
+			return s.notifier.Notify(tx, notification.EventReturnReminder, user, s.data(r))
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) sendOverdueAlerts(ctx context.Context, now time.Time) error {
+	errs := []error{}
+
+	levels := s.Schedule.OverdueAfter
+	for i, after := range levels {
+		level := i + 1
+		kind := fmt.Sprintf("%s_%d", notification.EventOverdue, level)
+
+		// rentals past this level but not the next one
+		where, args := "rentals.return_date <= ?", []interface{}{now.Add(-after)}
+		if level < len(levels) {
+			where, args = where+" AND rentals.return_date > ?", append(args, now.Add(-levels[i+1]))
+		}
+
+		rentals, err := s.dueRentals(ctx, kind, true, where, args...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, r := range rentals {
+			errs = append(errs, s.send(ctx, r, kind, func(tx *gorm.DB, user *entity.User) error {
+				data := s.data(r)
+				data["OverdueHours"] = int(now.Sub(r.ReturnDate).Hours())
+				data["Level"] = level
+				if err := s.notifier.Notify(tx, notification.EventOverdue, user, data); err != nil {
+					return err
+				}
+
+				if s.Schedule.AdminLevel < 1 || level < s.Schedule.AdminLevel {
+					return nil
+				}
+				return s.alertAdmins(tx, user, data)
+			}))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) alertAdmins(tx *gorm.DB, customer *entity.User, data mailer.Data) error {
+	admins := []entity.User{}
+	if res := tx.Where("role = ?", "admin").Find(&admins); res.Error != nil {
+		return res.Error
+	}
+
+	for i := range admins {
+		alert := mailer.Data{"Customer": customer.Fullname, "CustomerEmail": customer.Email}
+		for k, v := range data {
+			if k != "Fullname" {
+				alert[k] = v
+			}
+		}
+		if err := s.notifier.Notify(tx, notification.EventOverdueAlert, &admins[i], alert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dueRentals returns the active rentals matching where that haven't been
+// sent the kind notice, paid limits them to rentals that were paid for.
+func (s *Scheduler) dueRentals(ctx context.Context, kind string, paid bool, where string, args ...interface{}) ([]dueRental, error) {
+	query := s.db.WithContext(ctx).Table("rentals").
+		Select("rentals.rental_id, rentals.user_id, cars.name AS car_name, rentals.rental_date, rentals.return_date").
+		Joins("JOIN cars ON cars.car_id = rentals.car_id").
+		Where("rentals.returned_at IS NULL").
+		Where(where, args...).
+		Where("NOT EXISTS (SELECT 1 FROM rental_notices WHERE rental_notices.rental_id = rentals.rental_id AND rental_notices.kind = ?)", kind)
+	if paid {
+		query = query.Where("EXISTS (SELECT 1 FROM payments WHERE payments.rental_id = rentals.rental_id AND payments.payment_status = ?)", "settlement")
+	}
+
+	rentals := []dueRental{}
+	if res := query.Order("rentals.rental_id").Find(&rentals); res.Error != nil {
+		return nil, fmt.Errorf("find rentals for %s: %w", kind, res.Error)
+	}
+	return rentals, nil
+}
+
+// send records the kind notice of r and calls notify in the same
+// transaction, a notice recorded by another scheduler is skipped.
+func (s *Scheduler) send(ctx context.Context, r dueRental, kind string, notify func(tx *gorm.DB, user *entity.User) error) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.RentalNotice{RentalID: r.RentalID, Kind: kind})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		user := new(entity.User)
+		if res := tx.Where("user_id = ?", r.UserID).First(user); res.Error != nil {
+			return res.Error
+		}
+		return notify(tx, user)
+	})
+	if err != nil {
+		return fmt.Errorf("send %s of rental %d: %w", kind, r.RentalID, err)
+	}
+	return nil
+}
+
+func (s *Scheduler) data(r dueRental) mailer.Data {
+	return mailer.Data{
+		"RentalID":   r.RentalID,
+		"CarName":    r.CarName,
+		"RentalDate": r.RentalDate.In(s.location),
+		"ReturnDate": r.ReturnDate.In(s.location),
+	}
+}
@@ -0,0 +1,48 @@
+package reminder
+
+import (
+	"context"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/notification"
+	"p2-mini-project/src/testutil"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSend_once(t *testing.T) {
+	db, mock := testutil.DbMock(t)
+	s := NewScheduler(db, notification.NewNotifier(), time.UTC, time.Minute, Schedule{})
+	r := dueRental{RentalID: 7, UserID: 1}
+
+	notified := 0
+	notify := func(tx *gorm.DB, user *entity.User) error {
+		assert.Equal(t, 1, user.ID)
+		notified++
+		return nil
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "rental_notices" .* ON CONFLICT DO NOTHING`).
+		WithArgs(7, notification.EventPickupReminder, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_notice_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE user_id = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "fullname"}).AddRow(1, "Budi"))
+	mock.ExpectCommit()
+
+	// the second scheduler finds the notice already recorded
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "rental_notices" .* ON CONFLICT DO NOTHING`).
+		WithArgs(7, notification.EventPickupReminder, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_notice_id"}))
+	mock.ExpectCommit()
+
+	assert.Nil(t, s.send(context.Background(), r, notification.EventPickupReminder, notify))
+	assert.Nil(t, s.send(context.Background(), r, notification.EventPickupReminder, notify))
+	assert.Equal(t, 1, notified)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -1,53 +1,192 @@
 package routes
 
 import (
+	"log"
+	"log/slog"
 	"os"
 	"p2-mini-project/docs"
+	"p2-mini-project/src/config"
 	"p2-mini-project/src/handler"
+	"p2-mini-project/src/metrics"
 	"p2-mini-project/src/middleware"
+	"p2-mini-project/src/storage"
+	"p2-mini-project/src/tracing"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/gorm"
 )
 
 func Routes(db *gorm.DB) {
-	authService := handler.NewAuthService(db)
-	carService := handler.NewCarService(db)
-	adminService := handler.NewAdminService(db)
-	userService := handler.NewUserService(db)
+	loginIPLimiter, loginAccountLimiter, loginIPRejectLimiter := config.GetLoginLimiters()
+	authService := handler.NewAuthService(db, loginAccountLimiter, loginIPRejectLimiter, config.GetLoginLockout())
+	policy := config.GetPricingPolicy()
+	receipts := config.GetReceiptGenerator(policy.Location)
+	notifier := config.GetNotifier()
+	carService := handler.NewCarService(db, policy, receipts, notifier)
+	adminService := handler.NewAdminService(db, policy.Location)
+	userService := handler.NewUserService(db, notifier)
+	branchService := handler.NewBranchService(db)
+	pricingRuleService := handler.NewPricingRuleService(db)
+	paymentService := handler.NewPaymentService(db, receipts)
+	notificationService := handler.NewNotificationService(db, notifier)
+	webhookService := handler.NewWebhookService(db)
+	apiKeyService := handler.NewAPIKeyService(db)
+	reportService := handler.NewReportService(db, policy)
+	idempotencyConfig := config.GetIdempotencyConfig()
+	idempotent := middleware.IdempotencyMiddleware(db, idempotencyConfig.TTL, idempotencyConfig.Lease)
+
+	storageConfig := config.GetStorageConfig()
+	localStorage, err := storage.NewLocalStorage(storageConfig.LocalDir, storageConfig.BaseURL)
+	if err != nil {
+		log.Fatal("Failed to init storage: ", err)
+	}
+	carImageService := handler.NewCarImageService(db, localStorage, storageConfig.MaxImageSize)
+
+	httpConfig := config.GetHTTPConfig()
+	r := gin.New()
+	r.TrustedPlatform = httpConfig.TrustedPlatform
+	if err := r.SetTrustedProxies(httpConfig.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies: ", err)
+	}
+
+	// the span is started first so request logs carry its trace ID
+	tracingConfig := config.GetTracingConfig()
+	if tracingConfig.Exporter != tracing.ExporterNone {
+		r.Use(otelgin.Middleware(tracingConfig.ServiceName))
+	}
+
+	r.Use(middleware.RequestIDMiddleware, middleware.LoggerMiddleware(slog.Default()), middleware.RecoveryMiddleware)
+	r.Use(middleware.SecureHeadersMiddleware(httpConfig.ContentSecurityPolicy, httpConfig.HSTSMaxAge))
+	if corsMiddleware := config.GetCORSMiddleware(httpConfig); corsMiddleware != nil {
+		r.Use(corsMiddleware)
+	}
+	r.Use(middleware.BodyLimitMiddleware(httpConfig.MaxBodySize, "/api/v1/admin/cars/:car_id/images"))
+
+	metricsConfig := config.GetMetricsConfig()
+	if metricsConfig.Enabled {
+		if err := metrics.Register(prometheus.DefaultRegisterer, db); err != nil {
+			log.Fatal("Failed to register metrics: ", err)
+		}
+		r.Use(metrics.Middleware)
+		go config.ServeMetrics(metricsConfig)
+	}
 
-	r := gin.Default()
 	r.Use(middleware.ErrorMiddleware)
 
+	if strings.HasPrefix(storageConfig.BaseURL, "/") {
+		r.Static(storageConfig.BaseURL, storageConfig.LocalDir)
+	}
+
 	api := r.Group("/api/v1")
 	{
 		users := api.Group("/users")
 		{
 			users.POST("/register", authService.RegisterHandler)
-			users.POST("/login", authService.LoginHandler)
+			users.POST("/login", middleware.RateLimitMiddleware(loginIPLimiter, authService.RecordIPRateLimited), authService.LoginHandler)
+			users.GET("/verify", authService.VerifyEmail)
 		}
 		authUsers := api.Group("/users")
-		authUsers.Use(middleware.AuthMiddleware("user"))
+		authUsers.Use(middleware.AuthMiddleware(db, "user", "users"))
 		{
-			authUsers.POST("/topup", userService.TopUp)
+			authUsers.POST("/topup", idempotent, userService.TopUp)
+			authUsers.POST("/verification", authService.ResendVerification)
+			authUsers.PUT("/me/language", authService.UpdateLanguage)
+			authUsers.GET("/me/payments/:payment_id/receipt", paymentService.GetPaymentReceipt)
+			authUsers.GET("/me/notifications", notificationService.GetNotifications)
+			authUsers.POST("/me/notifications/read", notificationService.ReadAllNotifications)
+			authUsers.POST("/me/notifications/:notification_id/read", notificationService.ReadNotification)
+			authUsers.GET("/me/notification-preferences", notificationService.GetNotificationPreferences)
+			authUsers.PUT("/me/notification-preferences", notificationService.UpdateNotificationPreferences)
 		}
 		cars := api.Group("/cars")
-		cars.Use(middleware.AuthMiddleware("user"))
+		cars.Use(middleware.AuthMiddleware(db, "user", "cars"))
 		{
 			cars.GET("", carService.GetAllCars)
 			cars.GET("/:category_id", carService.GetAllCarsByCategory)
-			cars.POST("/rental", carService.RentalCar)
-			cars.POST("/pay/:rental_id", carService.PayRentalCar)
+			cars.POST("/quote", carService.QuoteRentalCar)
+			cars.POST("/rental", idempotent, carService.RentalCar)
+			cars.POST("/pay/:rental_id", idempotent, carService.PayRentalCar)
 			cars.POST("/return/:rental_id", carService.ReturnRentalCar)
 		}
+		branches := api.Group("/branches")
+		branches.Use(middleware.AuthMiddleware(db, "user", "branches"))
+		{
+			branches.GET("", branchService.GetAllBranches)
+		}
+		adminBranches := api.Group("/admin/branches")
+		adminBranches.Use(middleware.AuthMiddleware(db, "admin", "admin:branches"))
+		{
+			adminBranches.POST("", branchService.CreateBranch)
+			adminBranches.PUT("/:branch_id", branchService.UpdateBranch)
+			adminBranches.DELETE("/:branch_id", branchService.DeleteBranch)
+		}
+		adminPricingRules := api.Group("/admin/pricing-rules")
+		adminPricingRules.Use(middleware.AuthMiddleware(db, "admin", "admin:pricing-rules"))
+		{
+			adminPricingRules.GET("", pricingRuleService.GetAllPricingRules)
+			adminPricingRules.POST("", pricingRuleService.CreatePricingRule)
+			adminPricingRules.PUT("/:rule_id", pricingRuleService.UpdatePricingRule)
+			adminPricingRules.DELETE("/:rule_id", pricingRuleService.DeletePricingRule)
+		}
+		adminWebhooks := api.Group("/admin/webhooks")
+		adminWebhooks.Use(middleware.AuthMiddleware(db, "admin", "admin:webhooks"))
+		{
+			adminWebhooks.GET("", webhookService.GetAllWebhooks)
+			adminWebhooks.POST("", webhookService.CreateWebhook)
+			adminWebhooks.PUT("/:webhook_id", webhookService.UpdateWebhook)
+			adminWebhooks.DELETE("/:webhook_id", webhookService.DeleteWebhook)
+			adminWebhooks.GET("/:webhook_id/deliveries", webhookService.GetWebhookDeliveries)
+			adminWebhooks.GET("/:webhook_id/deliveries/:delivery_id", webhookService.GetWebhookDelivery)
+			adminWebhooks.POST("/:webhook_id/deliveries/:delivery_id/redeliver", webhookService.RedeliverWebhook)
+		}
+		adminPayments := api.Group("/admin/payments")
+		adminPayments.Use(middleware.AuthMiddleware(db, "admin", "admin:payments"))
+		{
+			adminPayments.GET("", adminService.GetAllPayments)
+		}
+		adminWalletTransactions := api.Group("/admin/wallet-transactions")
+		adminWalletTransactions.Use(middleware.AuthMiddleware(db, "admin", "admin:wallet-transactions"))
+		{
+			adminWalletTransactions.GET("", adminService.GetWalletTransactions)
+		}
+		adminLoginAttempts := api.Group("/admin/login-attempts")
+		adminLoginAttempts.Use(middleware.AuthMiddleware(db, "admin", "admin:login-attempts"))
+		{
+			adminLoginAttempts.GET("", adminService.GetLoginAttempts)
+		}
+		// API keys can't manage API keys
+		adminAPIKeys := api.Group("/admin/api-keys")
+		adminAPIKeys.Use(middleware.AuthMiddleware(db, "admin", ""))
+		{
+			adminAPIKeys.GET("", apiKeyService.GetAllAPIKeys)
+			adminAPIKeys.POST("", apiKeyService.CreateAPIKey)
+			adminAPIKeys.DELETE("/:api_key_id", apiKeyService.RevokeAPIKey)
+		}
+		adminReports := api.Group("/admin/reports")
+		adminReports.Use(middleware.AuthMiddleware(db, "admin", "admin:reports"))
+		{
+			adminReports.GET("/revenue", reportService.GetRevenue)
+			adminReports.GET("/revenue/categories", reportService.GetRevenueByCategory)
+			adminReports.GET("/revenue/cars", reportService.GetRevenueByCar)
+			adminReports.GET("/utilization", reportService.GetUtilization)
+			adminReports.GET("/rental-length", reportService.GetRentalLength)
+			adminReports.GET("/coupons", reportService.GetCouponUsage)
+			adminReports.GET("/top-customers", reportService.GetTopCustomers)
+		}
 		admin := api.Group("/admin/cars")
-		admin.Use(middleware.AuthMiddleware("admin"))
+		admin.Use(middleware.AuthMiddleware(db, "admin", "admin:cars"))
 		{
 			admin.POST("", adminService.CreateNewCar)
 			admin.PUT("/:car_id", adminService.UpdateCar)
 			admin.DELETE("/:car_id", adminService.DeleteCar)
+			admin.POST("/:car_id/images", carImageService.UploadCarImages)
+			admin.PATCH("/:car_id/images/:image_id", carImageService.UpdateCarImage)
+			admin.DELETE("/:car_id/images/:image_id", carImageService.DeleteCarImage)
 			admin.GET("/users", adminService.GetAllUsers)
 			admin.GET("/rental-history", adminService.GetRentalHistory)
 		}
@@ -0,0 +1,99 @@
+// Package safehttp sends requests to URLs entered by users and admins, such
+// as webhook endpoints, without letting them reach the internal network.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL     = errors.New("safehttp: url must be an absolute https url")
+	ErrBlockedAddress = errors.New("safehttp: address is not publicly routable")
+)
+
+// blockedPrefixes are the ranges net.IP has no predicate for.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// ValidateURL reports whether raw is an https URL that doesn't name a local
+// host. Host names are only resolved when dialing, see NewTransport.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && IsBlocked(addr) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// IsBlocked reports whether addr is loopback, private, link-local or
+// otherwise not publicly routable.
+func IsBlocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTransport returns a transport that refuses to connect to blocked
+// addresses. The check runs on the resolved address of every connection, so
+// DNS names pointing inside and redirects can't get around it.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || IsBlocked(addrPort.Addr()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would make the dialer check the proxy instead of the target
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return transport
+}
+
+// CheckRedirect keeps redirects on https, use it as http.Client.CheckRedirect.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("safehttp: stopped after 10 redirects")
+	}
+	if req.URL.Scheme != "https" {
+		return ErrInvalidURL
+	}
+	return nil
+}
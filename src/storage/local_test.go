@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage_PutAndDelete(t *testing.T) {
+	root := t.TempDir()
+	ls, err := NewLocalStorage(root, "/uploads/")
+	assert.Nil(t, err)
+
+	err = ls.Put(context.Background(), "cars/1/image.jpg", strings.NewReader("data"), "image/jpeg")
+	assert.Nil(t, err)
+
+	content, err := os.ReadFile(filepath.Join(root, "cars", "1", "image.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "data", string(content))
+	assert.Equal(t, "/uploads/cars/1/image.jpg", ls.URL("cars/1/image.jpg"))
+
+	assert.Nil(t, ls.Delete(context.Background(), "cars/1/image.jpg"))
+	assert.Nil(t, ls.Delete(context.Background(), "cars/1/image.jpg"))
+}
+
+func TestLocalStorage_rejectsInvalidKey(t *testing.T) {
+	ls, err := NewLocalStorage(t.TempDir(), "/uploads")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"", "../secret", "cars/../../secret", "/etc/passwd"} {
+		err := ls.Put(context.Background(), key, strings.NewReader("data"), "text/plain")
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("storage: invalid object key")
+
+// Storage persists binary objects such as car images. Keys are slash
+// separated relative paths, e.g. "cars/1/abc.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
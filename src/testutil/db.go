@@ -0,0 +1,26 @@
+// Package testutil has fixtures shared by the tests of several packages.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// DbMock returns a postgres gorm DB backed by sqlmock, the connection is
+// closed when the test ends.
+func DbMock(t testing.TB) (*gorm.DB, sqlmock.Sqlmock) {
+	sqldb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqldb.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqldb}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
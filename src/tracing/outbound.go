@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"p2-mini-project/src/mailer"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTransport starts a client span for every request sent through base to
+// service and propagates the trace to it.
+func NewTransport(base http.RoundTripper, service string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return service + " " + r.Method
+		}),
+		otelhttp.WithSpanOptions(trace.WithAttributes(attribute.String("peer.service", service))),
+	)
+}
+
+// Mailer starts a span for every email sent through the wrapped mailer, the
+// recipient is not recorded.
+type Mailer struct {
+	mailer.Mailer
+}
+
+func NewMailer(m mailer.Mailer) *Mailer {
+	return &Mailer{Mailer: m}
+}
+
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	ctx, span := tracer().Start(ctx, "smtp send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", "smtp"),
+			attribute.Int("mail.attachments", len(msg.Attachments)),
+		))
+	defer span.End()
+
+	err := m.Mailer.Send(ctx, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/mailer"
+	"p2-mini-project/src/testutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	defaultProvider, defaultPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(defaultProvider)
+		otel.SetTextMapPropagator(defaultPropagator)
+	})
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, kv := range span.Attributes() {
+		out[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return out
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "jaeger"})
+	assert.NotNil(t, err)
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := recorder(t)
+
+	db, mock := testutil.DbMock(t)
+	assert.Nil(t, db.Use(GormPlugin{}))
+
+	mock.ExpectQuery(`SELECT \* FROM "cars"`).WillReturnRows(sqlmock.NewRows([]string{"car_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "cars"`).WillReturnError(errors.New("connection refused"))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /cars")
+	var cars []map[string]interface{}
+	assert.Nil(t, db.WithContext(ctx).Table("cars").Find(&cars).Error)
+	assert.NotNil(t, db.WithContext(ctx).Table("cars").Find(&cars).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "gorm.query", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, `SELECT * FROM "cars"`, attributes(spans[0])["db.statement"])
+	assert.Equal(t, int64(1), attributes(spans[0])["db.rows_affected"])
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTransport(t *testing.T) {
+	recorder := recorder(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, "xendit")}
+	res, err := client.Get(server.URL + "/v2/invoices")
+	assert.Nil(t, err)
+	res.Body.Close()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "xendit GET", spans[0].Name())
+	assert.Contains(t, traceparent, spans[0].SpanContext().TraceID().String())
+}
+
+func TestMailer(t *testing.T) {
+	recorder := recorder(t)
+	memory := mailer.NewMemoryMailer()
+	m := NewMailer(memory)
+
+	assert.Nil(t, m.Send(context.Background(), mailer.Message{To: "budi@mail.com"}))
+	memory.Err = errors.New("connection refused")
+	assert.NotNil(t, m.Send(context.Background(), mailer.Message{To: "budi@mail.com"}))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "smtp send", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
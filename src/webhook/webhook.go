@@ -0,0 +1,218 @@
+// Package webhook delivers events to the webhook endpoints registered by
+// admins for partner systems. Publish records a delivery for every endpoint
+// subscribed to the event in the transaction of the event, the outbox worker
+// then posts it signed with the endpoint secret and retries it with backoff.
+// Every attempt is logged with the response of the endpoint.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"p2-mini-project/src/entity"
+	"p2-mini-project/src/outbox"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EventRentalCreated  = "rental.created"
+	EventPaymentSettled = "payment.settled"
+	EventRentalReturned = "rental.returned"
+	EventCarUpdated     = "car.updated"
+)
+
+// Events lists every event endpoints can subscribe to.
+var Events = []string{EventRentalCreated, EventPaymentSettled, EventRentalReturned, EventCarUpdated}
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const KindDelivery = "webhook_delivery"
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxResponseBody is how much of the endpoint response is logged.
+const maxResponseBody = 1024
+
+// Envelope is the body posted to endpoints.
+type Envelope struct {
+	ID        int             `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type deliveryMessage struct {
+	DeliveryID int `json:"delivery_id"`
+}
+
+// NewSecret returns a random signing secret for an endpoint.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-Webhook-Signature header of body sent at timestamp,
+// "t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">".
+// Endpoints should recompute it and reject old timestamps.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish records a delivery of event with data for every active endpoint
+// subscribed to it, the deliveries are sent once tx commits.
+func Publish(tx *gorm.DB, event string, data interface{}) error {
+	subscribed, err := json.Marshal([]string{event})
+	if err != nil {
+		return err
+	}
+	endpoints := []entity.WebhookEndpoint{}
+	if res := tx.Where("active = ? AND events @> ?", true, string(subscribed)).Find(&endpoints); res.Error != nil {
+		return res.Error
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range endpoints {
+		delivery := entity.WebhookDelivery{EndpointID: endpoint.ID, Event: event, Payload: payload, Status: StatusPending}
+		if res := tx.Create(&delivery); res.Error != nil {
+			return res.Error
+		}
+		if err := Enqueue(tx, delivery.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enqueue schedules the delivery to be sent by the outbox worker, it is used
+// to redeliver deliveries too.
+func Enqueue(tx *gorm.DB, delivery_id int) error {
+	return outbox.Enqueue(tx, KindDelivery, deliveryMessage{DeliveryID: delivery_id})
+}
+
+// DeliveryHandler sends deliveries with client and logs every attempt,
+// failed attempts are retried by the outbox.
+func DeliveryHandler(db *gorm.DB, client *http.Client) outbox.Handler {
+	return func(ctx context.Context, payload []byte) error {
+		msg := deliveryMessage{}
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+
+		delivery := entity.WebhookDelivery{}
+		res := db.WithContext(ctx).Preload("Endpoint").Where("webhook_delivery_id = ?", msg.DeliveryID).First(&delivery)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			// the endpoint was deleted with its deliveries
+			return nil
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		if !delivery.Endpoint.Active {
+			return db.WithContext(ctx).Model(&delivery).Updates(map[string]interface{}{"status": StatusFailed, "last_error": "endpoint is disabled"}).Error
+		}
+
+		attempt := Deliver(ctx, client, &delivery, time.Now())
+		return record(ctx, db, &delivery, attempt)
+	}
+}
+
+// Deliver posts delivery to its endpoint and returns the attempt, the
+// attempt has an Error when the endpoint doesn't respond with 2xx.
+func Deliver(ctx context.Context, client *http.Client, delivery *entity.WebhookDelivery, now time.Time) entity.WebhookAttempt {
+	attempt := entity.WebhookAttempt{DeliveryID: delivery.ID}
+
+	body, err := json.Marshal(Envelope{ID: delivery.ID, Event: delivery.Event, CreatedAt: delivery.CreatedAt, Data: json.RawMessage(delivery.Payload)})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderSignature, Sign(delivery.Endpoint.Secret, now, body))
+
+	res, err := client.Do(req)
+	attempt.Duration = time.Since(now).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	attempt.ResponseStatus = res.StatusCode
+	attempt.ResponseBody = string(response)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("endpoint responded %s", res.Status)
+	}
+	return attempt
+}
+
+// record logs attempt and updates the delivery, the returned error makes the
+// outbox retry the delivery.
+func record(ctx context.Context, db *gorm.DB, delivery *entity.WebhookDelivery, attempt entity.WebhookAttempt) error {
+	updates := map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"response_status": attempt.ResponseStatus,
+		"last_error":      attempt.Error,
+	}
+	if attempt.Error == "" {
+		updates["status"], updates["delivered_at"] = StatusSucceeded, time.Now()
+	} else {
+		updates["status"] = StatusFailed
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if res := tx.Create(&attempt); res.Error != nil {
+			return res.Error
+		}
+		return tx.Model(delivery).Updates(updates).Error
+	})
+	if err != nil {
+		return fmt.Errorf("record delivery %d: %w", delivery.ID, err)
+	}
+
+	if attempt.Error != "" {
+		return errors.New(attempt.Error)
+	}
+	return nil
+}
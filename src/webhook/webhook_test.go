@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"p2-mini-project/src/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1713430800, 0)
+
+	signature := Sign("whsec_test", at, []byte(`{"id":1}`))
+	assert.Equal(t, "t=1713430800,v1=", signature[:16])
+	assert.Equal(t, signature, Sign("whsec_test", at, []byte(`{"id":1}`)))
+	assert.NotEqual(t, signature, Sign("whsec_other", at, []byte(`{"id":1}`)))
+	assert.NotEqual(t, signature, Sign("whsec_test", at.Add(time.Second), []byte(`{"id":1}`)))
+}
+
+func TestDeliver(t *testing.T) {
+	now := time.Unix(1713430800, 0)
+	status := http.StatusOK
+
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	delivery := &entity.WebhookDelivery{
+		ID:       3,
+		Event:    EventRentalCreated,
+		Payload:  []byte(`{"rental_id":7}`),
+		Endpoint: entity.WebhookEndpoint{URL: server.URL, Secret: "whsec_test"},
+	}
+
+	attempt := Deliver(context.Background(), server.Client(), delivery, now)
+	assert.Equal(t, "", attempt.Error)
+	assert.Equal(t, http.StatusOK, attempt.ResponseStatus)
+	assert.Equal(t, "ok", attempt.ResponseBody)
+	assert.Equal(t, EventRentalCreated, header.Get(HeaderEvent))
+	assert.Equal(t, "3", header.Get(HeaderDelivery))
+	assert.Equal(t, Sign("whsec_test", now, body), header.Get(HeaderSignature))
+
+	envelope := Envelope{}
+	assert.Nil(t, json.Unmarshal(body, &envelope))
+	assert.Equal(t, 3, envelope.ID)
+	assert.JSONEq(t, `{"rental_id":7}`, string(envelope.Data))
+
+	status = http.StatusBadGateway
+	attempt = Deliver(context.Background(), server.Client(), delivery, now)
+	assert.Equal(t, http.StatusBadGateway, attempt.ResponseStatus)
+	assert.NotEmpty(t, attempt.Error)
+}